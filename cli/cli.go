@@ -1,61 +1,405 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/config"
+)
+
+const Version = "1.0.0"
+
+// Subcommand names
+const (
+	CommandGenerate  = "generate"
+	CommandDetect    = "detect"
+	CommandValidate  = "validate"
+	CommandDiff      = "diff"
+	CommandInit      = "init"
+	CommandImport    = "import"
+	CommandLayouts   = "layouts"
+	CommandApply     = "apply"
+	CommandWorkspace = "workspace"
+	CommandSchema    = "schema"
+	CommandMigrate   = "migrate"
+	CommandConvert   = "convert"
+	CommandRecord    = "record"
 )
 
+// Output formats for commands that print structured data
 const (
-	Version           = "1.0.0"
-	DefaultLayoutName = "two_mon"
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+var (
+	// ErrHelp is returned by Parse after help information has been printed
+	ErrHelp = errors.New("help requested")
+	// ErrVersion is returned by Parse after version information has been printed
+	ErrVersion = errors.New("version requested")
+	// ErrPaths is returned by Parse after the file locations have been printed
+	ErrPaths = errors.New("paths requested")
 )
 
 // Args represents the parsed command-line arguments
 type Args struct {
+	Command     string
 	ConfigPath  string
 	OutputPath  string
 	LayoutName  string
+	Format      string
+	Force       bool
+	Import      bool
+	ImportPath  string
+	Restart     bool
 	ShowVersion bool
 	ShowHelp    bool
+	// PrintPaths shows where configuration files are searched for and written
+	PrintPaths bool
+	// Workspace is the workspace number within the focused output's range
+	Workspace int
+	PerOutput int
+	Move      bool
+	// Lenient turns unknown configuration keys into warnings
+	Lenient bool
+	// Overrides set configuration values given with --set, in order
+	Overrides []config.Override
+	// MonitorsFrom is a file to read the monitors from instead of detecting them
+	MonitorsFrom string
+	// Offline renders for the monitors saved by the last detection
+	Offline bool
+	// DryRun shows changes instead of writing them
+	DryRun bool
+	// Display is the X display to record
+	Display string
+	// Fixture is a recorded RandR state to replay instead of querying X
+	Fixture string
+}
+
+// command describes a subcommand and its flags
+type command struct {
+	name        string
+	summary     string
+	description []string
+	examples    []example
+	flagSet     *flag.FlagSet
+	// hasLayout marks commands that render a layout
+	hasLayout bool
+	// hasOutput marks commands that take an output path
+	hasOutput bool
+	// argument names the positional argument the command takes, if any
+	argument string
+	// loadsConfig marks commands that load the configuration file
+	loadsConfig bool
 }
 
+// example is a usage example shown in command help
+type example struct {
+	comment string
+	args    string
+}
+
+// LayoutSource returns the layouts defined in the config file at configPath
+// (or the default location if configPath is empty) for help output
+type LayoutSource func(configPath string) ([]config.LayoutSummary, error)
+
 // CLI handles command-line interface operations
 type CLI struct {
-	flagSet *flag.FlagSet
-	args    *Args
+	commands     []*command
+	args         *Args
+	out          io.Writer
+	layoutSource LayoutSource
 }
 
 // NewCLI creates a new CLI handler
 func NewCLI() *CLI {
-	args := &Args{}
-	flagSet := flag.NewFlagSet("i3-config-generator", flag.ExitOnError)
+	cli := &CLI{
+		args:         &Args{},
+		out:          os.Stdout,
+		layoutSource: config.NewLoader("").PeekLayouts,
+	}
+
+	cli.addCommand(&command{
+		name:    CommandGenerate,
+		summary: "Generate the i3 configuration (default when no command is given)",
+		description: []string{
+			"Generates i3 window manager configuration files based on detected monitors",
+			"and user-defined layouts. Supports multiple screen configurations and",
+			"automatically maps workspaces to the appropriate displays.",
+		},
+		examples: []example{
+			{"Generate config with default settings", ""},
+			{"Generate config for single monitor setup", "--layout one_mon"},
+			{"Use custom config and output locations", "--config ~/my-config.yaml --output ~/my-i3-config"},
+			{"Generate config for no external monitors", "-l no_mon -o ~/.i3/laptop-config"},
+			{"Generate over SSH for the monitors detected last time", "--offline"},
+			{"Reproduce a colleague's output from their monitor report", "--monitors-from monitors.yaml"},
+		},
+		hasLayout:   true,
+		hasOutput:   true,
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandDetect,
+		summary: "Show the monitors detected on this machine",
+		description: []string{
+			"Queries the X server through RandR and prints every output with its",
+			"connection state, primary flag, geometry, modes, physical size and EDID",
+			"identity, together with the display role each monitor is assigned to.",
+		},
+		examples: []example{
+			{"Show the monitor inventory as a table", ""},
+			{"Attach the full inventory to a bug report", "--format yaml > monitors.yaml"},
+			{"Replay a fixture attached to a bug report", "--fixture docked.yaml"},
+		},
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandRecord,
+		summary: "Record the RandR state of the display as a test fixture",
+		description: []string{
+			"Captures the screen resources, outputs, CRTCs and EDID data of the X display",
+			"exactly as RandR reports them and writes them as YAML to stdout or to the",
+			"file given with --output. 'detect --fixture' and the tests replay such",
+			"fixtures without an X server, so attach one to bug reports about detection.",
+		},
+		examples: []example{
+			{"Record the current display for a bug report", "-o docked.yaml"},
+			{"Record another X display", "--display :1"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandValidate,
+		summary: "Check the configuration file for errors",
+		description: []string{
+			"Loads and validates the configuration file without rendering anything.",
+		},
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandDiff,
+		summary: "Show how the generated configuration differs from the current one",
+		description: []string{
+			"Renders the configuration and prints a unified diff against the existing",
+			"output file. Secret values are never shown.",
+		},
+		hasLayout:   true,
+		hasOutput:   true,
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandInit,
+		summary: "Write a starter configuration file",
+		description: []string{
+			"Detects the connected monitors and writes a starter configuration with",
+			"layouts for the current output set to the default config location, or to",
+			"the path given with --config. Existing files are only replaced with --force.",
+			"With --import, settings such as the mod key, font, terminal and startup",
+			"programs are taken over from an existing i3 config.",
+		},
+		examples: []example{
+			{"Write a starter config for the connected monitors", ""},
+			{"Take over settings from ~/.i3/config", "--import"},
+			{"Replace the config, importing from another file", "--force --import-from ~/dotfiles/i3/config"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandImport,
+		summary: "Convert an existing i3 config into a configuration file",
+		description: []string{
+			"Reads a hand-written i3 config (following its include directives) and",
+			"writes a configuration file like 'init', mapping the settings it",
+			"understands and keeping everything else as raw config in extra.end_of_file.",
+			"Bindings the generated config already defines are dropped with a warning.",
+		},
+		examples: []example{
+			{"Import ~/.i3/config into the default config location", ""},
+			{"Import another file, replacing an existing config", "--from ~/dotfiles/i3/config --force"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandLayouts,
+		summary: "List the layouts defined in the configuration",
+		description: []string{
+			"Prints all screen layouts defined in the configuration file with their",
+			"descriptions, marking the one used when --layout is not given.",
+		},
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandApply,
+		summary: "Generate the configuration and reload i3",
+		description: []string{
+			"Generates the configuration like 'generate' and then asks the running i3",
+			"instance to reload it (or restart with --restart).",
+		},
+		hasLayout:   true,
+		hasOutput:   true,
+		loadsConfig: true,
+	})
+	cli.addCommand(&command{
+		name:    CommandSchema,
+		summary: "Print the JSON Schema of the configuration file",
+		description: []string{
+			"Prints a JSON Schema describing the configuration file, for editors that",
+			"complete and check YAML against a schema. Monitor roles and the layout",
+			"names defined in the configuration are listed as the accepted values.",
+			"With yaml-language-server, point to it from the first line of config.yaml:",
+			"  # yaml-language-server: $schema=./config.schema.json",
+		},
+		examples: []example{
+			{"Write the schema next to the configuration", "> ~/.config/i3-config-generator/config.schema.json"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandMigrate,
+		summary: "Upgrade the configuration file to the current format",
+		description: []string{
+			"Rewrites the configuration file in the current format version, moving",
+			"settings that have been renamed or deprecated. Comments are kept, though",
+			"blank lines within sections may be lost. Older files are also upgraded in",
+			"memory whenever they are loaded, with a warning.",
+		},
+		examples: []example{
+			{"Show the changes without writing them", "--dry-run"},
+			{"Upgrade a config outside the default location", "--config ~/dotfiles/i3cg.yaml"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandConvert,
+		summary: "Convert the configuration file between YAML, JSON and TOML",
+		description: []string{
+			"Reads the configuration file and writes it in another format, to stdout or",
+			"to the file given with --output. The format is taken from --to or from the",
+			"extension of the output file. Comments are only kept when writing YAML.",
+		},
+		examples: []example{
+			{"Print the configuration as TOML", "--to toml"},
+			{"Write a JSON copy next to the configuration", "-o ~/.config/i3-config-generator/config.json"},
+		},
+	})
+	cli.addCommand(&command{
+		name:    CommandWorkspace,
+		summary: "Switch to a workspace of the focused output",
+		description: []string{
+			"Used by the key bindings of the per_output workspace scheme, where each",
+			"output has its own range of workspace numbers. Switches to (or with --move,",
+			"moves the focused container to) workspace N of the focused output's range.",
+		},
+		examples: []example{
+			{"Switch to the third workspace of the focused output", "3"},
+			{"Move the focused window to its output's first workspace", "--move 1"},
+		},
+		argument: "N",
+	})
+
+	return cli
+}
+
+// addCommand registers a subcommand and defines its flags
+func (cli *CLI) addCommand(cmd *command) {
+	args := cli.args
+	flagSet := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
 
 	// Configuration file location flag
 	flagSet.StringVar(&args.ConfigPath, "config", "",
-		"Path to configuration file (default: $HOME/.config/i3-config-generator/config.yaml)")
+		"Path to configuration file (default: search $XDG_CONFIG_HOME and $XDG_CONFIG_DIRS, see --print-paths)")
 	flagSet.StringVar(&args.ConfigPath, "c", "",
 		"Path to configuration file (shorthand)")
 
-	// Output file location flag
-	defaultOutput := getDefaultOutputPath()
-	flagSet.StringVar(&args.OutputPath, "output", defaultOutput,
-		"Output file path for generated i3 configuration")
-	flagSet.StringVar(&args.OutputPath, "o", defaultOutput,
-		"Output file path for generated i3 configuration (shorthand)")
-
-	// Layout selection flag
-	flagSet.StringVar(&args.LayoutName, "layout", DefaultLayoutName,
-		"Screen layout to use (two_mon, one_mon, no_mon)")
-	flagSet.StringVar(&args.LayoutName, "l", DefaultLayoutName,
-		"Screen layout to use (shorthand)")
-
-	// Version flag
-	flagSet.BoolVar(&args.ShowVersion, "version", false,
-		"Show version information")
-	flagSet.BoolVar(&args.ShowVersion, "v", false,
-		"Show version information (shorthand)")
+	if cmd.loadsConfig {
+		flagSet.BoolVar(&args.Lenient, "lenient", false,
+			"Warn about unknown configuration keys instead of failing")
+		flagSet.Var(overrideFlag{&args.Overrides}, "set",
+			"Override a configuration value, as key.path=value (repeatable; also I3CG_KEY__PATH=value)")
+	}
+
+	if cmd.hasOutput {
+		// Output file location flag
+		defaultOutput := getDefaultOutputPath()
+		flagSet.StringVar(&args.OutputPath, "output", defaultOutput,
+			"Output file path for generated i3 configuration")
+		flagSet.StringVar(&args.OutputPath, "o", defaultOutput,
+			"Output file path for generated i3 configuration (shorthand)")
+	}
+
+	if cmd.hasLayout {
+		// Layout selection flag, validated against the config once it is loaded
+		flagSet.StringVar(&args.LayoutName, "layout", "",
+			"Screen layout to use (default: default_layout from the config, or "+config.DefaultLayoutName+")")
+		flagSet.StringVar(&args.LayoutName, "l", "",
+			"Screen layout to use (shorthand)")
+
+		// Monitor sources for machines without access to the X server
+		flagSet.StringVar(&args.MonitorsFrom, "monitors-from", "",
+			"Read the monitors from a file (YAML or JSON, e.g. from 'detect --format yaml') instead of detecting them")
+		flagSet.BoolVar(&args.Offline, "offline", false,
+			"Use the monitors saved by the last successful detection instead of querying X")
+	}
+
+	switch cmd.name {
+	case CommandGenerate:
+		// Version flag, kept on generate for backward compatibility
+		flagSet.BoolVar(&args.ShowVersion, "version", false,
+			"Show version information")
+		flagSet.BoolVar(&args.ShowVersion, "v", false,
+			"Show version information (shorthand)")
+	case CommandDetect:
+		flagSet.StringVar(&args.Format, "format", FormatTable,
+			"Output format (table, json, yaml)")
+		flagSet.StringVar(&args.Format, "f", FormatTable,
+			"Output format (shorthand)")
+		flagSet.StringVar(&args.Fixture, "fixture", "",
+			"Replay a fixture written by 'record' instead of querying the X server")
+	case CommandRecord:
+		flagSet.StringVar(&args.Display, "display", "",
+			"X display to record (default: $DISPLAY, else :0)")
+		flagSet.StringVar(&args.OutputPath, "output", "",
+			"File to write the fixture to (default: stdout)")
+		flagSet.StringVar(&args.OutputPath, "o", "",
+			"File to write the fixture to (shorthand)")
+	case CommandInit:
+		flagSet.BoolVar(&args.Force, "force", false,
+			"Overwrite an existing configuration file")
+		flagSet.BoolVar(&args.Import, "import", false,
+			"Import settings from the existing i3 config")
+		flagSet.StringVar(&args.ImportPath, "import-from", "",
+			"Import settings from the given i3 config file")
+	case CommandImport:
+		flagSet.StringVar(&args.ImportPath, "from", "",
+			"i3 config file to import (default: $XDG_CONFIG_HOME/i3/config if present, else ~/.i3/config)")
+		flagSet.BoolVar(&args.Force, "force", false,
+			"Overwrite an existing configuration file")
+	case CommandMigrate:
+		flagSet.BoolVar(&args.DryRun, "dry-run", false,
+			"Print the changes as a diff instead of writing the file")
+	case CommandConvert:
+		flagSet.StringVar(&args.Format, "to", "",
+			"Format to write ("+strings.Join(config.Formats, ", ")+"; default: from the output file extension)")
+		flagSet.StringVar(&args.OutputPath, "output", "",
+			"File to write the converted configuration to (default: stdout)")
+		flagSet.StringVar(&args.OutputPath, "o", "",
+			"File to write the converted configuration to (shorthand)")
+	case CommandApply:
+		flagSet.BoolVar(&args.Restart, "restart", false,
+			"Restart i3 instead of reloading the configuration")
+	case CommandWorkspace:
+		flagSet.IntVar(&args.PerOutput, "per-output", config.DefaultWorkspacesPerOutput,
+			"Number of workspaces in each output's range")
+		flagSet.BoolVar(&args.Move, "move", false,
+			"Move the focused container instead of switching workspace")
+	}
+
+	flagSet.BoolVar(&args.PrintPaths, "print-paths", false,
+		"Show where configuration files are searched for and written, and exit")
 
 	// Help flag
 	flagSet.BoolVar(&args.ShowHelp, "help", false,
@@ -63,38 +407,72 @@ func NewCLI() *CLI {
 	flagSet.BoolVar(&args.ShowHelp, "h", false,
 		"Show help information (shorthand)")
 
-	return &CLI{
-		flagSet: flagSet,
-		args:    args,
-	}
+	cmd.flagSet = flagSet
+	cli.commands = append(cli.commands, cmd)
+}
+
+// SetOutput sets where help and version information is written (default: stdout)
+func (cli *CLI) SetOutput(w io.Writer) {
+	cli.out = w
+}
+
+// SetLayoutSource sets how the layouts listed in help output are looked up
+func (cli *CLI) SetLayoutSource(source LayoutSource) {
+	cli.layoutSource = source
 }
 
 // Parse parses the command-line arguments
+// It returns ErrHelp or ErrVersion after printing the requested information,
+// so callers can exit successfully without treating it as a failure
 func (cli *CLI) Parse(osArgs []string) (*Args, error) {
-	// Custom usage function
-	cli.flagSet.Usage = cli.printUsage
+	rest := osArgs[1:]
 
-	if err := cli.flagSet.Parse(osArgs[1:]); err != nil {
-		return nil, err
+	// A bare invocation (or one starting with flags) behaves like generate
+	name := CommandGenerate
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		name = rest[0]
+		rest = rest[1:]
 	}
 
-	// Handle special flags first
-	if cli.args.ShowVersion {
+	switch name {
+	case "help":
+		if len(rest) > 0 {
+			if cmd := cli.lookup(rest[0]); cmd != nil {
+				cli.printCommandUsage(cmd)
+				return nil, ErrHelp
+			}
+		}
+		cli.printUsage()
+		return nil, ErrHelp
+	case "version":
 		cli.printVersion()
-		os.Exit(0)
+		return nil, ErrVersion
 	}
 
-	if cli.args.ShowHelp {
-		cli.printUsage()
-		os.Exit(0)
+	cmd := cli.lookup(name)
+	if cmd == nil {
+		return nil, fmt.Errorf("unknown command: %s (run '%s help' for a list of commands)", name, programName())
 	}
+	cli.args.Command = cmd.name
 
-	// Validate layout name
-	if !isValidLayout(cli.args.LayoutName) {
-		return nil, fmt.Errorf("invalid layout name: %s (valid options: two_mon, one_mon, no_mon)", cli.args.LayoutName)
+	// Commands share fields of Args, so start from this command's defaults
+	cmd.flagSet.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() != f.DefValue {
+			f.Value.Set(f.DefValue)
+		}
+	})
+	if err := cmd.flagSet.Parse(rest); err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.name, err)
+	}
+	positional := cmd.flagSet.Args()
+	if cmd.argument != "" && len(positional) > 0 {
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("%s: unexpected argument: %s", cmd.name, positional[0])
 	}
 
-	// Expand paths
+	// Expand the config path early so help can list the layouts it defines
 	if cli.args.ConfigPath != "" {
 		expanded, err := expandPath(cli.args.ConfigPath)
 		if err != nil {
@@ -103,63 +481,319 @@ func (cli *CLI) Parse(osArgs []string) (*Args, error) {
 		cli.args.ConfigPath = expanded
 	}
 
-	expanded, err := expandPath(cli.args.OutputPath)
-	if err != nil {
-		return nil, fmt.Errorf("invalid output path: %w", err)
+	// Handle special flags first
+	if cli.args.ShowVersion {
+		cli.printVersion()
+		return nil, ErrVersion
+	}
+
+	if cli.args.ShowHelp {
+		if len(osArgs) > 1 && strings.HasPrefix(osArgs[1], "-") {
+			// Bare "--help" shows the overview of all commands
+			cli.printUsage()
+		} else {
+			cli.printCommandUsage(cmd)
+		}
+		return nil, ErrHelp
+	}
+
+	if cli.args.PrintPaths {
+		cli.printPaths(cmd)
+		return nil, ErrPaths
+	}
+
+	if cmd.name == CommandDetect {
+		switch cli.args.Format {
+		case FormatTable, FormatJSON, FormatYAML:
+		default:
+			return nil, fmt.Errorf("invalid format: %s (valid options: %s, %s, %s)",
+				cli.args.Format, FormatTable, FormatJSON, FormatYAML)
+		}
+	}
+
+	if cmd.name == CommandConvert {
+		if err := cli.parseConvert(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.name == CommandDetect && cli.args.Fixture != "" {
+		expanded, err := expandPath(cli.args.Fixture)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture path: %w", err)
+		}
+		cli.args.Fixture = expanded
+	}
+
+	if cmd.name == CommandRecord && cli.args.OutputPath != "" {
+		expanded, err := expandPath(cli.args.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+		cli.args.OutputPath = expanded
+	}
+
+	if cmd.name == CommandWorkspace {
+		if err := cli.parseWorkspace(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.name == CommandImport || (cmd.name == CommandInit && (cli.args.Import || cli.args.ImportPath != "")) {
+		cli.args.Import = true
+		if cli.args.ImportPath == "" {
+			cli.args.ImportPath = getDefaultOutputPath()
+		}
+		expanded, err := expandPath(cli.args.ImportPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import path: %w", err)
+		}
+		cli.args.ImportPath = expanded
+	}
+
+	if cmd.hasLayout && cli.args.MonitorsFrom != "" {
+		if cli.args.Offline {
+			return nil, fmt.Errorf("%s: --monitors-from and --offline cannot be used together", cmd.name)
+		}
+		expanded, err := expandPath(cli.args.MonitorsFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid monitors path: %w", err)
+		}
+		cli.args.MonitorsFrom = expanded
+	}
+
+	// Expand paths
+	if cmd.hasOutput {
+		expanded, err := expandPath(cli.args.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+		cli.args.OutputPath = expanded
 	}
-	cli.args.OutputPath = expanded
 
 	return cli.args, nil
 }
 
+// overrideFlag collects repeated --set flags
+type overrideFlag struct {
+	overrides *[]config.Override
+}
+
+// String returns the flag value for help output, which has no default
+func (f overrideFlag) String() string {
+	return ""
+}
+
+// Set adds the override given as key.path=value
+func (f overrideFlag) Set(value string) error {
+	override, err := config.ParseOverride(value)
+	if err != nil {
+		return err
+	}
+	*f.overrides = append(*f.overrides, override)
+	return nil
+}
+
+// parseConvert checks the target format of the convert command, taking it from
+// the output file extension when --to is not given
+func (cli *CLI) parseConvert(cmd *command) error {
+	if cli.args.OutputPath != "" {
+		expanded, err := expandPath(cli.args.OutputPath)
+		if err != nil {
+			return fmt.Errorf("invalid output path: %w", err)
+		}
+		cli.args.OutputPath = expanded
+	}
+	if cli.args.Format == "" {
+		if cli.args.OutputPath == "" {
+			return fmt.Errorf("%s: --to is required when writing to stdout", cmd.name)
+		}
+		cli.args.Format = config.FormatOf(cli.args.OutputPath)
+	}
+	for _, format := range config.Formats {
+		if cli.args.Format == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: invalid format: %s (valid options: %s)", cmd.name, cli.args.Format, strings.Join(config.Formats, ", "))
+}
+
+// parseWorkspace checks the workspace number given to the workspace command
+func (cli *CLI) parseWorkspace(cmd *command) error {
+	if cmd.flagSet.NArg() == 0 {
+		return fmt.Errorf("%s: missing workspace number", cmd.name)
+	}
+	if cli.args.PerOutput < 1 {
+		return fmt.Errorf("%s: --per-output must be positive", cmd.name)
+	}
+	number, err := strconv.Atoi(cmd.flagSet.Arg(0))
+	if err != nil || number < 1 || number > cli.args.PerOutput {
+		return fmt.Errorf("%s: invalid workspace number: %s (expected 1-%d)", cmd.name, cmd.flagSet.Arg(0), cli.args.PerOutput)
+	}
+	cli.args.Workspace = number
+	return nil
+}
+
+// lookup returns the command with the given name, or nil if there is none
+func (cli *CLI) lookup(name string) *command {
+	for _, cmd := range cli.commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
 // printVersion prints version information
 func (cli *CLI) printVersion() {
-	fmt.Printf("i3-config-generator version %s\n", Version)
-	fmt.Println("A dynamic i3 window manager configuration generator")
+	fmt.Fprintf(cli.out, "i3-config-generator version %s\n", Version)
+	fmt.Fprintln(cli.out, "A dynamic i3 window manager configuration generator")
 }
 
-// printUsage prints usage information
+// printUsage prints the overview of all commands
 func (cli *CLI) printUsage() {
-	fmt.Printf("i3-config-generator v%s - Dynamic i3 configuration generator\n\n", Version)
-	fmt.Println("USAGE:")
-	fmt.Printf("  %s [OPTIONS]\n\n", os.Args[0])
+	out := cli.out
+	fmt.Fprintf(out, "i3-config-generator v%s - Dynamic i3 configuration generator\n\n", Version)
+	fmt.Fprintln(out, "USAGE:")
+	fmt.Fprintf(out, "  %s [COMMAND] [OPTIONS]\n\n", programName())
+
+	fmt.Fprintln(out, "COMMANDS:")
+	for _, cmd := range cli.commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "  %-10s %s\n", "help", "Show help for a command")
+	fmt.Fprintf(out, "  %-10s %s\n", "version", "Show version information")
+	fmt.Fprintln(out)
 
-	fmt.Println("DESCRIPTION:")
-	fmt.Println("  Generates i3 window manager configuration files based on detected monitors")
-	fmt.Println("  and user-defined layouts. Supports multiple screen configurations and")
-	fmt.Println("  automatically maps workspaces to the appropriate displays.")
-	fmt.Println()
+	fmt.Fprintf(out, "Run '%s help COMMAND' for the options of a command.\n\n", programName())
 
-	fmt.Println("OPTIONS:")
-	cli.flagSet.PrintDefaults()
-	fmt.Println()
+	cli.printLayouts()
+
+	fmt.Fprintln(out, "CONFIGURATION:")
+	fmt.Fprintln(out, "  Default config location: $XDG_CONFIG_HOME/i3-config-generator/config.yaml,")
+	fmt.Fprintln(out, "    layered on the same file in each of $XDG_CONFIG_DIRS (default /etc/xdg)")
+	fmt.Fprintln(out, "  Default output location: $XDG_CONFIG_HOME/i3/config if present, else $HOME/.i3/config")
+	fmt.Fprintln(out, "  Run with --print-paths to see how these resolve")
+}
+
+// printPaths prints the configuration files that would be loaded and the file
+// the generated configuration is written to
+func (cli *CLI) printPaths(cmd *command) {
+	out := cli.out
+	if cli.args.ConfigPath != "" {
+		fmt.Fprintln(out, "Configuration file (from --config):")
+		fmt.Fprintf(out, "  %s\n", cli.args.ConfigPath)
+	} else {
+		fmt.Fprintln(out, "Configuration search path (later files override earlier ones):")
+		for _, dir := range config.NewLoader("").SearchPath() {
+			kind := "user"
+			if dir.System {
+				kind = "system"
+			}
+			if dir.Path != "" {
+				fmt.Fprintf(out, "  %-6s %s\n", kind, dir.Path)
+			} else {
+				fmt.Fprintf(out, "  %-6s %s (no config file)\n", kind, dir.Dir)
+			}
+		}
+	}
+
+	output := getDefaultOutputPath()
+	if cmd.hasOutput {
+		if expanded, err := expandPath(cli.args.OutputPath); err == nil {
+			output = expanded
+		}
+	}
+	fmt.Fprintln(out, "Generated i3 configuration:")
+	fmt.Fprintf(out, "  %s\n", output)
+	fmt.Fprintln(out, "Last detected monitors (for --offline):")
+	fmt.Fprintf(out, "  %s\n", config.MonitorStatePath())
+}
+
+// printCommandUsage prints usage information for a single command
+func (cli *CLI) printCommandUsage(cmd *command) {
+	out := cli.out
+	fmt.Fprintf(out, "i3-config-generator v%s - %s\n\n", Version, cmd.summary)
+	fmt.Fprintln(out, "USAGE:")
+	if cmd.argument != "" {
+		fmt.Fprintf(out, "  %s %s [OPTIONS] %s\n\n", programName(), cmd.name, cmd.argument)
+	} else {
+		fmt.Fprintf(out, "  %s %s [OPTIONS]\n\n", programName(), cmd.name)
+	}
 
-	fmt.Println("EXAMPLES:")
-	fmt.Printf("  # Generate config with default settings\n")
-	fmt.Printf("  %s\n\n", os.Args[0])
+	fmt.Fprintln(out, "DESCRIPTION:")
+	for _, line := range cmd.description {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintln(out, "OPTIONS:")
+	cmd.flagSet.SetOutput(out)
+	cmd.flagSet.PrintDefaults()
+	cmd.flagSet.SetOutput(io.Discard)
+	fmt.Fprintln(out)
+
+	if len(cmd.examples) > 0 {
+		fmt.Fprintln(out, "EXAMPLES:")
+		for _, ex := range cmd.examples {
+			fmt.Fprintf(out, "  # %s\n", ex.comment)
+			if ex.args == "" {
+				fmt.Fprintf(out, "  %s %s\n\n", programName(), cmd.name)
+			} else {
+				fmt.Fprintf(out, "  %s %s %s\n\n", programName(), cmd.name, ex.args)
+			}
+		}
+	}
 
-	fmt.Printf("  # Generate config for single monitor setup\n")
-	fmt.Printf("  %s --layout one_mon\n\n", os.Args[0])
+	if cmd.hasLayout {
+		cli.printLayouts()
+	}
+}
 
-	fmt.Printf("  # Use custom config and output locations\n")
-	fmt.Printf("  %s --config ~/my-config.yaml --output ~/my-i3-config\n\n", os.Args[0])
+// printLayouts prints the layouts defined in the configuration
+func (cli *CLI) printLayouts() {
+	fmt.Fprintln(cli.out, "LAYOUTS:")
+	defer fmt.Fprintln(cli.out)
 
-	fmt.Printf("  # Generate config for no external monitors\n")
-	fmt.Printf("  %s -l no_mon -o ~/.i3/laptop-config\n\n", os.Args[0])
+	layouts, err := cli.layoutSource(cli.args.ConfigPath)
+	if err != nil {
+		fmt.Fprintf(cli.out, "  (unavailable: %v)\n", err)
+		return
+	}
+	if len(layouts) == 0 {
+		fmt.Fprintln(cli.out, "  (none defined in the configuration)")
+		return
+	}
 
-	fmt.Println("LAYOUTS:")
-	fmt.Println("  two_mon  - Two external monitors + laptop screen (default)")
-	fmt.Println("  one_mon  - One external monitor + laptop screen")
-	fmt.Println("  no_mon   - Laptop screen only")
-	fmt.Println()
+	width := 0
+	for _, layout := range layouts {
+		width = max(width, len(layout.Name))
+	}
+	for _, layout := range layouts {
+		line := fmt.Sprintf("  %-*s", width, layout.Name)
+		if layout.Description != "" {
+			line += " - " + layout.Description
+		}
+		if layout.Default {
+			line += " (default)"
+		}
+		fmt.Fprintln(cli.out, strings.TrimRight(line, " "))
+	}
+}
 
-	fmt.Println("CONFIGURATION:")
-	fmt.Println("  Default config location: $HOME/.config/i3-config-generator/config.yaml")
-	fmt.Println("  Default output location: $HOME/.i3/config")
+// programName returns the name the program was invoked as
+func programName() string {
+	return filepath.Base(os.Args[0])
 }
 
-// getDefaultOutputPath returns the default output path for i3 configuration
+// getDefaultOutputPath returns the i3 config i3 itself reads first:
+// $XDG_CONFIG_HOME/i3/config if present, otherwise ~/.i3/config
+// It is also the file init imports from by default
 func getDefaultOutputPath() string {
+	xdgPath := filepath.Join(config.ConfigHome(), "i3", "config")
+	if _, err := os.Stat(xdgPath); err == nil {
+		return xdgPath
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return ".i3/config" // Fallback
@@ -193,13 +827,3 @@ func expandPath(path string) (string, error) {
 
 	return absPath, nil
 }
-
-// isValidLayout checks if the layout name is valid
-func isValidLayout(layout string) bool {
-	validLayouts := map[string]bool{
-		"two_mon": true,
-		"one_mon": true,
-		"no_mon":  true,
-	}
-	return validLayouts[layout]
-}
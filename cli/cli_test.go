@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/a7d-corp/i3-config-generator-go/config"
 )
 
 func TestCLI_Parse_DefaultValues(t *testing.T) {
@@ -20,8 +25,9 @@ func TestCLI_Parse_DefaultValues(t *testing.T) {
 		t.Errorf("Expected empty ConfigPath, got %s", args.ConfigPath)
 	}
 
-	if args.LayoutName != DefaultLayoutName {
-		t.Errorf("Expected layout %s, got %s", DefaultLayoutName, args.LayoutName)
+	// An empty layout lets the config choose its default layout
+	if args.LayoutName != "" {
+		t.Errorf("Expected empty layout, got %s", args.LayoutName)
 	}
 
 	// OutputPath should be expanded to absolute path
@@ -87,21 +93,352 @@ func TestCLI_Parse_ShortFlags(t *testing.T) {
 	}
 }
 
-func TestCLI_Parse_InvalidLayout(t *testing.T) {
+func TestCLI_Parse_AnyLayout(t *testing.T) {
 	cli := NewCLI()
 
-	testArgs := []string{
-		"i3-config-generator",
-		"--layout", "invalid_layout",
+	// Layouts are validated against the config after it is loaded
+	args, err := cli.Parse([]string{"i3-config-generator", "--layout", "ultrawide"})
+	if err != nil {
+		t.Fatalf("Failed to parse custom layout: %v", err)
+	}
+
+	if args.LayoutName != "ultrawide" {
+		t.Errorf("Expected layout ultrawide, got %s", args.LayoutName)
+	}
+}
+
+func TestCLI_Help_ListsConfiguredLayouts(t *testing.T) {
+	cli := NewCLI()
+	var out strings.Builder
+	cli.SetOutput(&out)
+
+	var requestedPath string
+	cli.SetLayoutSource(func(configPath string) ([]config.LayoutSummary, error) {
+		requestedPath = configPath
+		return []config.LayoutSummary{
+			{Name: "docked", Description: "Desk with two screens", Default: true},
+			{Name: "laptop"},
+		}, nil
+	})
+
+	_, err := cli.Parse([]string{"i3-config-generator", "generate", "-c", "/tmp/team.yaml", "--help"})
+	if !errors.Is(err, ErrHelp) {
+		t.Fatalf("Expected ErrHelp, got %v", err)
+	}
+
+	if requestedPath != "/tmp/team.yaml" {
+		t.Errorf("Expected layouts to be read from /tmp/team.yaml, got %q", requestedPath)
+	}
+
+	for _, expected := range []string{"docked - Desk with two screens (default)", "  laptop\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected help to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	// Test: Missing config is reported instead of failing help
+	cli = NewCLI()
+	out.Reset()
+	cli.SetOutput(&out)
+	cli.SetLayoutSource(func(string) ([]config.LayoutSummary, error) {
+		return nil, errors.New("no configuration file found")
+	})
+	if _, err := cli.Parse([]string{"i3-config-generator", "--help"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("Expected ErrHelp, got %v", err)
+	}
+	if !strings.Contains(out.String(), "(unavailable: no configuration file found)") {
+		t.Errorf("Expected unavailable layouts notice, got:\n%s", out.String())
+	}
+}
+
+func TestCLI_Parse_Subcommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"bare invocation", []string{"i3-config-generator"}, CommandGenerate},
+		{"bare flags", []string{"i3-config-generator", "-l", "one_mon"}, CommandGenerate},
+		{"generate", []string{"i3-config-generator", "generate", "-l", "one_mon"}, CommandGenerate},
+		{"detect", []string{"i3-config-generator", "detect"}, CommandDetect},
+		{"validate", []string{"i3-config-generator", "validate", "-c", "/tmp/config.yaml"}, CommandValidate},
+		{"diff", []string{"i3-config-generator", "diff", "-o", "/tmp/output"}, CommandDiff},
+		{"init", []string{"i3-config-generator", "init", "--force"}, CommandInit},
+		{"layouts", []string{"i3-config-generator", "layouts"}, CommandLayouts},
+		{"apply", []string{"i3-config-generator", "apply", "--restart"}, CommandApply},
+		{"schema", []string{"i3-config-generator", "schema"}, CommandSchema},
+		{"workspace", []string{"i3-config-generator", "workspace", "2"}, CommandWorkspace},
+		{"migrate", []string{"i3-config-generator", "migrate", "--dry-run"}, CommandMigrate},
+		{"convert", []string{"i3-config-generator", "convert", "--to", "toml"}, CommandConvert},
+		{"record", []string{"i3-config-generator", "record", "-o", "/tmp/fixture.yaml"}, CommandRecord},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewCLI()
+			cli.SetOutput(io.Discard)
+
+			args, err := cli.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Failed to parse args: %v", err)
+			}
+			if args.Command != tt.expected {
+				t.Errorf("Expected command %s, got %s", tt.expected, args.Command)
+			}
+		})
+	}
+}
+
+func TestCLI_Parse_CommandFlags(t *testing.T) {
+	cli := NewCLI()
+
+	args, err := cli.Parse([]string{"i3-config-generator", "init", "--force"})
+	if err != nil {
+		t.Fatalf("Failed to parse init args: %v", err)
+	}
+	if !args.Force {
+		t.Error("Expected Force to be set")
+	}
+	if args.Import {
+		t.Error("Expected Import to be unset by default")
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "init", "--import-from", "~/dotfiles/i3"})
+	if err != nil {
+		t.Fatalf("Failed to parse init args: %v", err)
+	}
+	homeDir, _ := os.UserHomeDir()
+	if !args.Import || args.ImportPath != filepath.Join(homeDir, "dotfiles", "i3") {
+		t.Errorf("Expected import from expanded path, got %v %s", args.Import, args.ImportPath)
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "apply", "--restart", "-l", "no_mon"})
+	if err != nil {
+		t.Fatalf("Failed to parse apply args: %v", err)
+	}
+	if !args.Restart {
+		t.Error("Expected Restart to be set")
+	}
+	if args.LayoutName != "no_mon" {
+		t.Errorf("Expected layout no_mon, got %s", args.LayoutName)
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "import", "--from", "/tmp/i3config", "--force"})
+	if err != nil {
+		t.Fatalf("Failed to parse import args: %v", err)
+	}
+	if args.Command != CommandImport || !args.Import || args.ImportPath != "/tmp/i3config" || !args.Force {
+		t.Errorf("Expected import from /tmp/i3config with force, got %+v", args)
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "validate", "--lenient"})
+	if err != nil {
+		t.Fatalf("Failed to parse validate args: %v", err)
+	}
+	if !args.Lenient {
+		t.Error("Expected Lenient to be set")
+	}
+
+	// Flags belong to their command: init has no --layout
+	cli = NewCLI()
+	if _, err := cli.Parse([]string{"i3-config-generator", "init", "--layout", "no_mon"}); err == nil {
+		t.Error("Expected error for flag not defined on init")
+	}
+}
+
+func TestCLI_Parse_DetectFormat(t *testing.T) {
+	cli := NewCLI()
+
+	args, err := cli.Parse([]string{"i3-config-generator", "detect"})
+	if err != nil {
+		t.Fatalf("Failed to parse detect args: %v", err)
+	}
+	if args.Format != FormatTable {
+		t.Errorf("Expected default format %s, got %s", FormatTable, args.Format)
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "detect", "--format", "json"})
+	if err != nil {
+		t.Fatalf("Failed to parse detect args: %v", err)
+	}
+	if args.Format != FormatJSON {
+		t.Errorf("Expected format %s, got %s", FormatJSON, args.Format)
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "detect", "--fixture", "~/docked.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to parse detect args: %v", err)
+	}
+	homeDir, _ := os.UserHomeDir()
+	if args.Fixture != filepath.Join(homeDir, "docked.yaml") {
+		t.Errorf("Expected fixture from expanded path, got %s", args.Fixture)
+	}
+
+	cli = NewCLI()
+	_, err = cli.Parse([]string{"i3-config-generator", "detect", "-f", "xml"})
+	if err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("Expected invalid format error, got %v", err)
+	}
+}
+
+func TestCLI_Parse_Set(t *testing.T) {
+	cli := NewCLI()
+	args, err := cli.Parse([]string{"i3-config-generator", "validate",
+		"--set", "layouts.two_mon.gaps_inner=20", "--set", "terminal=xterm"})
+	if err != nil {
+		t.Fatalf("Failed to parse validate args: %v", err)
+	}
+	expected := []config.Override{
+		{Source: "--set", Path: "layouts.two_mon.gaps_inner", Value: "20"},
+		{Source: "--set", Path: "terminal", Value: "xterm"},
+	}
+	if !reflect.DeepEqual(args.Overrides, expected) {
+		t.Errorf("Expected overrides %+v, got %+v", expected, args.Overrides)
+	}
+
+	cli = NewCLI()
+	_, err = cli.Parse([]string{"i3-config-generator", "validate", "--set", "terminal"})
+	if err == nil || !strings.Contains(err.Error(), "expected key.path=value") {
+		t.Errorf("Expected invalid --set error, got %v", err)
+	}
+}
+
+func TestCLI_Parse_MonitorSources(t *testing.T) {
+	cli := NewCLI()
+	args, err := cli.Parse([]string{"i3-config-generator", "diff", "--offline"})
+	if err != nil {
+		t.Fatalf("Failed to parse diff args: %v", err)
+	}
+	if !args.Offline {
+		t.Error("Expected Offline to be set")
+	}
+
+	cli = NewCLI()
+	args, err = cli.Parse([]string{"i3-config-generator", "generate", "--monitors-from", "/tmp/monitors.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to parse generate args: %v", err)
+	}
+	if args.MonitorsFrom != "/tmp/monitors.yaml" {
+		t.Errorf("Expected MonitorsFrom /tmp/monitors.yaml, got %s", args.MonitorsFrom)
+	}
+
+	cli = NewCLI()
+	_, err = cli.Parse([]string{"i3-config-generator", "apply", "--offline", "--monitors-from", "/tmp/monitors.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("Expected error for conflicting monitor sources, got %v", err)
+	}
+}
+
+func TestCLI_Parse_ConvertFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		errorMsg string
+	}{
+		{"explicit", []string{"--to", "json"}, config.FormatJSON, ""},
+		{"from output extension", []string{"-o", "/tmp/config.toml"}, config.FormatTOML, ""},
+		{"explicit over extension", []string{"--to", "yaml", "-o", "/tmp/config.txt"}, config.FormatYAML, ""},
+		{"stdout without format", nil, "", "--to is required"},
+		{"unknown format", []string{"--to", "ini"}, "", "invalid format: ini"},
 	}
 
-	_, err := cli.Parse(testArgs)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewCLI()
+			args, err := cli.Parse(append([]string{"i3-config-generator", "convert"}, tt.args...))
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse convert args: %v", err)
+			}
+			if args.Format != tt.expected {
+				t.Errorf("Expected format %s, got %s", tt.expected, args.Format)
+			}
+		})
+	}
+}
+
+func TestCLI_Parse_Workspace(t *testing.T) {
+	cli := NewCLI()
+	args, err := cli.Parse([]string{"i3-config-generator", "workspace", "--move", "3"})
+	if err != nil {
+		t.Fatalf("Failed to parse workspace args: %v", err)
+	}
+	if args.Workspace != 3 || !args.Move || args.PerOutput != 10 {
+		t.Errorf("Expected workspace 3 with --move in ranges of 10, got %+v", args)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"i3-config-generator", "workspace"}, "missing workspace number"},
+		{[]string{"i3-config-generator", "workspace", "11"}, "invalid workspace number: 11 (expected 1-10)"},
+		{[]string{"i3-config-generator", "workspace", "--per-output", "5", "6"}, "expected 1-5"},
+		{[]string{"i3-config-generator", "workspace", "1", "2"}, "unexpected argument: 2"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewCLI().Parse(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q for %v, got %v", tt.expected, tt.args[1:], err)
+		}
+	}
+}
+
+func TestCLI_Parse_HelpAndVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr error
+		contains    string
+	}{
+		{"bare help flag", []string{"i3-config-generator", "--help"}, ErrHelp, "COMMANDS:"},
+		{"help command", []string{"i3-config-generator", "help"}, ErrHelp, "COMMANDS:"},
+		{"help for command", []string{"i3-config-generator", "help", "init"}, ErrHelp, "-force"},
+		{"command help flag", []string{"i3-config-generator", "diff", "-h"}, ErrHelp, "unified diff"},
+		{"version flag", []string{"i3-config-generator", "--version"}, ErrVersion, Version},
+		{"version command", []string{"i3-config-generator", "version"}, ErrVersion, Version},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewCLI()
+			var out strings.Builder
+			cli.SetOutput(&out)
+
+			_, err := cli.Parse(tt.args)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if !strings.Contains(out.String(), tt.contains) {
+				t.Errorf("Expected output to contain '%s', got:\n%s", tt.contains, out.String())
+			}
+		})
+	}
+}
+
+func TestCLI_Parse_UnknownCommand(t *testing.T) {
+	cli := NewCLI()
+
+	_, err := cli.Parse([]string{"i3-config-generator", "frobnicate"})
 	if err == nil {
-		t.Error("Expected error for invalid layout")
+		t.Fatal("Expected error for unknown command")
 	}
 
-	if !strings.Contains(err.Error(), "invalid layout name") {
-		t.Errorf("Expected error about invalid layout, got: %v", err)
+	if !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Expected error about unknown command, got: %v", err)
 	}
 }
 
@@ -167,39 +504,72 @@ func TestExpandPath(t *testing.T) {
 	}
 }
 
-func TestIsValidLayout(t *testing.T) {
-	tests := []struct {
-		layout string
-		valid  bool
-	}{
-		{"two_mon", true},
-		{"one_mon", true},
-		{"no_mon", true},
-		{"invalid", false},
-		{"", false},
-		{"THREE_MON", false},
+func TestGetDefaultOutputPath(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "cli_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
 	}
+	defer os.RemoveAll(tempDir)
 
-	for _, tt := range tests {
-		t.Run(tt.layout, func(t *testing.T) {
-			result := isValidLayout(tt.layout)
-			if result != tt.valid {
-				t.Errorf("isValidLayout(%s) = %v, want %v", tt.layout, result, tt.valid)
-			}
-		})
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "xdg"))
+
+	// Without an XDG i3 config the traditional location is used
+	result := getDefaultOutputPath()
+	if result != filepath.Join(tempDir, ".i3", "config") {
+		t.Errorf("Expected default output path to be ~/.i3/config, got %s", result)
+	}
+
+	// i3's preferred location wins once it exists
+	xdgPath := filepath.Join(tempDir, "xdg", "i3", "config")
+	if err := os.MkdirAll(filepath.Dir(xdgPath), 0755); err != nil {
+		t.Fatalf("Failed to create i3 config dir: %v", err)
+	}
+	if err := os.WriteFile(xdgPath, nil, 0644); err != nil {
+		t.Fatalf("Failed to write i3 config: %v", err)
+	}
+	if result := getDefaultOutputPath(); result != xdgPath {
+		t.Errorf("Expected default output path %s, got %s", xdgPath, result)
 	}
 }
 
-func TestGetDefaultOutputPath(t *testing.T) {
-	result := getDefaultOutputPath()
+func TestCLI_Parse_PrintPaths(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "cli_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
 
-	// Should end with .i3/config
-	if !strings.HasSuffix(result, ".i3/config") {
-		t.Errorf("Expected default output path to end with .i3/config, got %s", result)
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	t.Setenv("XDG_CONFIG_DIRS", "/opt/xdg:/etc/xdg")
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	configDir := filepath.Join(tempDir, "config", "i3-config-generator")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cli := NewCLI()
+	var out strings.Builder
+	cli.SetOutput(&out)
+	_, err = cli.Parse([]string{"i3-config-generator", "generate", "--print-paths", "-o", "/tmp/i3/config"})
+	if !errors.Is(err, ErrPaths) {
+		t.Fatalf("Expected ErrPaths, got %v", err)
 	}
 
-	// Should be an absolute path
-	if !filepath.IsAbs(result) {
-		t.Errorf("Expected absolute path, got %s", result)
+	// System directories come first, as the user's file overrides them
+	expected := "Configuration search path (later files override earlier ones):\n" +
+		"  system /etc/xdg/i3-config-generator (no config file)\n" +
+		"  system /opt/xdg/i3-config-generator (no config file)\n" +
+		"  user   " + filepath.Join(configDir, "config.toml") + "\n" +
+		"Generated i3 configuration:\n" +
+		"  /tmp/i3/config\n" +
+		"Last detected monitors (for --offline):\n" +
+		"  /tmp/state/i3-config-generator/monitors.json\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
 	}
 }
\ No newline at end of file
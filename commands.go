@@ -0,0 +1,473 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/a7d-corp/i3-config-generator-go/cli"
+	"github.com/a7d-corp/i3-config-generator-go/config"
+	"github.com/a7d-corp/i3-config-generator-go/diff"
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"github.com/a7d-corp/i3-config-generator-go/template"
+)
+
+// runGenerate renders the configuration and writes it to the output file
+func runGenerate(args *cli.Args) error {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Configuration loaded successfully\n")
+
+	layoutName, err := cfg.ResolveLayoutName(args.LayoutName)
+	if err != nil {
+		return err
+	}
+
+	detectedMonitors, err := detectMonitors(cfg, args, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	// Render the template
+	fmt.Printf("✓ Rendering i3 configuration for layout: %s\n", layoutName)
+	renderer := template.NewRenderer("")
+
+	renderedConfig, err := renderer.Render(cfg, layoutName, detectedMonitors)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// Write the configuration to the output file
+	fmt.Printf("✓ Writing configuration to: %s\n", args.OutputPath)
+	if err := renderer.RenderToFile(cfg, layoutName, detectedMonitors, args.OutputPath); err != nil {
+		return fmt.Errorf("failed to write configuration file: %w", err)
+	}
+
+	// Show summary
+	fmt.Printf("\n🎉 i3 configuration generated successfully!\n")
+	fmt.Printf("   Layout: %s\n", layoutName)
+	fmt.Printf("   Output: %s\n", args.OutputPath)
+	if detectedMonitors != nil {
+		fmt.Printf("   Monitors: %d detected\n", len(detectedMonitors.All))
+	}
+	fmt.Printf("   Size: %.1f KB\n", float64(len(renderedConfig))/1024)
+
+	if args.Command == cli.CommandGenerate {
+		fmt.Println("\nTo use this configuration:")
+		fmt.Printf("   1. Backup your current i3 config (if any)\n")
+		fmt.Printf("   2. Restart i3: i3-msg restart\n")
+		fmt.Printf("   3. Or reload config: i3-msg reload\n")
+	}
+
+	return nil
+}
+
+// runValidate loads and validates the configuration file
+func runValidate(args *cli.Args) error {
+	if _, err := loadConfig(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Configuration is valid\n")
+	return nil
+}
+
+// runDiff prints a unified diff between the current output file and a fresh render
+func runDiff(args *cli.Args) error {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+
+	layoutName, err := cfg.ResolveLayoutName(args.LayoutName)
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout for the diff itself
+	detectedMonitors, err := detectMonitors(cfg, args, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := template.NewRenderer("").Render(cfg, layoutName, detectedMonitors)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	oldName := args.OutputPath
+	current, err := os.ReadFile(args.OutputPath)
+	if os.IsNotExist(err) {
+		oldName = "/dev/null"
+	} else if err != nil {
+		return fmt.Errorf("failed to read current configuration: %w", err)
+	}
+
+	// Never print resolved secrets, whichever side they appear on
+	result := diff.Unified(oldName, args.OutputPath+" (generated)",
+		cfg.Redact(string(current)), cfg.Redact(rendered), diff.DefaultContext)
+	if result == "" {
+		fmt.Printf("✓ %s is up to date\n", args.OutputPath)
+		return nil
+	}
+
+	fmt.Print(result)
+	return nil
+}
+
+// runInit writes a starter configuration with layouts for the connected monitors,
+// importing settings from an existing i3 config if requested
+func runInit(args *cli.Args) error {
+	configPath := args.ConfigPath
+	if configPath == "" {
+		configPath = config.NewLoader("").GetConfigPath()
+	}
+
+	opts := config.StarterOptions{Display: os.Getenv("DISPLAY")}
+	if opts.Display == "" {
+		opts.Display = ":0"
+	}
+
+	fmt.Printf("✓ Detecting monitors...\n")
+	monitors, err := monitor.NewNativeDetector(opts.Display, nil, 0).DetectMonitors()
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: monitor detection failed, writing generic layouts: %v\n", err)
+	case len(monitors.All) == 0:
+		fmt.Fprintf(os.Stderr, "Warning: no connected monitors found, writing generic layouts\n")
+	default:
+		fmt.Printf("✓ Detected %d monitors: %s\n", len(monitors.All), strings.Join(monitors.All, ", "))
+		printMonitorStates(os.Stdout, monitors)
+		opts.Monitors = monitors
+	}
+
+	if args.Import {
+		imported, err := config.ImportI3Settings(args.ImportPath)
+		if err != nil {
+			return fmt.Errorf("failed to import settings: %w", err)
+		}
+		fmt.Printf("✓ Imported settings from: %s\n", args.ImportPath)
+		imported.PlaceWorkspaces(opts.Monitors)
+		for _, warning := range imported.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		opts.Imported = imported
+	}
+
+	content, err := config.BuildStarterConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Imported != nil {
+		// Imported bindings and settings must not clash with those the generated config defines
+		reserved, emitted, err := generatedDirectives(content)
+		if err != nil {
+			return fmt.Errorf("failed to check imported bindings: %w", err)
+		}
+		for _, line := range opts.Imported.DropBindings(reserved) {
+			fmt.Fprintf(os.Stderr, "Warning: dropped binding already defined by the generated config: %s\n", line)
+		}
+		for _, line := range opts.Imported.DropSettings(emitted) {
+			fmt.Fprintf(os.Stderr, "Warning: dropped setting already defined by the generated config: %s\n", line)
+		}
+		if content, err = config.BuildStarterConfig(opts); err != nil {
+			return err
+		}
+		if count := len(opts.Imported.Passthrough); count > 0 {
+			fmt.Printf("  - %d statements kept as raw config in extra.end_of_file\n", count)
+		}
+	}
+	if err := config.WriteStarterConfig(configPath, content, args.Force); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Starter configuration written to: %s\n", configPath)
+	return nil
+}
+
+// generatedDirectives renders every layout of a configuration without its hotkeys
+// and extra config, and returns functions reporting whether the result binds a key
+// and whether it sets an option
+func generatedDirectives(content []byte) (func(mode, key string) bool, func(key string) bool, error) {
+	cfg, err := config.Parse(content, "generated configuration")
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.Hotkeys = nil
+	cfg.Extra = config.ExtraConfig{}
+
+	// Output names do not affect the bindings
+	monitors := &monitor.DetectedMonitors{
+		Primary: "primary",
+		Left:    "left",
+		Right:   "right",
+		All:     []string{"primary", "left", "right"},
+	}
+
+	bound := map[string]bool{}
+	set := map[string]bool{}
+	renderer := template.NewRenderer("")
+	for _, name := range cfg.LayoutNames() {
+		rendered, err := renderer.Render(cfg, name, monitors)
+		if err != nil {
+			return nil, nil, err
+		}
+		parsed, err := i3conf.Parse(strings.NewReader(rendered), "generated i3 config")
+		if err != nil {
+			return nil, nil, err
+		}
+		expanded, err := parsed.ExpandVariables("$mod")
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, binding := range expanded.Bindings() {
+			bound[binding.Mode+"\x00"+binding.NormalizedKey()] = true
+		}
+		for _, statement := range expanded.Statements {
+			if key, ok := statement.SettingKey(); ok {
+				set[key] = true
+			}
+		}
+	}
+
+	bindsKey := func(mode, key string) bool {
+		return bound[mode+"\x00"+key]
+	}
+	setsOption := func(key string) bool {
+		return set[key]
+	}
+	return bindsKey, setsOption, nil
+}
+
+// runLayouts lists the layouts defined in the configuration
+func runLayouts(args *cli.Args) error {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, layout := range cfg.LayoutSummaries() {
+		marker := " "
+		if layout.Default {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\n", marker, layout.Name, layout.Description)
+	}
+	return w.Flush()
+}
+
+// runSchema prints the JSON Schema of the configuration file
+func runSchema(args *cli.Args) error {
+	// Layout names are only a convenience for editors, so a missing config is fine
+	var layoutNames []string
+	layouts, err := config.NewLoader("").PeekLayouts(args.ConfigPath)
+	if err != nil && args.ConfigPath != "" {
+		return err
+	}
+	for _, layout := range layouts {
+		layoutNames = append(layoutNames, layout.Name)
+	}
+
+	data, err := json.MarshalIndent(config.GenerateSchema(layoutNames), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// runMigrate upgrades the configuration file to the current format version
+func runMigrate(args *cli.Args) error {
+	result, err := config.NewLoader("").MigrateFile(args.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if result.Version >= config.CurrentVersion {
+		fmt.Printf("✓ %s is already at version %d\n", result.Path, result.Version)
+		return nil
+	}
+
+	if args.DryRun {
+		fmt.Print(diff.Unified(result.Path, result.Path+" (migrated)",
+			string(result.Original), string(result.Migrated), diff.DefaultContext))
+		for _, note := range result.Notes {
+			fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(result.Path, result.Migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", result.Path, err)
+	}
+	fmt.Printf("✓ Upgraded %s from version %d to %d\n", result.Path, result.Version, config.CurrentVersion)
+	for _, migration := range result.Applied {
+		fmt.Printf("  - %s\n", migration.Description)
+	}
+	for _, note := range result.Notes {
+		fmt.Printf("  ! %s\n", note)
+	}
+	return nil
+}
+
+// runConvert writes the configuration file in another format
+func runConvert(args *cli.Args) error {
+	converted, err := config.NewLoader("").ConvertFile(args.ConfigPath, args.Format)
+	if err != nil {
+		return err
+	}
+	if args.OutputPath == "" {
+		_, err := os.Stdout.Write(converted)
+		return err
+	}
+
+	if err := os.WriteFile(args.OutputPath, converted, 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", args.OutputPath, err)
+	}
+	fmt.Printf("✓ Wrote %s\n", args.OutputPath)
+	return nil
+}
+
+// runApply generates the configuration and asks i3 to pick it up
+func runApply(args *cli.Args) error {
+	if err := runGenerate(args); err != nil {
+		return err
+	}
+
+	action := "reload"
+	if args.Restart {
+		action = "restart"
+	}
+
+	fmt.Printf("\n✓ Running: i3-msg %s\n", action)
+	cmd := exec.Command("i3-msg", action)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to %s i3: %w", action, err)
+	}
+	return nil
+}
+
+// redactSecrets hides the secret values of the configuration in messages shown
+// to the user; loadConfig points it at the loader that resolved them
+var redactSecrets = func(s string) string { return s }
+
+// loadConfig loads the configuration from the path given on the command line,
+// or from the default location
+func loadConfig(args *cli.Args) (*config.Config, error) {
+	loader := config.NewLoader("")
+	loader.SetLenient(args.Lenient)
+	redactSecrets = loader.Redact
+	// Command-line overrides win over the environment
+	loader.SetOverrides(append(config.EnvOverrides(os.Environ()), args.Overrides...))
+	var cfg *config.Config
+	var err error
+	if args.ConfigPath != "" {
+		cfg, err = loader.LoadFromFile(args.ConfigPath)
+	} else {
+		cfg, err = loader.Load()
+	}
+	if err != nil {
+		// Errors quote configuration values, which may hold resolved secrets
+		return nil, fmt.Errorf("failed to load configuration: %s", redactSecrets(err.Error()))
+	}
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", redactSecrets(warning))
+	}
+	return cfg, nil
+}
+
+// detectMonitors runs monitor detection if it is enabled in the configuration,
+// reporting progress to w; monitors given with --monitors-from or --offline are
+// used whether or not detection is enabled
+func detectMonitors(cfg *config.Config, args *cli.Args, w io.Writer) (*monitor.DetectedMonitors, error) {
+	if !cfg.MonitorDetection.Enabled && args.MonitorsFrom == "" && !args.Offline {
+		fmt.Fprintf(w, "✓ Using static monitor configuration\n")
+		return nil, nil
+	}
+
+	statePath := config.MonitorStatePath()
+	var detectedMonitors *monitor.DetectedMonitors
+	switch {
+	case args.MonitorsFrom != "":
+		monitors, err := monitor.LoadMonitors(args.MonitorsFrom)
+		if err != nil {
+			return nil, err
+		}
+		// Hand-written files get the dummy monitors detection would have added
+		monitors.Pad(cfg.MonitorDetection.DummyMonitors, cfg.MonitorDetection.MinMonitors)
+		fmt.Fprintf(w, "✓ Using monitors from %s\n", args.MonitorsFrom)
+		detectedMonitors = monitors
+	case args.Offline:
+		monitors, err := monitor.LoadMonitors(statePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no saved monitors in %s; run once with access to the X server first", statePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(w, "✓ Using monitors saved in %s%s\n", statePath, savedAt(statePath))
+		detectedMonitors = monitors
+	default:
+		fmt.Fprintf(w, "✓ Detecting monitors...\n")
+		detector, err := cfg.CreateDetector()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create monitor detector: %w", err)
+		}
+		monitors, err := detector.DetectMonitors()
+		if err != nil {
+			if _, statErr := os.Stat(statePath); statErr == nil {
+				return nil, fmt.Errorf("failed to detect monitors: %w (use --offline to render for the monitors saved%s)", err, savedAt(statePath))
+			}
+			return nil, fmt.Errorf("failed to detect monitors: %w", err)
+		}
+		// Keep the result for --offline; failing to save does not stop rendering
+		if err := monitor.SaveMonitors(statePath, monitors); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		detectedMonitors = monitors
+	}
+
+	fmt.Fprintf(w, "✓ Detected %d monitors: %s\n", len(detectedMonitors.All), detectedMonitors.Primary)
+	if len(detectedMonitors.All) > 1 {
+		fmt.Fprintf(w, "  - Primary: %s, Left: %s, Right: %s\n",
+			detectedMonitors.Primary, detectedMonitors.Left, detectedMonitors.Right)
+	}
+	printMonitorStates(w, detectedMonitors)
+	return detectedMonitors, nil
+}
+
+// printMonitorStates lists the outputs that were left out of the roles and warns
+// about outputs detection had to skip
+func printMonitorStates(w io.Writer, monitors *monitor.DetectedMonitors) {
+	if len(monitors.Disabled) > 0 {
+		fmt.Fprintf(w, "  - Connected but disabled (no role): %s\n", strings.Join(monitors.Disabled, ", "))
+	}
+	if len(monitors.Stale) > 0 {
+		fmt.Fprintf(w, "  - Disconnected but still enabled (no role): %s\n", strings.Join(monitors.Stale, ", "))
+	}
+	for _, warning := range monitors.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
+
+// savedAt describes when the file at path was last written, or returns an
+// empty string when that is unknown
+func savedAt(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return " on " + info.ModTime().Format("2006-01-02 15:04")
+}
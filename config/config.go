@@ -4,88 +4,168 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"strings"
 )
 
 const (
-	// Default configuration directory relative to user's home
-	DefaultConfigDir = ".config/i3-config-generator"
+	// Configuration directory name below $XDG_CONFIG_HOME and each of $XDG_CONFIG_DIRS
+	AppName = "i3-config-generator"
 	// Configuration file names to search for (in order of preference)
 	ConfigFileYAML = "config.yaml"
 	ConfigFileYML  = "config.yml"
+	ConfigFileJSON = "config.json"
+	ConfigFileTOML = "config.toml"
 )
 
 // Loader handles configuration file loading operations
 type Loader struct {
 	configDir string
+	// systemDirs hold configuration files the one in configDir is layered on,
+	// most important first
+	systemDirs []string
+	// lenient reports unknown keys as warnings instead of errors
+	lenient bool
+	// overrides set values on top of the loaded files
+	overrides []Override
+	// secrets holds the secret values resolved by the last load, including one
+	// that failed, so that its errors can be redacted
+	secrets []string
 }
 
 // NewLoader creates a new configuration loader
-// If configDir is empty, uses the default location ($HOME/.config/i3-config-generator)
+// If configDir is empty, uses the XDG search path: $XDG_CONFIG_HOME/i3-config-generator
+// (default $HOME/.config/i3-config-generator) layered on the same directory in
+// each of $XDG_CONFIG_DIRS (default /etc/xdg)
 func NewLoader(configDir string) *Loader {
+	var systemDirs []string
 	if configDir == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			// Fallback to current directory if home directory is not available
-			configDir = DefaultConfigDir
-		} else {
-			configDir = filepath.Join(homeDir, DefaultConfigDir)
+		configDir = filepath.Join(ConfigHome(), AppName)
+		for _, dir := range ConfigDirs() {
+			systemDirs = append(systemDirs, filepath.Join(dir, AppName))
 		}
 	}
 
 	return &Loader{
-		configDir: configDir,
+		configDir:  configDir,
+		systemDirs: systemDirs,
 	}
 }
 
-// Load attempts to load the configuration file from the configured directory
-// It tries both .yaml and .yml extensions in that order
+// SetLenient makes unknown keys warnings instead of errors, so configuration files
+// written for newer versions can still be loaded
+func (l *Loader) SetLenient(lenient bool) {
+	l.lenient = lenient
+}
+
+// Load loads the configuration files found on the search path, with each file
+// overriding the settings of the system-wide ones before it
+// In each directory the .yaml, .yml, .json and .toml extensions are tried in that order
 func (l *Loader) Load() (*Config, error) {
-	configPath, err := l.findConfigFile()
+	paths, err := l.searchFiles()
 	if err != nil {
 		return nil, err
 	}
 
-	return l.LoadFromFile(configPath)
+	return l.loadFiles(paths)
 }
 
-// LoadFromFile loads configuration from a specific file path
+// LoadFromFile loads configuration from a specific file path, in the format
+// given by its extension
 func (l *Loader) LoadFromFile(filePath string) (*Config, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	return l.loadFiles([]string{filePath})
+}
+
+// loadFiles loads configuration files, later files overriding earlier ones, and
+// applies the overrides on top
+func (l *Loader) loadFiles(paths []string) (*Config, error) {
+	l.secrets = nil
+	layers := make([]*configLayer, 0, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		layer, err := readLayer(data, path)
+		if err != nil {
+			return nil, err
+		}
+		l.secrets = append(l.secrets, layer.secrets...)
+		if i < len(paths)-1 {
+			// Snippets of lower layers are looked up next to the file defining them
+			if err := resolveSnippetNodes(layer.document, filepath.Dir(path)); err != nil {
+				return nil, err
+			}
+		}
+		layers = append(layers, layer)
+	}
+	for _, override := range l.overrides {
+		layer, err := overrideLayer(override, layers)
+		if err != nil {
+			return nil, err
+		}
+		l.secrets = append(l.secrets, layer.secrets...)
+		layers = append(layers, layer)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML config file %s: %w", filePath, err)
+	config, err := decodeLayers(layers, l.lenient)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate the configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	// Snippet files are looked up next to the configuration file
+	filePath := paths[len(paths)-1]
+	if err := config.Extra.resolveFiles(filepath.Dir(filePath)); err != nil {
+		return nil, err
+	}
+	for _, layout := range config.Layouts {
+		if layout.Overrides.Extra == nil {
+			continue
+		}
+		if err := layout.Overrides.Extra.resolveFiles(filepath.Dir(filePath)); err != nil {
+			return nil, err
+		}
 	}
 
-	return &config, nil
+	return config, nil
+}
+
+// Parse loads a configuration from data in the format given by the extension of
+// name, which also identifies it in error messages
+func Parse(data []byte, name string) (*Config, error) {
+	return parse(data, name, false)
 }
 
+// parse loads a configuration from YAML, JSON or TOML data, with unknown keys
+// reported as warnings when lenient is set
+func parse(data []byte, name string, lenient bool) (*Config, error) {
+	layer, err := readLayer(data, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeLayers([]*configLayer{layer}, lenient)
+}
+
+// configFileNames are the configuration file names searched for, in order of preference
+var configFileNames = []string{ConfigFileYAML, ConfigFileYML, ConfigFileJSON, ConfigFileTOML}
+
 // findConfigFile searches for the configuration file in the configured directory
-// Returns the path to the first file found (checking .yaml first, then .yml)
+// Returns the path to the first file found (checking .yaml, .yml, .json, then .toml)
 func (l *Loader) findConfigFile() (string, error) {
-	// List of filenames to try in order of preference
-	candidates := []string{ConfigFileYAML, ConfigFileYML}
+	return findConfigFile(l.configDir)
+}
 
-	for _, filename := range candidates {
-		configPath := filepath.Join(l.configDir, filename)
+// findConfigFile returns the path to the first configuration file found in dir
+func findConfigFile(dir string) (string, error) {
+	for _, filename := range configFileNames {
+		configPath := filepath.Join(dir, filename)
 		if _, err := os.Stat(configPath); err == nil {
 			return configPath, nil
 		}
 	}
 
 	// If no config file found, return an informative error
-	return "", fmt.Errorf("no configuration file found in %s (tried: %s, %s)",
-		l.configDir, ConfigFileYAML, ConfigFileYML)
+	return "", fmt.Errorf("no configuration file found in %s (tried: %s)",
+		dir, strings.Join(configFileNames, ", "))
 }
 
 // GetConfigDir returns the configuration directory being used
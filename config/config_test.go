@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -119,8 +120,9 @@ colors:
 		t.Errorf("Expected ModKey 'Mod4', got '%s'", config.I3.ModKey)
 	}
 
-	if !config.UseDetectedMonitors {
-		t.Error("Expected UseDetectedMonitors to be true")
+	// use_detected_monitors is moved to monitor_detection.enabled when loading
+	if !config.MonitorDetection.Enabled {
+		t.Error("Expected MonitorDetection.Enabled to be true")
 	}
 
 	if config.MonitorDetection.MinMonitors != 3 {
@@ -158,9 +160,9 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "valid config with native detection",
 			config: Config{
-				I3:                  I3Config{ModKey: "Mod4"},
-				UseDetectedMonitors: true,
+				I3: I3Config{ModKey: "Mod4"},
 				MonitorDetection: MonitorConfig{
+					Enabled:   true,
 					UseNative: true,
 					Display:   ":0",
 				},
@@ -170,9 +172,9 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "valid config with shell command detection",
 			config: Config{
-				I3:                  I3Config{ModKey: "Mod4"},
-				UseDetectedMonitors: true,
+				I3: I3Config{ModKey: "Mod4"},
 				MonitorDetection: MonitorConfig{
+					Enabled:          true,
 					UseNative:        false,
 					DetectionCommand: "xrandr | awk '/ connected/' | awk '{print $1}'",
 				},
@@ -186,12 +188,142 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown default layout",
+			config: Config{
+				I3:            I3Config{ModKey: "Mod4"},
+				DefaultLayout: "missing",
+				Layouts:       map[string]LayoutConfig{"two_mon": {}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "hotkey without command",
+			config: Config{
+				I3:      I3Config{ModKey: "Mod4"},
+				Hotkeys: []HotkeyConfig{{Key: "$mod+x"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid bar position",
+			config: Config{
+				I3:  I3Config{ModKey: "Mod4"},
+				Bar: BarConfig{Position: "left"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "natural ordering",
+			config: Config{
+				I3:       I3Config{ModKey: "Mod4"},
+				Ordering: OrderingNatural,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid ordering",
+			config: Config{
+				I3:       I3Config{ModKey: "Mod4"},
+				Ordering: "random",
+			},
+			wantErr: true,
+		},
+		{
+			name: "extra snippets for unknown mode",
+			config: Config{
+				I3:    I3Config{ModKey: "Mod4"},
+				Extra: ExtraConfig{Modes: map[string][]Snippet{"launch": {{Raw: "bindsym f exec firefox"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown mod key",
+			config: Config{
+				I3: I3Config{ModKey: "Super"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "layouts without the fallback default layout",
+			config: Config{
+				I3:      I3Config{ModKey: "Mod4"},
+				Layouts: map[string]LayoutConfig{"no_mon": {}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown role in layout",
+			config: Config{
+				I3:      I3Config{ModKey: "Mod4"},
+				Layouts: map[string]LayoutConfig{"two_mon": {WorkspaceToDisplay: OrderedMap{{Key: "1", Value: "laptop"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unparsable move_workspace key",
+			config: Config{
+				I3:      I3Config{ModKey: "Mod4"},
+				Layouts: map[string]LayoutConfig{"two_mon": {MoveWorkspace: OrderedMap{{Key: "Hyper+1", Value: "left_display"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unparsable hotkey",
+			config: Config{
+				I3:      I3Config{ModKey: "Mod4"},
+				Hotkeys: []HotkeyConfig{{Key: "$mod+", Command: "true"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "assignment to unknown numbered workspace",
+			config: Config{
+				I3:          I3Config{ModKey: "Mod4"},
+				Assignments: []Assignment{{Match: Criteria{Class: "^Slack$"}, AssignmentTarget: AssignmentTarget{Workspace: "12"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "assignment to workspace placed by a layout",
+			config: Config{
+				I3:          I3Config{ModKey: "Mod4"},
+				Layouts:     map[string]LayoutConfig{"two_mon": {WorkspaceToDisplay: OrderedMap{{Key: "12", Value: "left_display"}}}},
+				Assignments: []Assignment{{Match: Criteria{Class: "^Slack$"}, AssignmentTarget: AssignmentTarget{Workspace: "12"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "assignment to numbered workspace with per-output workspaces",
+			config: Config{
+				I3:          I3Config{ModKey: "Mod4"},
+				Workspaces:  WorkspacesConfig{Scheme: WorkspaceSchemePerOutput, PerOutput: 10},
+				Assignments: []Assignment{{Match: Criteria{Class: "^Slack$"}, AssignmentTarget: AssignmentTarget{Workspace: "12"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "min_monitors beyond dummy monitors",
+			config: Config{
+				I3:               I3Config{ModKey: "Mod4"},
+				MonitorDetection: MonitorConfig{DummyMonitors: []string{"dummy1"}, MinMonitors: 3},
+			},
+			wantErr: true,
+		},
+		{
+			name: "min_monitors reachable with dummy monitors",
+			config: Config{
+				I3:               I3Config{ModKey: "Mod4"},
+				MonitorDetection: MonitorConfig{DummyMonitors: []string{"dummy1", "dummy2"}, MinMonitors: 3},
+			},
+			wantErr: false,
+		},
 		{
 			name: "monitor detection enabled but no detection method",
 			config: Config{
-				I3:                  I3Config{ModKey: "Mod4"},
-				UseDetectedMonitors: true,
+				I3: I3Config{ModKey: "Mod4"},
 				MonitorDetection: MonitorConfig{
+					Enabled:          true,
 					UseNative:        false,
 					DetectionCommand: "",
 				},
@@ -210,6 +342,57 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_ListsEveryProblem(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	configContent := `i3:
+  mod_key: "Mod4"
+default_layout: two_mon
+layouts:
+  two_mon:
+    gaps_inner: -3
+    move_workspace:
+      "Ctrl+Shift+1": "left_display"
+      "Hyper+2": "right_display"
+hotkeys:
+  - key: "$mod+"
+    command: "true"
+assignments:
+  - match:
+      class: "^Slack$"
+    workspace: "12"
+`
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	_, err = NewLoader(tempDir).LoadFromFile(configPath)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+
+	expected := []string{
+		configPath + `:6:17: layouts.two_mon.gaps_inner: must not be negative`,
+		configPath + `:9:18: layouts.two_mon.move_workspace.Hyper+2: key "Hyper+2" has unknown modifier "Hyper"`,
+		configPath + `:11:10: hotkeys[0].key: key "$mod+" has no key after the modifiers`,
+		configPath + `:16:16: assignments[0].workspace: unknown workspace 12`,
+	}
+	if len(validationErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), err)
+	}
+	for i, problem := range validationErr.Problems {
+		if problem.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], problem.String())
+		}
+	}
+}
+
 func TestConfig_GetLayout(t *testing.T) {
 	config := Config{
 		Layouts: map[string]LayoutConfig{
@@ -235,3 +418,60 @@ func TestConfig_GetLayout(t *testing.T) {
 		t.Error("Expected error for non-existing layout")
 	}
 }
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	config := Config{
+		I3:       I3Config{ModKey: "Mod4"},
+		Terminal: "/usr/bin/alacritty",
+		MediaKeys: MediaKeysConfig{
+			VolumeUp: "/usr/bin/pamixer -i 5",
+		},
+	}
+
+	config.ApplyDefaults()
+
+	// Explicit values must be preserved
+	if config.Terminal != "/usr/bin/alacritty" {
+		t.Errorf("Expected Terminal to be preserved, got '%s'", config.Terminal)
+	}
+	if config.MediaKeys.VolumeUp != "/usr/bin/pamixer -i 5" {
+		t.Errorf("Expected VolumeUp to be preserved, got '%s'", config.MediaKeys.VolumeUp)
+	}
+
+	// Unset values are filled in
+	if config.I3.Font != DefaultFont {
+		t.Errorf("Expected Font '%s', got '%s'", DefaultFont, config.I3.Font)
+	}
+	if config.Launcher.Command != DefaultLauncherCommand {
+		t.Errorf("Expected launcher command '%s', got '%s'", DefaultLauncherCommand, config.Launcher.Command)
+	}
+	if config.Locker.Command != DefaultLockerCommand {
+		t.Errorf("Expected locker command '%s', got '%s'", DefaultLockerCommand, config.Locker.Command)
+	}
+	if config.MediaKeys.VolumeDown != DefaultMediaKeys.VolumeDown {
+		t.Errorf("Expected VolumeDown '%s', got '%s'", DefaultMediaKeys.VolumeDown, config.MediaKeys.VolumeDown)
+	}
+	if config.Backlight.UpLarge != DefaultBacklight.UpLarge {
+		t.Errorf("Expected backlight UpLarge '%s', got '%s'", DefaultBacklight.UpLarge, config.Backlight.UpLarge)
+	}
+	if config.Bar.StatusCommand != DefaultBarStatusCommand {
+		t.Errorf("Expected bar status command '%s', got '%s'", DefaultBarStatusCommand, config.Bar.StatusCommand)
+	}
+
+	// Keyboard layout has no default: setxkbmap is skipped unless configured
+	if config.Keyboard.Layout != "" {
+		t.Errorf("Expected empty keyboard layout, got '%s'", config.Keyboard.Layout)
+	}
+}
+
+func TestConfig_ApplyDefaults_ExternalBar(t *testing.T) {
+	config := Config{
+		Bar: BarConfig{Command: "polybar-launch.sh"},
+	}
+
+	config.ApplyDefaults()
+
+	if config.Bar.StatusCommand != "" {
+		t.Errorf("Expected no status command with an external bar, got '%s'", config.Bar.StatusCommand)
+	}
+}
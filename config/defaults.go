@@ -0,0 +1,93 @@
+package config
+
+const (
+	DefaultFont             = "pango:monospace 8"
+	DefaultTerminal         = "i3-sensible-terminal"
+	DefaultLauncherKey      = "$mod+d"
+	DefaultLauncherCommand  = "dmenu_run"
+	DefaultLockerKey        = "Control+mod1+l"
+	DefaultLockerCommand    = "i3lock"
+	DefaultBarStatusCommand = "i3status"
+	DefaultBarPosition      = "bottom"
+	DefaultBorderWidth      = 2
+	DefaultWorkspaceScheme  = WorkspaceSchemeShared
+	DefaultWorkspaceCommand = "i3-config-generator workspace"
+	// DefaultWorkspacesPerOutput matches the ten number keys
+	DefaultWorkspacesPerOutput = 10
+)
+
+// DefaultMediaKeys are generic PulseAudio/MPRIS commands that work on most desktops
+var DefaultMediaKeys = MediaKeysConfig{
+	VolumeDown: "pactl set-sink-volume @DEFAULT_SINK@ -5%",
+	VolumeUp:   "pactl set-sink-volume @DEFAULT_SINK@ +5%",
+	VolumeMute: "pactl set-sink-mute @DEFAULT_SINK@ toggle",
+	PlayPause:  "playerctl play-pause",
+	Next:       "playerctl next",
+	Previous:   "playerctl previous",
+}
+
+// DefaultGapKeys are the gap adjustment bindings of the built-in template
+var DefaultGapKeys = GapKeysConfig{
+	Step:            5,
+	Decrease:        "$Mod+shift+g",
+	Increase:        "$Mod+shift+h",
+	DecreaseCurrent: "$Mod+shift+ctrl+g",
+	IncreaseCurrent: "$Mod+shift+ctrl+h",
+}
+
+// DefaultBacklight uses brightnessctl, which needs no special permissions on systemd hosts
+var DefaultBacklight = BacklightConfig{
+	Down:      "brightnessctl set 10%-",
+	Up:        "brightnessctl set +10%",
+	DownLarge: "brightnessctl set 25%-",
+	UpLarge:   "brightnessctl set +25%",
+}
+
+// ApplyDefaults fills in unset optional settings with sensible defaults
+func (c *Config) ApplyDefaults() {
+	setDefault(&c.I3.Font, DefaultFont)
+	setDefault(&c.Terminal, DefaultTerminal)
+	setDefault(&c.Launcher.Key, DefaultLauncherKey)
+	setDefault(&c.Launcher.Command, DefaultLauncherCommand)
+	setDefault(&c.Locker.Key, DefaultLockerKey)
+	setDefault(&c.Locker.Command, DefaultLockerCommand)
+
+	// Built-in bar settings only matter when no external bar is launched
+	if c.Bar.Command == "" {
+		setDefault(&c.Bar.StatusCommand, DefaultBarStatusCommand)
+		setDefault(&c.Bar.Position, DefaultBarPosition)
+	}
+
+	setDefault(&c.MediaKeys.VolumeDown, DefaultMediaKeys.VolumeDown)
+	setDefault(&c.MediaKeys.VolumeUp, DefaultMediaKeys.VolumeUp)
+	setDefault(&c.MediaKeys.VolumeMute, DefaultMediaKeys.VolumeMute)
+	setDefault(&c.MediaKeys.PlayPause, DefaultMediaKeys.PlayPause)
+	setDefault(&c.MediaKeys.Next, DefaultMediaKeys.Next)
+	setDefault(&c.MediaKeys.Previous, DefaultMediaKeys.Previous)
+
+	setDefault(&c.Backlight.Down, DefaultBacklight.Down)
+	setDefault(&c.Backlight.Up, DefaultBacklight.Up)
+	setDefault(&c.Backlight.DownLarge, DefaultBacklight.DownLarge)
+	setDefault(&c.Backlight.UpLarge, DefaultBacklight.UpLarge)
+
+	setDefault(&c.Workspaces.Scheme, DefaultWorkspaceScheme)
+	setDefault(&c.Workspaces.Command, DefaultWorkspaceCommand)
+	if c.Workspaces.PerOutput == 0 {
+		c.Workspaces.PerOutput = DefaultWorkspacesPerOutput
+	}
+
+	if c.GapKeys.Step == 0 {
+		c.GapKeys.Step = DefaultGapKeys.Step
+	}
+	setDefault(&c.GapKeys.Decrease, DefaultGapKeys.Decrease)
+	setDefault(&c.GapKeys.Increase, DefaultGapKeys.Increase)
+	setDefault(&c.GapKeys.DecreaseCurrent, DefaultGapKeys.DecreaseCurrent)
+	setDefault(&c.GapKeys.IncreaseCurrent, DefaultGapKeys.IncreaseCurrent)
+}
+
+// setDefault assigns value to field if the field is empty
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Binding modes of the generated config that extra snippets can be added to
+const (
+	ExtraModeResize = "resize"
+	ExtraModePower  = "power"
+)
+
+// ExtraModes lists the binding modes accepted in extra.modes
+var ExtraModes = []string{ExtraModePower, ExtraModeResize}
+
+// ExtraConfig holds raw i3 config snippets injected at fixed points of the
+// generated config, for i3 features the generator does not model
+type ExtraConfig struct {
+	// BeforeKeybindings snippets are added before the first keybinding
+	BeforeKeybindings []Snippet `yaml:"before_keybindings"`
+	// AfterWorkspaces snippets follow the workspace bindings and output assignments
+	AfterWorkspaces []Snippet `yaml:"after_workspaces"`
+	// EndOfFile snippets are appended after everything else
+	EndOfFile []Snippet `yaml:"end_of_file"`
+	// Modes adds snippets inside the blocks of the generated binding modes
+	Modes map[string][]Snippet `yaml:"modes" schema:"keys=extra_mode"`
+}
+
+// Snippet is a piece of raw i3 config, written inline or read from a file
+// In YAML a plain string is an inline snippet; {file: path} reads a file
+type Snippet struct {
+	Raw  string `yaml:"raw,omitempty"`
+	File string `yaml:"file,omitempty"`
+}
+
+// UnmarshalYAML accepts either a string or a mapping with raw or file
+func (s *Snippet) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Raw = node.Value
+		return nil
+	}
+
+	type plain Snippet
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	if (s.Raw == "") == (s.File == "") {
+		return fmt.Errorf("line %d: snippet needs exactly one of raw or file", node.Line)
+	}
+	return nil
+}
+
+// MarshalYAML writes inline snippets back as plain strings
+func (s Snippet) MarshalYAML() (interface{}, error) {
+	if s.File == "" {
+		return s.Raw, nil
+	}
+	type plain Snippet
+	return plain(s), nil
+}
+
+// Content returns the snippet text, reading it from its file if needed
+func (s Snippet) Content() (string, error) {
+	if s.File == "" {
+		return s.Raw, nil
+	}
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return "", fmt.Errorf("failed to read snippet file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// validate checks that snippets are only added to modes the generated config defines
+func (e *ExtraConfig) validate() error {
+	var modes []string
+	for mode := range e.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	for _, mode := range modes {
+		if !slices.Contains(ExtraModes, mode) {
+			return fmt.Errorf("unknown mode '%s' in modes (valid options: %s)", mode, strings.Join(ExtraModes, ", "))
+		}
+	}
+	return nil
+}
+
+// resolveFiles makes snippet file paths absolute, relative paths being taken
+// from the directory of the configuration file
+func (e *ExtraConfig) resolveFiles(configDir string) error {
+	lists := [][]Snippet{e.BeforeKeybindings, e.AfterWorkspaces, e.EndOfFile}
+	for _, snippets := range e.Modes {
+		lists = append(lists, snippets)
+	}
+
+	for _, snippets := range lists {
+		for i := range snippets {
+			if snippets[i].File == "" {
+				continue
+			}
+			path, err := expandHome(snippets[i].File)
+			if err != nil {
+				return fmt.Errorf("failed to expand snippet file %s: %w", snippets[i].File, err)
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(configDir, path)
+			}
+			snippets[i].File = path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSnippet_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Snippet
+		wantErr  bool
+	}{
+		{
+			name:     "plain strings",
+			input:    "- default_border pixel 1\n- |\n  mode \"launch\" {\n  \tbindsym f exec firefox\n  }\n",
+			expected: []Snippet{{Raw: "default_border pixel 1"}, {Raw: "mode \"launch\" {\n\tbindsym f exec firefox\n}\n"}},
+		},
+		{
+			name:     "raw and file mappings",
+			input:    "- raw: focus_follows_mouse no\n- file: snippets/rules.conf\n",
+			expected: []Snippet{{Raw: "focus_follows_mouse no"}, {File: "snippets/rules.conf"}},
+		},
+		{
+			name:    "both raw and file",
+			input:   "- raw: focus_follows_mouse no\n  file: snippets/rules.conf\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty mapping",
+			input:   "- {}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var snippets []Snippet
+			err := yaml.Unmarshal([]byte(tt.input), &snippets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(snippets, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, snippets)
+			}
+		})
+	}
+}
+
+func TestSnippet_MarshalYAML(t *testing.T) {
+	data, err := yaml.Marshal([]Snippet{{Raw: "default_border pixel 1"}, {File: "rules.conf"}})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expected := "- default_border pixel 1\n- file: rules.conf\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestLoader_LoadFromFile_Extra(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "rules.conf"), []byte("for_window [class=\"mpv\"] sticky enable\n\n"), 0644); err != nil {
+		t.Fatalf("Failed to write snippet file: %v", err)
+	}
+
+	configPath := filepath.Join(tempDir, "config.yaml")
+	content := `
+i3:
+  mod_key: "Mod4"
+extra:
+  before_keybindings:
+    - default_border pixel 1
+  end_of_file:
+    - file: rules.conf
+  modes:
+    resize:
+      - bindsym h resize shrink width 1 px
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := NewLoader(tempDir).LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.Extra.BeforeKeybindings) != 1 || config.Extra.BeforeKeybindings[0].Raw != "default_border pixel 1" {
+		t.Errorf("Expected before_keybindings snippet, got %+v", config.Extra.BeforeKeybindings)
+	}
+	if len(config.Extra.Modes[ExtraModeResize]) != 1 {
+		t.Errorf("Expected resize mode snippet, got %+v", config.Extra.Modes)
+	}
+
+	// Relative snippet files are read from the config directory
+	if len(config.Extra.EndOfFile) != 1 || config.Extra.EndOfFile[0].File != filepath.Join(tempDir, "rules.conf") {
+		t.Fatalf("Expected snippet file relative to the config, got %+v", config.Extra.EndOfFile)
+	}
+	snippet, err := config.Extra.EndOfFile[0].Content()
+	if err != nil {
+		t.Fatalf("Failed to read snippet: %v", err)
+	}
+	if snippet != `for_window [class="mpv"] sticky enable` {
+		t.Errorf("Expected snippet file content without trailing newlines, got %q", snippet)
+	}
+
+	// Test: Unknown mode
+	invalid := strings.Replace(content, "resize:", "launch:", 1)
+	if err := os.WriteFile(configPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	_, err = NewLoader(tempDir).LoadFromFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), `extra.modes.launch: unknown mode "launch"`) {
+		t.Errorf("Expected unknown mode error, got %v", err)
+	}
+}
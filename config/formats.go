@@ -0,0 +1,584 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// Formats lists the supported configuration file formats
+var Formats = []string{FormatYAML, FormatJSON, FormatTOML}
+
+// FormatOf returns the format of a configuration file from its extension;
+// files with other extensions are read as YAML
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// formatName returns the name of a format for messages
+func formatName(format string) string {
+	return strings.ToUpper(format)
+}
+
+// parseDocument parses a configuration file in the given format into a YAML node
+// tree, so every format shares the schema check, migrations and secrets
+// JSON is read by the YAML parser, of which it is a subset, keeping positions;
+// TOML documents have no positions
+func parseDocument(data []byte, format string) (*yaml.Node, error) {
+	document := &yaml.Node{}
+	switch format {
+	case FormatTOML:
+		var values map[string]interface{}
+		metadata, err := toml.Decode(string(data), &values)
+		if err != nil {
+			return nil, err
+		}
+		root, err := tomlNode(values, nil, tomlKeyOrder(metadata))
+		if err != nil {
+			return nil, err
+		}
+		document.Kind = yaml.DocumentNode
+		document.Content = []*yaml.Node{root}
+	default:
+		if err := yaml.Unmarshal(data, document); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+// readDocument reads a configuration file in the format given by its extension
+func readDocument(path string) ([]byte, *yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	format := FormatOf(path)
+	document, err := parseDocument(data, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s config file %s: %w", formatName(format), path, err)
+	}
+	return data, document, nil
+}
+
+// ConvertFile rewrites the configuration file at path, or the one in the
+// configuration directory when path is empty, in another format
+// The result is checked to read back to the same document before it is returned
+func (l *Loader) ConvertFile(path, format string) ([]byte, error) {
+	if path == "" {
+		found, err := l.findConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		path = found
+	}
+
+	_, document, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := EncodeDocument(document, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s to %s: %w", path, formatName(format), err)
+	}
+
+	// Compare the values only, as comments, styles and key order may change
+	readBack, err := parseDocument(converted, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back converted config: %w", err)
+	}
+	var want, got interface{}
+	if err := document.Decode(&want); err != nil {
+		return nil, err
+	}
+	if err := readBack.Decode(&got); err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(dropNulls(want), dropNulls(got)) {
+		return nil, fmt.Errorf("converting %s to %s would change its values", path, formatName(format))
+	}
+	return converted, nil
+}
+
+// dropNulls removes null mapping values, which TOML cannot represent and which
+// read the same as missing keys
+func dropNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+			} else {
+				v[key] = dropNulls(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = dropNulls(item)
+		}
+	}
+	return value
+}
+
+// EncodeDocument writes a configuration document in the given format; comments
+// are only kept in YAML
+func EncodeDocument(document *yaml.Node, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return spaceSections(buf.Bytes()), nil
+	case FormatJSON:
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, documentRoot(document), ""); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	case FormatTOML:
+		root := documentRoot(document)
+		if root.Kind == 0 {
+			return nil, nil
+		}
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("a TOML document must be a mapping")
+		}
+		var buf bytes.Buffer
+		if err := writeTOMLTable(&buf, root, nil); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown format %s (valid options: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// tomlKeyOrder numbers the keys of a TOML document in the order they appear,
+// including tables that are only defined implicitly by their subtables
+func tomlKeyOrder(metadata toml.MetaData) map[string]int {
+	order := map[string]int{}
+	for _, key := range metadata.Keys() {
+		for i := 1; i <= len(key); i++ {
+			path := strings.Join(key[:i], "\x00")
+			if _, ok := order[path]; !ok {
+				order[path] = len(order)
+			}
+		}
+	}
+	return order
+}
+
+// tomlNode converts a decoded TOML value at path into a YAML node
+func tomlNode(value interface{}, path []string, order map[string]int) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		position := func(key string) int {
+			if index, ok := order[strings.Join(append(path, key), "\x00")]; ok {
+				return index
+			}
+			return math.MaxInt
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			if position(keys[i]) != position(keys[j]) {
+				return position(keys[i]) < position(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := tomlNode(v[key], append(path[:len(path):len(path)], key), order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		return node, nil
+	case []map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	case time.Time:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Format(time.RFC3339)}, nil
+	case fmt.Stringer:
+		// Local dates and times, which no setting uses
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.String()}, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported TOML value %v", strings.Join(path, "."), value)
+	}
+}
+
+// resolveAlias returns the node an alias refers to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// writeJSON writes node as indented JSON, keeping the order of mapping keys
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case 0:
+		buf.WriteString("{}")
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, err := marshalJSON(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(indent + "  " + key + ": ")
+			if err := writeJSON(buf, node.Content[i+1], indent+"  "); err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(indent + "  ")
+			if err := writeJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	case yaml.ScalarNode:
+		value, err := scalarJSON(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(value)
+	default:
+		return fmt.Errorf("line %d: cannot convert YAML node to JSON", node.Line)
+	}
+	return nil
+}
+
+// scalarJSON returns a scalar as a JSON value
+func scalarJSON(node *yaml.Node) (string, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case nil, bool, int, int64, uint64, string:
+		return marshalJSON(v)
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", fmt.Errorf("line %d: %s cannot be written as JSON", node.Line, node.Value)
+		}
+		return marshalJSON(v)
+	default:
+		// Timestamps and other tagged values are kept as written
+		return marshalJSON(node.Value)
+	}
+}
+
+// marshalJSON encodes a JSON value without escaping HTML characters, which are
+// common in i3 commands
+func marshalJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// tomlLineWidth is the length above which arrays are split over several lines
+const tomlLineWidth = 100
+
+// writeTOMLTable writes the entries of a mapping as a TOML table at path: plain
+// values first, then subtables and arrays of tables, as TOML requires
+func writeTOMLTable(buf *bytes.Buffer, mapping *yaml.Node, path []string) error {
+	type entry struct {
+		key   string
+		value *yaml.Node
+	}
+	var tables []entry
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, resolveAlias(mapping.Content[i+1])
+		if isTOMLTable(value) || isTOMLTableArray(value) {
+			tables = append(tables, entry{key, value})
+			continue
+		}
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			// TOML has no null; an unset value is simply left out
+			continue
+		}
+		inline, err := tomlInline(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(append(path, key), "."), err)
+		}
+		line := tomlKey(key) + " = " + inline
+		if value.Kind == yaml.SequenceNode && len(value.Content) > 1 && len(line) > tomlLineWidth {
+			// Long arrays get one item per line, like YAML sequences
+			line = tomlKey(key) + " = [\n"
+			for _, item := range value.Content {
+				itemValue, err := tomlInline(item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", strings.Join(append(path, key), "."), err)
+				}
+				line += "  " + itemValue + ",\n"
+			}
+			line += "]"
+		}
+		buf.WriteString(line + "\n")
+	}
+
+	for _, table := range tables {
+		tablePath := append(path[:len(path):len(path)], table.key)
+		header := tomlKeyPath(tablePath)
+		if isTOMLTableArray(table.value) {
+			for _, item := range table.value.Content {
+				buf.WriteString("\n[[" + header + "]]\n")
+				if err := writeTOMLTable(buf, resolveAlias(item), tablePath); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if hasTOMLValues(table.value) {
+			// Tables holding only subtables are defined by them
+			buf.WriteString("\n[" + header + "]\n")
+		}
+		if err := writeTOMLTable(buf, table.value, tablePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasTOMLValues reports whether a mapping has entries that are not tables
+func hasTOMLValues(mapping *yaml.Node) bool {
+	for i := 1; i < len(mapping.Content); i += 2 {
+		value := resolveAlias(mapping.Content[i])
+		if !isTOMLTable(value) && !isTOMLTableArray(value) && value.Tag != "!!null" {
+			return true
+		}
+	}
+	return false
+}
+
+// isTOMLTable reports whether a value is written as a [table]; empty mappings are
+// written inline so they stay next to their siblings
+func isTOMLTable(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && len(node.Content) > 0
+}
+
+// isTOMLTableArray reports whether a value is written as an [[array of tables]]
+func isTOMLTableArray(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if resolveAlias(item).Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline returns a value in TOML inline syntax
+func tomlInline(node *yaml.Node) (string, error) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		var entries []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if value := resolveAlias(node.Content[i+1]); value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				continue
+			}
+			value, err := tomlInline(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, tomlKey(node.Content[i].Value)+" = "+value)
+		}
+		if len(entries) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(entries, ", ") + " }", nil
+	case yaml.SequenceNode:
+		var items []string
+		for _, item := range node.Content {
+			value, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return "", err
+		}
+		switch v := value.(type) {
+		case nil:
+			return "", fmt.Errorf("null values cannot be written as TOML")
+		case bool:
+			return strconv.FormatBool(v), nil
+		case int:
+			return strconv.Itoa(v), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case uint64:
+			return strconv.FormatUint(v, 10), nil
+		case float64:
+			return tomlFloat(v), nil
+		case string:
+			return tomlString(v), nil
+		default:
+			return tomlString(node.Value), nil
+		}
+	default:
+		return "", fmt.Errorf("line %d: cannot convert YAML node to TOML", node.Line)
+	}
+}
+
+// tomlFloat formats a float so TOML reads it back as a float
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return s
+}
+
+// tomlString quotes a string as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tomlKey returns a key bare when TOML allows it, quoted otherwise
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return tomlString(key)
+		}
+	}
+	return key
+}
+
+// tomlKeyPath returns a dotted table name
+func tomlKeyPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
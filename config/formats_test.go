@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formatsTestConfig = `version: 2
+i3:
+  mod_key: "Mod4"
+  font: "pango:Ubuntu Mono 8"
+terminal: "/usr/bin/alacritty"
+hotkeys:
+  - key: "Print"
+    command: "flameshot gui"
+  - key: "Mod4+Shift+x"
+    command: "xkill"
+    no_startup_id: true
+monitor_detection:
+  enabled: false
+  dummy_monitors: ["dummy1", "dummy2"]
+layouts:
+  two_mon:
+    description: "Two external monitors"
+    gaps_inner: 20
+    workspace_to_display:
+      "1": "primary_display"
+      "2": "left_display"
+  one_mon:
+    description: "One external monitor"
+    workspace_to_display:
+      "1": "primary_display"
+application_bindings:
+  "[class=\"Firefox\"]": "2"
+  "[class=\"Slack\"]": "1"
+extra: {}
+`
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"config.yaml", FormatYAML},
+		{"config.yml", FormatYAML},
+		{"/etc/i3cg/config.JSON", FormatJSON},
+		{"config.toml", FormatTOML},
+		{"config", FormatYAML},
+	}
+
+	for _, tt := range tests {
+		if got := FormatOf(tt.path); got != tt.expected {
+			t.Errorf("FormatOf(%q): expected %s, got %s", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestLoader_ConvertFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	yamlPath := filepath.Join(tempDir, ConfigFileYAML)
+	if err := os.WriteFile(yamlPath, []byte(formatsTestConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	loader := NewLoader(tempDir)
+	expected, err := loader.LoadFromFile(yamlPath)
+	if err != nil {
+		t.Fatalf("Failed to load YAML config: %v", err)
+	}
+
+	for _, format := range []string{FormatJSON, FormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			converted, err := loader.ConvertFile("", format)
+			if err != nil {
+				t.Fatalf("Failed to convert config: %v", err)
+			}
+			path := filepath.Join(tempDir, "config."+format)
+			if err := os.WriteFile(path, converted, 0644); err != nil {
+				t.Fatalf("Failed to write converted config: %v", err)
+			}
+
+			// The same settings load from every format, in the same order
+			cfg, err := loader.LoadFromFile(path)
+			if err != nil {
+				t.Fatalf("Failed to load converted config: %v\n%s", err, converted)
+			}
+			if !reflect.DeepEqual(cfg, expected) {
+				t.Errorf("Expected the %s config to load like the YAML one, got %+v", format, cfg)
+			}
+
+			// Converting back gives the same YAML, apart from comments and quoting
+			back, err := loader.ConvertFile(path, FormatYAML)
+			if err != nil {
+				t.Fatalf("Failed to convert config back: %v", err)
+			}
+			backPath := filepath.Join(tempDir, "back.yaml")
+			if err := os.WriteFile(backPath, back, 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			cfg, err = loader.LoadFromFile(backPath)
+			if err != nil {
+				t.Fatalf("Failed to load round-tripped config: %v\n%s", err, back)
+			}
+			if !reflect.DeepEqual(cfg, expected) {
+				t.Errorf("Expected the round-tripped config to load like the original, got %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestParse_Formats(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		errorMsg string
+	}{
+		{
+			name: "json",
+			data: `{"i3": {"mod_key": "Mod4"}, "terminal": "xterm"}`,
+		},
+		{
+			name: "toml",
+			data: "terminal = \"xterm\"\n\n[i3]\nmod_key = \"Mod4\"\n",
+		},
+		{
+			name:     "json",
+			data:     "{\"i3\": {\"mod_key\": \"Mod4\"},\n \"terminl\": \"xterm\"}",
+			errorMsg: "config.json:2:2: terminl: unknown key",
+		},
+		{
+			name:     "toml",
+			data:     "[i3]\nmod_key = \"Mod4\"\nfnt = \"pango:Sans 8\"\n",
+			errorMsg: "i3.fnt: unknown key",
+		},
+		{
+			name:     "toml",
+			data:     "[i3\n",
+			errorMsg: "failed to parse TOML config file config.toml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.data), "config."+tt.name)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.I3.ModKey != "Mod4" || cfg.Terminal != "xterm" {
+				t.Errorf("Expected mod key Mod4 and terminal xterm, got %s and %s", cfg.I3.ModKey, cfg.Terminal)
+			}
+		})
+	}
+}
+
+func TestLoader_findConfigFile_Formats(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	loader := NewLoader(tempDir)
+	_, err = loader.findConfigFile()
+	if err == nil || !strings.Contains(err.Error(), "config.yaml, config.yml, config.json, config.toml") {
+		t.Errorf("Expected error listing every file name, got %v", err)
+	}
+
+	// TOML is found when it is the only file, and JSON is preferred over it
+	for _, name := range []string{ConfigFileTOML, ConfigFileJSON} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		found, err := loader.findConfigFile()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if found != path {
+			t.Errorf("Expected %s, got %s", path, found)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Values accepted by the gaps border settings
+var (
+	SmartGapsModes       = []string{"on", "off", "inverse_outer"}
+	SmartBordersModes    = []string{"on", "off", "no_gaps"}
+	HideEdgeBordersModes = []string{"none", "vertical", "horizontal", "both", "smart", "smart_no_gaps"}
+)
+
+// GapsConfig is the i3 4.22 gaps model of a layout
+type GapsConfig struct {
+	GapSizes `yaml:",inline"`
+	// Workspaces sets gaps for individual workspaces
+	Workspaces      []WorkspaceGaps `yaml:"workspaces"`
+	SmartGaps       string          `yaml:"smart_gaps" schema:"smart_gaps"`
+	SmartBorders    string          `yaml:"smart_borders" schema:"smart_borders"`
+	HideEdgeBorders string          `yaml:"hide_edge_borders" schema:"hide_edge_borders"`
+}
+
+// GapSizes are gap sizes in pixels; unset sizes are left to i3
+// The outer sizes apply on top of outer, and may be negative to cancel the inner gaps at screen edges
+type GapSizes struct {
+	Inner      *int `yaml:"inner"`
+	Outer      *int `yaml:"outer"`
+	Horizontal *int `yaml:"horizontal"`
+	Vertical   *int `yaml:"vertical"`
+	Top        *int `yaml:"top"`
+	Right      *int `yaml:"right"`
+	Bottom     *int `yaml:"bottom"`
+	Left       *int `yaml:"left"`
+}
+
+// WorkspaceGaps sets the gaps of a single workspace
+type WorkspaceGaps struct {
+	Workspace string `yaml:"workspace"`
+	GapSizes  `yaml:",inline"`
+}
+
+// GapKeysConfig holds the key bindings that adjust gaps at runtime
+// Keys default to the bindings of the built-in template; "none" removes a binding
+type GapKeysConfig struct {
+	// Step is the number of pixels each key press changes the gaps by
+	Step            int    `yaml:"step"`
+	Decrease        string `yaml:"decrease"`
+	Increase        string `yaml:"increase"`
+	DecreaseCurrent string `yaml:"decrease_current"`
+	IncreaseCurrent string `yaml:"increase_current"`
+}
+
+// NoKey disables a default key binding
+const NoKey = "none"
+
+// gapSize is a set gap size with its i3 name
+type gapSize struct {
+	name string
+	size int
+}
+
+// sizes returns the set sizes in the order i3 applies them
+func (g GapSizes) sizes() []gapSize {
+	var sizes []gapSize
+	for _, field := range []struct {
+		name string
+		size *int
+	}{
+		{"inner", g.Inner},
+		{"outer", g.Outer},
+		{"horizontal", g.Horizontal},
+		{"vertical", g.Vertical},
+		{"top", g.Top},
+		{"right", g.Right},
+		{"bottom", g.Bottom},
+		{"left", g.Left},
+	} {
+		if field.size != nil {
+			sizes = append(sizes, gapSize{field.name, *field.size})
+		}
+	}
+	return sizes
+}
+
+// Statements returns the set sizes as "gaps" arguments such as "horizontal 5",
+// scaled by factor
+func (g GapSizes) Statements(factor float64) []string {
+	var statements []string
+	for _, size := range g.sizes() {
+		statements = append(statements, size.name+" "+strconv.Itoa(ScaleSize(float64(size.size), factor)))
+	}
+	return statements
+}
+
+// IsZero reports whether no size is set
+func (g GapSizes) IsZero() bool {
+	return len(g.sizes()) == 0
+}
+
+// validate checks that the inner gap is not negative; outer gaps may be
+func (g GapSizes) validate() error {
+	if g.Inner != nil && *g.Inner < 0 {
+		return fmt.Errorf("inner gaps must not be negative")
+	}
+	return nil
+}
+
+// Statements returns the workspace's gaps as i3 config lines, scaled by factor
+func (w WorkspaceGaps) Statements(factor float64) []string {
+	var statements []string
+	for _, statement := range w.GapSizes.Statements(factor) {
+		statements = append(statements, "workspace "+quoteValueIfNeeded(w.Workspace)+" gaps "+statement)
+	}
+	return statements
+}
+
+// validate checks the gap sizes and border modes of a layout
+func (g GapsConfig) validate() error {
+	var problems problemList
+	problems.add("", g.GapSizes.validate())
+	for i, workspace := range g.Workspaces {
+		path := fmt.Sprintf("workspaces[%d]", i)
+		if workspace.Workspace == "" {
+			problems.addf(path, "workspace is required")
+		}
+		if workspace.GapSizes.IsZero() {
+			problems.addf(path, "at least one gap size is required")
+		}
+		problems.add(path, workspace.GapSizes.validate())
+	}
+	for _, setting := range []struct {
+		name  string
+		value string
+		valid []string
+	}{
+		{"smart_gaps", g.SmartGaps, SmartGapsModes},
+		{"smart_borders", g.SmartBorders, SmartBordersModes},
+		{"hide_edge_borders", g.HideEdgeBorders, HideEdgeBordersModes},
+	} {
+		if setting.value != "" && !slices.Contains(setting.valid, setting.value) {
+			problems.addf("", "invalid %s '%s' (valid options: %s)", setting.name, setting.value, strings.Join(setting.valid, ", "))
+		}
+	}
+	return problems.err()
+}
+
+// ResolvedGaps returns the layout's gap sizes, with gaps_inner and gaps_outer
+// used where gaps.inner and gaps.outer are not set
+func (l LayoutConfig) ResolvedGaps() GapSizes {
+	sizes := l.Gaps.GapSizes
+	if sizes.Inner == nil {
+		sizes.Inner = &l.GapsInner
+	}
+	if sizes.Outer == nil {
+		sizes.Outer = &l.GapsOuter
+	}
+	return sizes
+}
+
+// validateGaps checks the layout's gaps, which may be given by gaps_inner and
+// gaps_outer or in the gaps section but not both
+func (l LayoutConfig) validateGaps() error {
+	var problems problemList
+	if l.GapsInner != 0 && l.Gaps.Inner != nil {
+		problems.addf("", "gaps_inner and gaps.inner are both set")
+	}
+	if l.GapsOuter != 0 && l.Gaps.Outer != nil {
+		problems.addf("", "gaps_outer and gaps.outer are both set")
+	}
+	if l.GapsInner < 0 {
+		problems.addf("gaps_inner", "must not be negative")
+	}
+	problems.add("gaps", l.Gaps.validate())
+	return problems.err()
+}
+
+// validate checks the gap adjustment step and keys
+func (k GapKeysConfig) validate() error {
+	var problems problemList
+	if k.Step < 0 {
+		problems.addf("step", "must not be negative")
+	}
+	for _, binding := range []struct {
+		name string
+		key  string
+	}{
+		{"decrease", k.Decrease},
+		{"increase", k.Increase},
+		{"decrease_current", k.DecreaseCurrent},
+		{"increase_current", k.IncreaseCurrent},
+	} {
+		problems.add(binding.name, validateKey(binding.key))
+	}
+	return problems.err()
+}
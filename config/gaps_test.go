@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLayoutConfig_Gaps(t *testing.T) {
+	input := `
+gaps_outer: 4
+gaps:
+  inner: 10
+  horizontal: 20
+  top: -4
+  workspaces:
+    - workspace: "3: chat"
+      inner: 0
+      outer: 0
+  smart_gaps: inverse_outer
+`
+	var layout LayoutConfig
+	if err := yaml.Unmarshal([]byte(input), &layout); err != nil {
+		t.Fatalf("Failed to unmarshal layout: %v", err)
+	}
+	if err := layout.validateGaps(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// gaps_outer fills in for the unset gaps.outer
+	expected := []string{"inner 10", "outer 4", "horizontal 20", "top -4"}
+	if statements := layout.ResolvedGaps().Statements(1); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Expected %q, got %q", expected, statements)
+	}
+
+	expected = []string{`workspace "3: chat" gaps inner 0`, `workspace "3: chat" gaps outer 0`}
+	if statements := layout.Gaps.Workspaces[0].Statements(1); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Expected %q, got %q", expected, statements)
+	}
+
+	// Sizes are scaled like the other gaps
+	expected = []string{"inner 15", "outer 6", "horizontal 30", "top -6"}
+	if statements := layout.ResolvedGaps().Statements(1.5); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Expected %q, got %q", expected, statements)
+	}
+}
+
+func TestLayoutConfig_validateGaps(t *testing.T) {
+	ten := 10
+	negative := -5
+
+	tests := []struct {
+		name     string
+		layout   LayoutConfig
+		expected string
+	}{
+		{"shorthands only", LayoutConfig{GapsInner: 10, GapsOuter: -2}, ""},
+		{"negative outer", LayoutConfig{Gaps: GapsConfig{GapSizes: GapSizes{Outer: &negative, Left: &negative}}}, ""},
+		{"inner set twice", LayoutConfig{GapsInner: 5, Gaps: GapsConfig{GapSizes: GapSizes{Inner: &ten}}}, "gaps_inner and gaps.inner are both set"},
+		{"negative inner", LayoutConfig{Gaps: GapsConfig{GapSizes: GapSizes{Inner: &negative}}}, "gaps: inner gaps must not be negative"},
+		{"workspace without name", LayoutConfig{Gaps: GapsConfig{Workspaces: []WorkspaceGaps{{GapSizes: GapSizes{Inner: &ten}}}}}, "workspaces[0]: workspace is required"},
+		{"workspace without sizes", LayoutConfig{Gaps: GapsConfig{Workspaces: []WorkspaceGaps{{Workspace: "1"}}}}, "workspaces[0]: at least one gap size is required"},
+		{"invalid smart_gaps", LayoutConfig{Gaps: GapsConfig{SmartGaps: "yes"}}, "invalid smart_gaps 'yes'"},
+		{"invalid smart_borders", LayoutConfig{Gaps: GapsConfig{SmartBorders: "inverse_outer"}}, "invalid smart_borders 'inverse_outer'"},
+		{"invalid hide_edge_borders", LayoutConfig{Gaps: GapsConfig{HideEdgeBorders: "all"}}, "invalid hide_edge_borders 'all'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.layout.validateGaps()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
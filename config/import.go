@@ -0,0 +1,386 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+)
+
+// modVariable is the i3 variable the generated config defines from i3.mod_key
+const modVariable = "$mod"
+
+// ImportedSettings are the settings taken over from a hand-written i3 config
+type ImportedSettings struct {
+	ModKey              string
+	Font                string
+	BarFont             string
+	Terminal            string
+	Keyboard            KeyboardConfig
+	Launcher            LauncherConfig
+	Locker              LockerConfig
+	Bar                 BarConfig
+	MediaKeys           MediaKeysConfig
+	Backlight           BacklightConfig
+	ApplicationBindings OrderedMap
+	Assignments         []Assignment
+	StartupPrograms     []string
+	WindowOverrides     []WindowRule
+	Hotkeys             []HotkeyConfig
+	// GapsInner and GapsOuter are the global gap sizes, set on the default layout
+	GapsInner *int
+	GapsOuter *int
+	// WorkspaceDisplays places workspaces on monitor roles, set by PlaceWorkspaces
+	// from the workspace output statements
+	WorkspaceDisplays OrderedMap
+	// workspaceOutputs are the workspace output statements
+	workspaceOutputs []i3conf.Statement
+
+	// Passthrough holds statements without an equivalent setting; they are kept
+	// as raw config in extra.end_of_file
+	Passthrough []i3conf.Statement
+	// Warnings describe parts of the i3 config that could not be imported
+	Warnings []string
+}
+
+// importedKeyFields maps the media and brightness keys to the settings they configure
+var importedKeyFields = map[string]func(*ImportedSettings) *string{
+	"xf86audiolowervolume":        func(s *ImportedSettings) *string { return &s.MediaKeys.VolumeDown },
+	"xf86audioraisevolume":        func(s *ImportedSettings) *string { return &s.MediaKeys.VolumeUp },
+	"xf86audiomute":               func(s *ImportedSettings) *string { return &s.MediaKeys.VolumeMute },
+	"xf86audioplay":               func(s *ImportedSettings) *string { return &s.MediaKeys.PlayPause },
+	"xf86audionext":               func(s *ImportedSettings) *string { return &s.MediaKeys.Next },
+	"xf86audioprev":               func(s *ImportedSettings) *string { return &s.MediaKeys.Previous },
+	"xf86monbrightnessdown":       func(s *ImportedSettings) *string { return &s.Backlight.Down },
+	"xf86monbrightnessup":         func(s *ImportedSettings) *string { return &s.Backlight.Up },
+	"shift+xf86monbrightnessdown": func(s *ImportedSettings) *string { return &s.Backlight.DownLarge },
+	"shift+xf86monbrightnessup":   func(s *ImportedSettings) *string { return &s.Backlight.UpLarge },
+}
+
+// ImportI3Settings reads a hand-written i3 config, following its includes
+func ImportI3Settings(path string) (*ImportedSettings, error) {
+	parsed, err := i3conf.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ImportI3Config(parsed)
+}
+
+// ImportI3Config maps a parsed i3 config onto configuration settings
+// Variables other than $mod are expanded, as the generated config does not define them
+func ImportI3Config(parsed *i3conf.Config) (*ImportedSettings, error) {
+	expanded, err := parsed.ExpandVariables(modVariable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand variables: %w", err)
+	}
+
+	settings := &ImportedSettings{}
+	barImported := false
+	assigned := map[string]bool{}
+	for _, s := range expanded.Statements {
+		switch s.Keyword {
+		case "set", "set_from_resource":
+			if variable, err := i3conf.ParseVariable(s); err == nil && variable.Name == modVariable {
+				settings.ModKey = variable.Value
+			}
+		case "font":
+			settings.Font = s.RawFrom(0)
+		case "exec", "exec_always":
+			settings.importExec(s)
+		case "bindsym":
+			settings.importBinding(s)
+		case "for_window":
+			rule, err := i3conf.ParseForWindow(s)
+			if err != nil {
+				settings.Passthrough = append(settings.Passthrough, s)
+				continue
+			}
+			settings.WindowOverrides = append(settings.WindowOverrides, ruleFromI3(rule.Criteria, rule.Command))
+		case "assign":
+			// Later assignments of the same windows are kept as raw config
+			assign, err := i3conf.ParseAssign(s)
+			if err != nil || assigned[assign.Criteria] {
+				settings.Passthrough = append(settings.Passthrough, s)
+				continue
+			}
+			assigned[assign.Criteria] = true
+			if assignment, ok := assignmentFromI3(assign.Criteria, assign.Target); ok {
+				settings.Assignments = append(settings.Assignments, assignment)
+				continue
+			}
+			settings.ApplicationBindings.Set(assign.Criteria, assign.Target)
+		case "workspace":
+			// Placements need the detected monitors, see PlaceWorkspaces
+			if _, err := i3conf.ParseWorkspaceOutput(s); err != nil {
+				settings.Passthrough = append(settings.Passthrough, s)
+				continue
+			}
+			settings.workspaceOutputs = append(settings.workspaceOutputs, s)
+		case "bar":
+			// Further bars have no equivalent setting but work as raw config
+			if !s.IsBlock || barImported {
+				settings.Passthrough = append(settings.Passthrough, s)
+				continue
+			}
+			settings.importBar(s)
+			barImported = true
+		case "gaps":
+			// Gaps of single sides have no equivalent setting
+			if !settings.importGaps(s) {
+				settings.Passthrough = append(settings.Passthrough, s)
+			}
+		default:
+			settings.Passthrough = append(settings.Passthrough, s)
+		}
+	}
+	// A config without a bar block starts its bar elsewhere, if at all
+	settings.Bar.Disabled = !barImported
+
+	return settings, nil
+}
+
+// importExec maps startup programs and the keyboard layout
+func (s *ImportedSettings) importExec(statement i3conf.Statement) {
+	exec, err := i3conf.ParseExec(statement)
+	if err != nil {
+		s.Passthrough = append(s.Passthrough, statement)
+		return
+	}
+
+	fields := strings.Fields(exec.Command)
+	switch {
+	case len(fields) == 0:
+	case fields[0] == "setxkbmap" && s.Keyboard.Layout == "":
+		if keyboard := parseSetxkbmap(fields[1:]); keyboard.Layout != "" {
+			s.Keyboard = keyboard
+			return
+		}
+	case exec.Always:
+	case len(fields) == 4 && fields[0] == "xrandr" && fields[1] == "--output" && fields[3] == "--primary":
+		// The generated config selects the primary output itself
+		return
+	default:
+		s.StartupPrograms = append(s.StartupPrograms, exec.Command)
+		return
+	}
+	s.Passthrough = append(s.Passthrough, statement)
+}
+
+// importBinding maps exec bindings onto the terminal, launcher, locker, media keys
+// and hotkeys; other bindings are passed through
+func (s *ImportedSettings) importBinding(statement i3conf.Statement) {
+	binding, err := i3conf.ParseBinding(statement)
+	if err != nil || len(binding.Flags) > 0 {
+		s.Passthrough = append(s.Passthrough, statement)
+		return
+	}
+	exec, ok := binding.Exec()
+	if !ok {
+		s.Passthrough = append(s.Passthrough, statement)
+		return
+	}
+
+	key := binding.NormalizedKey()
+	if field, ok := importedKeyFields[key]; ok && *field(s) == "" {
+		*field(s) = exec.Command
+		return
+	}
+
+	switch {
+	case key == i3conf.NormalizeKey(modVariable+"+Return") && s.Terminal == "":
+		s.Terminal = exec.Command
+	case key == i3conf.NormalizeKey(DefaultLauncherKey) && s.Launcher.Command == "":
+		s.Launcher = LauncherConfig{Key: binding.Key, Command: exec.Command}
+	case s.Locker.Command == "" && isLocker(exec.Command):
+		s.Locker = LockerConfig{Key: binding.Key, Command: exec.Command}
+	default:
+		s.Hotkeys = append(s.Hotkeys, HotkeyConfig{
+			Key:         binding.Key,
+			Command:     exec.Command,
+			NoStartupID: exec.NoStartupID,
+		})
+	}
+}
+
+// PlaceWorkspaces places the imported workspaces on the roles their outputs have
+// among the detected monitors; monitors is nil when detection failed. The generated
+// layouts emit their own placements, so placements on outputs without a role are
+// dropped with a warning rather than kept as conflicting raw config
+func (s *ImportedSettings) PlaceWorkspaces(monitors *monitor.DetectedMonitors) {
+	s.WorkspaceDisplays = nil
+	for _, statement := range s.workspaceOutputs {
+		placement, err := i3conf.ParseWorkspaceOutput(statement)
+		if err != nil {
+			continue
+		}
+		if monitors == nil {
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s:%d: workspace placement not imported, no monitors were detected: %s",
+				statement.File, statement.Line, statement.Raw))
+			continue
+		}
+		// Of a list of fallback outputs the first one with a role is used
+		role := ""
+		for _, output := range placement.Outputs {
+			if role = outputRole(monitors, output); role != "" {
+				break
+			}
+		}
+		if role == "" {
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s:%d: workspace placement not imported, no detected monitor with a role is named %s: %s",
+				statement.File, statement.Line, strings.Join(placement.Outputs, " or "), statement.Raw))
+			continue
+		}
+		s.WorkspaceDisplays.Set(placement.Workspace, role)
+	}
+}
+
+// outputRole returns the role of the named monitor, empty if it has none
+func outputRole(monitors *monitor.DetectedMonitors, output string) string {
+	for _, role := range monitor.Roles {
+		if name, err := monitors.GetMonitorByRole(role); err == nil && name != "" && name == output {
+			return role
+		}
+	}
+	return ""
+}
+
+// importGaps maps a 'gaps inner|outer <size>' statement, reporting whether it was imported
+func (s *ImportedSettings) importGaps(statement i3conf.Statement) bool {
+	if len(statement.Args) != 2 {
+		return false
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(statement.Args[1], "px"))
+	if err != nil || size < 0 {
+		return false
+	}
+	switch statement.Args[0] {
+	case "inner":
+		s.GapsInner = &size
+	case "outer":
+		s.GapsOuter = &size
+	default:
+		return false
+	}
+	return true
+}
+
+// importBar maps the settings of the built-in bar
+func (s *ImportedSettings) importBar(statement i3conf.Statement) {
+	for _, setting := range statement.Block {
+		switch {
+		case setting.Keyword == "font" && !setting.IsBlock:
+			s.BarFont = setting.RawFrom(0)
+		case setting.Keyword == "status_command" && !setting.IsBlock:
+			s.Bar.StatusCommand = setting.RawFrom(0)
+		case setting.Keyword == "position" && len(setting.Args) == 1 && (setting.Args[0] == "top" || setting.Args[0] == "bottom"):
+			s.Bar.Position = setting.Args[0]
+		default:
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s:%d: bar setting not imported: %s",
+				setting.File, setting.Line, setting.Raw))
+		}
+	}
+}
+
+// DropBindings removes imported bindings for which reserved reports true, such as
+// keys the generated config binds itself, and returns the dropped lines
+// Mode blocks left without statements are removed as well
+func (s *ImportedSettings) DropBindings(reserved func(mode, key string) bool) []string {
+	var dropped []string
+
+	hotkeys := s.Hotkeys[:0]
+	for _, hotkey := range s.Hotkeys {
+		if reserved("", i3conf.NormalizeKey(hotkey.Key)) {
+			dropped = append(dropped, fmt.Sprintf("bindsym %s exec %s", hotkey.Key, hotkey.Command))
+			continue
+		}
+		hotkeys = append(hotkeys, hotkey)
+	}
+	s.Hotkeys = hotkeys
+
+	passthrough := s.Passthrough[:0]
+	for _, statement := range s.Passthrough {
+		if mode, err := i3conf.ParseModeName(statement); err == nil && len(statement.Block) > 0 {
+			block := make([]i3conf.Statement, 0, len(statement.Block))
+			for _, child := range statement.Block {
+				if binding, err := i3conf.ParseBinding(child); err == nil && reserved(mode, binding.NormalizedKey()) {
+					dropped = append(dropped, child.Raw)
+					continue
+				}
+				block = append(block, child)
+			}
+			if len(block) == 0 {
+				continue
+			}
+			statement.Block = block
+		} else if binding, err := i3conf.ParseBinding(statement); err == nil && reserved("", binding.NormalizedKey()) {
+			dropped = append(dropped, statement.Raw)
+			continue
+		}
+		passthrough = append(passthrough, statement)
+	}
+	s.Passthrough = passthrough
+
+	return dropped
+}
+
+// DropSettings removes passed-through statements whose option emitted reports true,
+// such as options the generated config sets itself, and returns the dropped lines
+func (s *ImportedSettings) DropSettings(emitted func(key string) bool) []string {
+	var dropped []string
+
+	passthrough := s.Passthrough[:0]
+	for _, statement := range s.Passthrough {
+		if key, ok := statement.SettingKey(); ok && emitted(key) {
+			dropped = append(dropped, statement.Raw)
+			continue
+		}
+		passthrough = append(passthrough, statement)
+	}
+	s.Passthrough = passthrough
+
+	return dropped
+}
+
+// PassthroughSnippets returns the passed-through statements as config snippets,
+// one per statement with blocks kept together
+func (s *ImportedSettings) PassthroughSnippets() []string {
+	snippets := make([]string, 0, len(s.Passthrough))
+	for _, statement := range s.Passthrough {
+		snippets = append(snippets, strings.Join(statement.Lines(), "\n"))
+	}
+	return snippets
+}
+
+// parseSetxkbmap reads the layout, variant and options from setxkbmap arguments
+func parseSetxkbmap(args []string) KeyboardConfig {
+	var keyboard KeyboardConfig
+	var options []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-layout" && i+1 < len(args):
+			i++
+			keyboard.Layout = args[i]
+		case args[i] == "-variant" && i+1 < len(args):
+			i++
+			keyboard.Variant = args[i]
+		case args[i] == "-option" && i+1 < len(args):
+			i++
+			if args[i] != "" {
+				options = append(options, args[i])
+			}
+		case !strings.HasPrefix(args[i], "-") && keyboard.Layout == "":
+			keyboard.Layout = args[i]
+		}
+	}
+	keyboard.Options = strings.Join(options, ",")
+	return keyboard
+}
+
+// isLocker reports whether a command starts a screen locker such as i3lock or light-locker
+func isLocker(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && strings.Contains(filepath.Base(fields[0]), "lock")
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+)
+
+const testI3Config = `# i3 config file (v4)
+set $mod Mod1
+set $term alacritty
+set $ws_web "1: web"
+font pango:DejaVu Sans Mono 10
+
+exec_always --no-startup-id setxkbmap -layout de -variant nodeadkeys -option caps:escape
+exec --no-startup-id xrandr --output eDP-1 --primary
+exec --no-startup-id nm-applet
+exec dunst
+exec_always --no-startup-id ~/.config/polybar/launch.sh
+
+bindsym $mod+Return exec $term
+bindsym $mod+d exec rofi -show run
+bindsym Control+Mod1+l exec i3lock -c 000000
+bindsym XF86AudioRaiseVolume exec --no-startup-id pactl set-sink-volume @DEFAULT_SINK@ +5%
+bindsym shift+XF86MonBrightnessUp exec brightnessctl set +20%
+bindsym $mod+Shift+s exec --no-startup-id "flameshot gui"
+bindsym $mod+h focus left
+bindsym $mod+b exec firefox, workspace $ws_web
+bindsym --release $mod+x exec xdotool key ctrl+c
+bindcode 107 exec scrot
+
+mode "launch" {
+	bindsym f exec firefox, mode "default"
+	bindsym Escape mode "default"
+}
+
+bar {
+	position top
+	status_command i3blocks
+	tray_output primary
+}
+
+for_window [class="mpv"] floating enable, border pixel 2, layout tabbed
+for_window [tiling_from="user"] border pixel 1
+assign [class="Firefox"] → workspace $ws_web
+assign [class="Firefox"] 3
+workspace $ws_web output HDMI-1
+workspace 2 output eDP-1 HDMI-1
+workspace 3 output HDMI-1
+workspace 4 gaps inner 0
+default_border pixel 1
+floating_modifier $mod
+gaps inner 12
+gaps outer 4px
+gaps top 30
+`
+
+func writeTestI3Config(t *testing.T, content string) string {
+	t.Helper()
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	path := filepath.Join(tempDir, "config")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write i3 config: %v", err)
+	}
+	return path
+}
+
+func TestImportI3Settings(t *testing.T) {
+	path := writeTestI3Config(t, testI3Config)
+	imported, err := ImportI3Settings(path)
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+
+	if imported.ModKey != "Mod1" {
+		t.Errorf("Expected mod key Mod1, got %s", imported.ModKey)
+	}
+	if imported.Font != "pango:DejaVu Sans Mono 10" {
+		t.Errorf("Expected font to be imported, got %s", imported.Font)
+	}
+	expectedKeyboard := KeyboardConfig{Layout: "de", Variant: "nodeadkeys", Options: "caps:escape"}
+	if imported.Keyboard != expectedKeyboard {
+		t.Errorf("Expected keyboard %+v, got %+v", expectedKeyboard, imported.Keyboard)
+	}
+	if imported.Terminal != "alacritty" {
+		t.Errorf("Expected terminal alacritty, got %s", imported.Terminal)
+	}
+	if imported.Launcher != (LauncherConfig{Key: "$mod+d", Command: "rofi -show run"}) {
+		t.Errorf("Expected rofi launcher, got %+v", imported.Launcher)
+	}
+	if imported.Locker != (LockerConfig{Key: "Control+Mod1+l", Command: "i3lock -c 000000"}) {
+		t.Errorf("Expected i3lock locker, got %+v", imported.Locker)
+	}
+	if imported.MediaKeys.VolumeUp != "pactl set-sink-volume @DEFAULT_SINK@ +5%" {
+		t.Errorf("Expected volume up command, got %s", imported.MediaKeys.VolumeUp)
+	}
+	if imported.Backlight.UpLarge != "brightnessctl set +20%" {
+		t.Errorf("Expected large brightness step, got %s", imported.Backlight.UpLarge)
+	}
+	if imported.Bar != (BarConfig{StatusCommand: "i3blocks", Position: "top"}) {
+		t.Errorf("Expected bar settings, got %+v", imported.Bar)
+	}
+	if imported.GapsInner == nil || *imported.GapsInner != 12 || imported.GapsOuter == nil || *imported.GapsOuter != 4 {
+		t.Errorf("Expected gaps inner 12 and outer 4, got %v and %v", imported.GapsInner, imported.GapsOuter)
+	}
+
+	expectedPrograms := []string{"nm-applet", "dunst"}
+	if !reflect.DeepEqual(imported.StartupPrograms, expectedPrograms) {
+		t.Errorf("Expected startup programs %v, got %v", expectedPrograms, imported.StartupPrograms)
+	}
+	expectedHotkeys := []HotkeyConfig{{Key: "$mod+Shift+s", Command: "flameshot gui", NoStartupID: true}}
+	if !reflect.DeepEqual(imported.Hotkeys, expectedHotkeys) {
+		t.Errorf("Expected hotkeys %+v, got %+v", expectedHotkeys, imported.Hotkeys)
+	}
+	floating := true
+	expectedRules := []WindowRule{
+		{Match: Criteria{Class: "mpv"}, Floating: &floating, Border: "pixel 2", Commands: []string{"layout tabbed"}},
+		{Raw: `[tiling_from="user"] border pixel 1`},
+	}
+	if !reflect.DeepEqual(imported.WindowOverrides, expectedRules) {
+		t.Errorf("Expected window overrides %+v, got %+v", expectedRules, imported.WindowOverrides)
+	}
+	expectedAssignments := []Assignment{{Match: Criteria{Class: "Firefox"}, AssignmentTarget: AssignmentTarget{Workspace: "1: web"}}}
+	if !reflect.DeepEqual(imported.Assignments, expectedAssignments) {
+		t.Errorf("Expected Firefox assignment with variables expanded, got %+v", imported.Assignments)
+	}
+
+	// Everything else is kept as raw config, with variables expanded except $mod
+	expectedSnippets := []string{
+		"exec_always --no-startup-id ~/.config/polybar/launch.sh",
+		"bindsym $mod+h focus left",
+		`bindsym $mod+b exec firefox, workspace "1: web"`,
+		"bindsym --release $mod+x exec xdotool key ctrl+c",
+		"bindcode 107 exec scrot",
+		"mode \"launch\" {\n\tbindsym f exec firefox, mode \"default\"\n\tbindsym Escape mode \"default\"\n}",
+		`assign [class="Firefox"] 3`,
+		"workspace 4 gaps inner 0",
+		"default_border pixel 1",
+		"floating_modifier $mod",
+		"gaps top 30",
+	}
+	if !reflect.DeepEqual(imported.PassthroughSnippets(), expectedSnippets) {
+		t.Errorf("Expected passthrough\n%q\ngot\n%q", expectedSnippets, imported.PassthroughSnippets())
+	}
+
+	// Outputs are placed on the roles of the detected monitors
+	imported.PlaceWorkspaces(&monitor.DetectedMonitors{Primary: "eDP-1", Left: "HDMI-1", All: []string{"eDP-1", "HDMI-1"}})
+	expectedDisplays := OrderedMap{
+		{Key: "1: web", Value: "left_display"},
+		{Key: "2", Value: "primary_display"},
+		{Key: "3", Value: "left_display"},
+	}
+	if !reflect.DeepEqual(imported.WorkspaceDisplays, expectedDisplays) {
+		t.Errorf("Expected workspace displays %+v, got %+v", expectedDisplays, imported.WorkspaceDisplays)
+	}
+
+	if len(imported.Warnings) != 1 || !strings.Contains(imported.Warnings[0], "tray_output primary") {
+		t.Errorf("Expected warning for unsupported bar setting, got %v", imported.Warnings)
+	}
+
+	// Test: Missing file
+	if _, err := ImportI3Settings(filepath.Join(filepath.Dir(path), "missing")); err == nil {
+		t.Error("Expected error for missing i3 config")
+	}
+}
+
+func TestImportedSettings_DropBindings(t *testing.T) {
+	parsed, err := i3conf.Parse(strings.NewReader(testI3Config), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse i3 config: %v", err)
+	}
+	imported, err := ImportI3Config(parsed)
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+
+	reserved := map[string]bool{
+		" " + i3conf.NormalizeKey("$mod+h"):       true,
+		" " + i3conf.NormalizeKey("$mod+shift+s"): true,
+		"launch " + i3conf.NormalizeKey("f"):      true,
+		"launch " + i3conf.NormalizeKey("Escape"): true,
+		"resize " + i3conf.NormalizeKey("$mod+b"): true,
+	}
+	dropped := imported.DropBindings(func(mode, key string) bool {
+		return reserved[mode+" "+key]
+	})
+
+	expectedDropped := []string{
+		"bindsym $mod+Shift+s exec flameshot gui",
+		"bindsym $mod+h focus left",
+		`bindsym f exec firefox, mode "default"`,
+		`bindsym Escape mode "default"`,
+	}
+	if !reflect.DeepEqual(dropped, expectedDropped) {
+		t.Errorf("Expected dropped %q, got %q", expectedDropped, dropped)
+	}
+	if len(imported.Hotkeys) != 0 {
+		t.Errorf("Expected hotkey to be dropped, got %+v", imported.Hotkeys)
+	}
+	for _, snippet := range imported.PassthroughSnippets() {
+		if strings.HasPrefix(snippet, "mode") || strings.Contains(snippet, "focus left") {
+			t.Errorf("Expected %q to be dropped", snippet)
+		}
+	}
+	// A binding reserved in another mode is kept
+	if !strings.Contains(strings.Join(imported.PassthroughSnippets(), "\n"), "bindsym $mod+b") {
+		t.Error("Expected $mod+b binding to be kept")
+	}
+}
+
+func TestImportedSettings_DropSettings(t *testing.T) {
+	parsed, err := i3conf.Parse(strings.NewReader(testI3Config), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse i3 config: %v", err)
+	}
+	imported, err := ImportI3Config(parsed)
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+
+	emitted := map[string]bool{"floating_modifier": true, "gaps top": true, "workspace": true}
+	dropped := imported.DropSettings(func(key string) bool {
+		return emitted[key]
+	})
+
+	expectedDropped := []string{"floating_modifier $mod", "gaps top 30"}
+	if !reflect.DeepEqual(dropped, expectedDropped) {
+		t.Errorf("Expected dropped %q, got %q", expectedDropped, dropped)
+	}
+	// Workspace settings and options the generated config leaves alone are kept
+	snippets := strings.Join(imported.PassthroughSnippets(), "\n")
+	for _, kept := range []string{"workspace 4 gaps inner 0", "default_border pixel 1", "bindsym $mod+h focus left"} {
+		if !strings.Contains(snippets, kept) {
+			t.Errorf("Expected %q to be kept", kept)
+		}
+	}
+}
+
+func TestImportI3Settings_WithoutBar(t *testing.T) {
+	imported, err := ImportI3Settings(writeTestI3Config(t, "exec_always --no-startup-id polybar main\n"))
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+	if !imported.Bar.Disabled {
+		t.Errorf("Expected bar to be disabled, got %+v", imported.Bar)
+	}
+
+	content, err := BuildStarterConfig(StarterOptions{Imported: imported})
+	if err != nil {
+		t.Fatalf("Failed to build starter config: %v", err)
+	}
+	if config := loadStarter(t, content); !config.Bar.Disabled {
+		t.Errorf("Expected bar.disabled in the starter config, got %+v", config.Bar)
+	}
+}
+
+func TestImportedSettings_PlaceWorkspaces(t *testing.T) {
+	path := writeTestI3Config(t, `workspace 1 output DP-1
+workspace 4 output eDP-1
+workspace 5 output HDMI-1
+workspace 6 output HDMI-1 DP-2
+`)
+	imported, err := ImportI3Settings(path)
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+	if len(imported.Passthrough) != 0 {
+		t.Errorf("Expected no passthrough, got %q", imported.PassthroughSnippets())
+	}
+
+	// Outputs keep the roles their monitors were detected with, and a placement
+	// on an output without a role would conflict with the generated ones
+	imported.PlaceWorkspaces(&monitor.DetectedMonitors{
+		Primary: "eDP-1",
+		Left:    "DP-1",
+		Right:   "DP-2",
+		All:     []string{"eDP-1", "DP-1", "DP-2"},
+	})
+	expectedDisplays := OrderedMap{
+		{Key: "1", Value: "left_display"},
+		{Key: "4", Value: "primary_display"},
+		{Key: "6", Value: "right_display"},
+	}
+	if !reflect.DeepEqual(imported.WorkspaceDisplays, expectedDisplays) {
+		t.Errorf("Expected workspace displays %+v, got %+v", expectedDisplays, imported.WorkspaceDisplays)
+	}
+	if len(imported.Warnings) != 1 || !strings.Contains(imported.Warnings[0], "no detected monitor with a role is named HDMI-1") ||
+		!strings.Contains(imported.Warnings[0], "workspace 5 output HDMI-1") {
+		t.Errorf("Expected warning for the dropped placement, got %v", imported.Warnings)
+	}
+
+	// Without detected monitors no placement can be imported
+	imported.Warnings = nil
+	imported.PlaceWorkspaces(nil)
+	if len(imported.WorkspaceDisplays) != 0 || len(imported.Warnings) != 4 {
+		t.Errorf("Expected every placement to be dropped with a warning, got %+v and %v", imported.WorkspaceDisplays, imported.Warnings)
+	}
+}
+
+func TestBuildStarterConfig_Imported(t *testing.T) {
+	imported, err := ImportI3Settings(writeTestI3Config(t, testI3Config))
+	if err != nil {
+		t.Fatalf("Failed to import settings: %v", err)
+	}
+	monitors := &monitor.DetectedMonitors{Primary: "eDP-1", Left: "HDMI-1", All: []string{"eDP-1", "HDMI-1"}}
+	imported.PlaceWorkspaces(monitors)
+
+	content, err := BuildStarterConfig(StarterOptions{Monitors: monitors, Imported: imported})
+	if err != nil {
+		t.Fatalf("Failed to build starter config: %v", err)
+	}
+	config := loadStarter(t, content)
+
+	if config.I3.ModKey != "Mod1" || config.I3.Font != "pango:DejaVu Sans Mono 10" {
+		t.Errorf("Expected imported i3 settings, got %+v", config.I3)
+	}
+	if config.Terminal != "alacritty" {
+		t.Errorf("Expected terminal alacritty, got %s", config.Terminal)
+	}
+	if config.Keyboard.Layout != "de" || config.Keyboard.Options != "caps:escape" {
+		t.Errorf("Expected imported keyboard, got %+v", config.Keyboard)
+	}
+	if config.Launcher.Command != "rofi -show run" {
+		t.Errorf("Expected imported launcher, got %+v", config.Launcher)
+	}
+	if config.Bar.StatusCommand != "i3blocks" || config.Bar.Position != "top" || config.Bar.Disabled {
+		t.Errorf("Expected imported bar, got %+v", config.Bar)
+	}
+	// Media keys that were not imported keep their defaults
+	if config.MediaKeys.VolumeUp != imported.MediaKeys.VolumeUp || config.MediaKeys.VolumeDown != DefaultMediaKeys.VolumeDown {
+		t.Errorf("Expected imported and default media keys, got %+v", config.MediaKeys)
+	}
+	if !reflect.DeepEqual(config.StartupPrograms, imported.StartupPrograms) {
+		t.Errorf("Expected startup programs %v, got %v", imported.StartupPrograms, config.StartupPrograms)
+	}
+	if !reflect.DeepEqual(config.WindowOverrides, imported.WindowOverrides) {
+		t.Errorf("Expected window overrides %v, got %v", imported.WindowOverrides, config.WindowOverrides)
+	}
+	if !reflect.DeepEqual(config.Assignments, imported.Assignments) {
+		t.Errorf("Expected assignments %+v, got %+v", imported.Assignments, config.Assignments)
+	}
+	if !reflect.DeepEqual(config.Hotkeys, imported.Hotkeys) {
+		t.Errorf("Expected hotkeys %+v, got %+v", imported.Hotkeys, config.Hotkeys)
+	}
+	// Gaps and workspace placements replace those of the default layout
+	layout := config.Layouts[config.DefaultLayout]
+	if layout.GapsInner != 12 || layout.GapsOuter != 4 {
+		t.Errorf("Expected gaps inner 12 and outer 4, got %d and %d", layout.GapsInner, layout.GapsOuter)
+	}
+	if !reflect.DeepEqual(layout.WorkspaceToDisplay, imported.WorkspaceDisplays) {
+		t.Errorf("Expected workspace_to_display %+v, got %+v", imported.WorkspaceDisplays, layout.WorkspaceToDisplay)
+	}
+	var expectedSnippets []Snippet
+	for _, snippet := range imported.PassthroughSnippets() {
+		expectedSnippets = append(expectedSnippets, Snippet{Raw: snippet})
+	}
+	if !reflect.DeepEqual(config.Extra.EndOfFile, expectedSnippets) {
+		t.Errorf("Expected passthrough in extra.end_of_file, got %q", config.Extra.EndOfFile)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configLayer is one configuration file of a layered configuration, such as
+// team defaults in /etc/xdg overridden by the user's own file
+type configLayer struct {
+	name     string
+	document *yaml.Node
+	secrets  []string
+	warnings []string
+}
+
+// readLayer parses a configuration file in the format given by the extension of
+// name, upgrades it to the current format and resolves its secrets
+func readLayer(data []byte, name string) (*configLayer, error) {
+	format := FormatOf(name)
+	document, err := parseDocument(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s config file %s: %w", formatName(format), name, err)
+	}
+
+	// Older formats are upgraded in memory; "migrate" rewrites the file itself
+	applied, _, err := Migrate(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config file %s: %w", name, err)
+	}
+
+	// Resolve secret references before decoding so they work in any string value
+	secrets, warnings, err := resolveSecrets(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets in config file %s: %w", name, err)
+	}
+
+	layer := &configLayer{name: name, document: document, secrets: secrets}
+	for _, warning := range warnings {
+		layer.warnings = append(layer.warnings, fmt.Sprintf("%s: %s", name, warning))
+	}
+	if len(applied) > 0 {
+		layer.warnings = append(layer.warnings, fmt.Sprintf(
+			"%s uses an older configuration format; run \"i3-config-generator migrate\" to upgrade it to version %d",
+			name, CurrentVersion))
+	}
+	return layer, nil
+}
+
+// decodeLayers checks every layer against the schema, merges them in order and
+// decodes and validates the result
+func decodeLayers(layers []*configLayer, lenient bool) (*Config, error) {
+	// Layouts defined in one file may be selected in another
+	var layoutNames, names []string
+	for _, layer := range layers {
+		for _, name := range documentLayoutNames(documentRoot(layer.document)) {
+			if !slices.Contains(layoutNames, name) {
+				layoutNames = append(layoutNames, name)
+			}
+		}
+		names = append(names, layer.name)
+	}
+
+	// Check each document against the schema first, for errors that name the offending key
+	var config Config
+	var problems []Problem
+	for _, layer := range layers {
+		warnings, err := validateDocument(layer.document, layer.name, layoutNames, lenient)
+		if validationErr, ok := err.(*ValidationError); ok {
+			problems = append(problems, validationErr.Problems...)
+		} else if err != nil {
+			return nil, fmt.Errorf("configuration validation failed: %w", err)
+		}
+		for _, warning := range warnings {
+			config.warnings = append(config.warnings, warning.String())
+		}
+		config.warnings = append(config.warnings, layer.warnings...)
+		config.secrets = append(config.secrets, layer.secrets...)
+	}
+	if len(problems) > 0 {
+		sortProblems(problems)
+		return nil, fmt.Errorf("configuration validation failed: %w", &ValidationError{Problems: problems})
+	}
+
+	// Remember where each value came from before the documents are merged
+	origin := make(map[*yaml.Node]string)
+	document := layers[0].document
+	recordOrigin(origin, document, layers[0].name)
+	for _, layer := range layers[1:] {
+		recordOrigin(origin, layer.document, layer.name)
+		document = mergeDocuments(document, layer.document)
+	}
+
+	if err := document.Decode(&config); err != nil {
+		format := FormatOf(layers[len(layers)-1].name)
+		return nil, fmt.Errorf("failed to parse %s config file %s: %w", formatName(format), strings.Join(names, ", "), err)
+	}
+
+	// Fill in optional settings that were left out
+	config.ApplyDefaults()
+
+	// Validate the configuration, pointing each problem at the value it is about
+	if err := config.Validate(); err != nil {
+		if validationErr, ok := err.(*ValidationError); ok {
+			locateProblems(validationErr.Problems, document, func(node *yaml.Node) string {
+				if name, ok := origin[node]; ok {
+					return name
+				}
+				return layers[len(layers)-1].name
+			})
+		}
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	return &config, nil
+}
+
+// recordOrigin notes name as the file of every node below node
+func recordOrigin(origin map[*yaml.Node]string, node *yaml.Node, name string) {
+	origin[node] = name
+	for _, child := range node.Content {
+		recordOrigin(origin, child, name)
+	}
+}
+
+// mergeDocuments overlays one document on another: mappings are merged key by
+// key, and any other value replaces the one below it
+func mergeDocuments(base, over *yaml.Node) *yaml.Node {
+	if documentRoot(over).Kind == 0 {
+		return base
+	}
+	if documentRoot(base).Kind == 0 {
+		return over
+	}
+	root := mergeNodes(documentRoot(base), documentRoot(over))
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+}
+
+// mergeNodes returns over merged onto base, updating base in place when both are mappings
+func mergeNodes(base, over *yaml.Node) *yaml.Node {
+	if base.Kind == yaml.AliasNode {
+		// Copy anchored mappings so other references to them are left alone
+		copied := *base.Alias
+		copied.Content = append([]*yaml.Node(nil), base.Alias.Content...)
+		base = &copied
+	}
+	if resolveAlias(over).Kind != yaml.MappingNode || base.Kind != yaml.MappingNode {
+		return over
+	}
+
+	over = resolveAlias(over)
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], over.Content[i+1]
+		merged := false
+		for j := 0; j+1 < len(base.Content); j += 2 {
+			if base.Content[j].Value == key.Value {
+				base.Content[j+1] = mergeNodes(base.Content[j+1], value)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			base.Content = append(base.Content, key, value)
+		}
+	}
+	return base
+}
+
+// resolveSnippetNodes makes the snippet file paths in a document absolute,
+// relative paths being taken from dir, so they keep pointing next to the file
+// that defined them once layers are merged
+func resolveSnippetNodes(document *yaml.Node, dir string) error {
+	root := documentRoot(document)
+	extras := []*yaml.Node{mappingValue(root, "extra")}
+	if layouts := mappingValue(root, "layouts"); layouts != nil && layouts.Kind == yaml.MappingNode {
+		for i := 1; i < len(layouts.Content); i += 2 {
+			extras = append(extras, mappingValue(mappingValue(layouts.Content[i], "overrides"), "extra"))
+		}
+	}
+
+	for _, extra := range extras {
+		lists := []*yaml.Node{
+			mappingValue(extra, "before_keybindings"),
+			mappingValue(extra, "after_workspaces"),
+			mappingValue(extra, "end_of_file"),
+		}
+		if modes := mappingValue(extra, "modes"); modes != nil && modes.Kind == yaml.MappingNode {
+			for i := 1; i < len(modes.Content); i += 2 {
+				lists = append(lists, modes.Content[i])
+			}
+		}
+
+		for _, list := range lists {
+			if list == nil || list.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, snippet := range list.Content {
+				file := mappingValue(snippet, "file")
+				if file == nil || file.Kind != yaml.ScalarNode || file.Value == "" {
+					continue
+				}
+				path, err := expandHome(file.Value)
+				if err != nil {
+					return fmt.Errorf("failed to expand snippet file %s: %w", file.Value, err)
+				}
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				file.Value = path
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLayoutName is used when neither the command line nor the config selects a layout
+const DefaultLayoutName = "two_mon"
+
+// LayoutSummary describes a layout for listings and help output
+type LayoutSummary struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// LayoutNames returns the names of all defined layouts in sorted order
+func (c *Config) LayoutNames() []string {
+	names := make([]string, 0, len(c.Layouts))
+	for name := range c.Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultLayoutName returns the layout used when none is requested explicitly
+func (c *Config) DefaultLayoutName() string {
+	if c.DefaultLayout != "" {
+		return c.DefaultLayout
+	}
+	return DefaultLayoutName
+}
+
+// ResolveLayoutName returns the layout to render for the requested name,
+// falling back to the default layout if name is empty
+func (c *Config) ResolveLayoutName(name string) (string, error) {
+	if name == "" {
+		name = c.DefaultLayoutName()
+	}
+	if _, err := c.GetLayout(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// LayoutSummaries returns a summary of every defined layout in sorted order
+func (c *Config) LayoutSummaries() []LayoutSummary {
+	defaultName := c.DefaultLayoutName()
+	summaries := make([]LayoutSummary, 0, len(c.Layouts))
+	for _, name := range c.LayoutNames() {
+		summaries = append(summaries, LayoutSummary{
+			Name:        name,
+			Description: c.Layouts[name].Description,
+			Default:     name == defaultName,
+		})
+	}
+	return summaries
+}
+
+// validateDefaultLayout checks that the layout used when none is requested exists;
+// without any layouts there is nothing to check
+func (c *Config) validateDefaultLayout() error {
+	if c.DefaultLayout == "" && len(c.Layouts) == 0 {
+		return nil
+	}
+	_, err := c.GetLayout(c.DefaultLayoutName())
+	if err != nil && c.DefaultLayout == "" {
+		return fmt.Errorf("not set, so %s is used: %w", DefaultLayoutName, err)
+	}
+	return err
+}
+
+// validate checks the monitor roles, keys and gaps of a layout
+func (l LayoutConfig) validate() error {
+	var problems problemList
+	for _, entry := range l.MoveWorkspace {
+		path := "move_workspace." + entry.Key
+		problems.add(path, validateKey(entry.Key))
+		problems.add(path, validateRole(entry.Value))
+	}
+	for _, entry := range l.WorkspaceToDisplay {
+		problems.add("workspace_to_display."+entry.Key, validateRole(entry.Value))
+	}
+	problems.add("", l.validateGaps())
+	return problems.err()
+}
+
+// validateRole checks that a monitor reference is one of the known roles
+func validateRole(role string) error {
+	if !slices.Contains(monitor.Roles, role) {
+		return fmt.Errorf("unknown role '%s' (valid options: %s)", role, strings.Join(monitor.Roles, ", "))
+	}
+	return nil
+}
+
+// unknownLayoutError builds the error for a layout that is not defined,
+// suggesting the closest match
+func (c *Config) unknownLayoutError(name string) error {
+	names := c.LayoutNames()
+	if len(names) == 0 {
+		return fmt.Errorf("layout '%s' not found (no layouts are defined)", name)
+	}
+	if suggestion := closestMatch(name, names); suggestion != "" {
+		return fmt.Errorf("layout '%s' not found, did you mean '%s'? (available: %s)",
+			name, suggestion, strings.Join(names, ", "))
+	}
+	return fmt.Errorf("layout '%s' not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// PeekLayouts reads only the layout names and descriptions from a config file,
+// without resolving secrets or validating it, so help output stays cheap
+// If filePath is empty the files on the search path are read and layered
+func (l *Loader) PeekLayouts(filePath string) ([]LayoutSummary, error) {
+	paths := []string{filePath}
+	if filePath == "" {
+		var err error
+		if paths, err = l.searchFiles(); err != nil {
+			return nil, err
+		}
+		filePath = strings.Join(paths, ", ")
+	}
+
+	document := &yaml.Node{}
+	for _, path := range paths {
+		_, layer, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		document = mergeDocuments(document, layer)
+	}
+
+	var partial struct {
+		DefaultLayout string `yaml:"default_layout"`
+		Layouts       map[string]struct {
+			Description string `yaml:"description"`
+		} `yaml:"layouts"`
+	}
+	if err := document.Decode(&partial); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filePath, err)
+	}
+
+	cfg := Config{DefaultLayout: partial.DefaultLayout, Layouts: make(map[string]LayoutConfig)}
+	for name, layout := range partial.Layouts {
+		cfg.Layouts[name] = LayoutConfig{Description: layout.Description}
+	}
+	return cfg.LayoutSummaries(), nil
+}
+
+// closestMatch returns the candidate closest to name by edit distance,
+// or an empty string if none is close enough to be a likely typo
+func closestMatch(name string, candidates []string) string {
+	best := ""
+	bestDistance := max(2, len(name)/3) + 1
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and adjacent transpositions
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	d := make([][]int, len(ar)+1)
+	for i := range d {
+		d[i] = make([]int, len(br)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ar)][len(br)]
+}
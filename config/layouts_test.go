@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_ResolveLayoutName(t *testing.T) {
+	config := Config{
+		Layouts: map[string]LayoutConfig{
+			"two_mon":   {},
+			"ultrawide": {},
+		},
+	}
+
+	// Test: Built-in default is used when the config has none
+	name, err := config.ResolveLayoutName("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != DefaultLayoutName {
+		t.Errorf("Expected %s, got %s", DefaultLayoutName, name)
+	}
+
+	// Test: Config default layout takes precedence
+	config.DefaultLayout = "ultrawide"
+	name, err = config.ResolveLayoutName("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "ultrawide" {
+		t.Errorf("Expected ultrawide, got %s", name)
+	}
+
+	// Test: Any layout defined in the config is accepted
+	name, err = config.ResolveLayoutName("two_mon")
+	if err != nil || name != "two_mon" {
+		t.Errorf("Expected two_mon, got %s (error: %v)", name, err)
+	}
+
+	// Test: Typos get a suggestion
+	_, err = config.ResolveLayoutName("ultrawid")
+	if err == nil {
+		t.Fatal("Expected error for unknown layout")
+	}
+	if !strings.Contains(err.Error(), "did you mean 'ultrawide'?") {
+		t.Errorf("Expected suggestion in error, got: %v", err)
+	}
+
+	// Test: Unrelated names list what is available
+	_, err = config.ResolveLayoutName("something")
+	if err == nil {
+		t.Fatal("Expected error for unknown layout")
+	}
+	if strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected no suggestion for unrelated name, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "two_mon, ultrawide") {
+		t.Errorf("Expected available layouts in error, got: %v", err)
+	}
+}
+
+func TestConfig_LayoutSummaries(t *testing.T) {
+	config := Config{
+		DefaultLayout: "docked",
+		Layouts: map[string]LayoutConfig{
+			"laptop": {Description: "Laptop screen only"},
+			"docked": {Description: "Desk with two screens"},
+		},
+	}
+
+	summaries := config.LayoutSummaries()
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d", len(summaries))
+	}
+
+	if summaries[0].Name != "docked" || !summaries[0].Default {
+		t.Errorf("Expected docked to be first and default, got %+v", summaries[0])
+	}
+	if summaries[1].Name != "laptop" || summaries[1].Default {
+		t.Errorf("Expected laptop to be second and not default, got %+v", summaries[1])
+	}
+	if summaries[1].Description != "Laptop screen only" {
+		t.Errorf("Expected description to be kept, got %q", summaries[1].Description)
+	}
+}
+
+func TestLoader_PeekLayouts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Secrets are not resolved and the config is not validated
+	configContent := `
+default_layout: laptop
+hotkeys:
+  - key: "$mod+x"
+    command: "${env:I3CG_TEST_UNSET_VARIABLE}"
+layouts:
+  laptop:
+    description: "Laptop screen only"
+  docked: {}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, ConfigFileYAML), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	summaries, err := NewLoader(tempDir).PeekLayouts("")
+	if err != nil {
+		t.Fatalf("Failed to peek layouts: %v", err)
+	}
+
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 layouts, got %d", len(summaries))
+	}
+	if summaries[1].Name != "laptop" || !summaries[1].Default || summaries[1].Description != "Laptop screen only" {
+		t.Errorf("Unexpected summary for laptop: %+v", summaries[1])
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"no_mon", "one_mon", "two_mon"}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"tow_mon", "two_mon"},
+		{"TWO_MON", "two_mon"},
+		{"one-mon", "one_mon"},
+		{"nomon", "no_mon"},
+		{"ultrawide", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := closestMatch(tt.name, candidates); result != tt.expected {
+				t.Errorf("closestMatch(%q) = %q, want %q", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
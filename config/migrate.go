@@ -0,0 +1,377 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration format version this release reads and writes
+// Documents without a version key are version 1
+const CurrentVersion = 2
+
+// Migration upgrades a configuration document from one format version to the next
+type Migration struct {
+	// From is the version upgraded from; the result is version From+1
+	From        int
+	Description string
+	// Apply rewrites the top-level mapping of the document in place, reporting
+	// whether anything needed to change and notes on settings it left alone
+	Apply func(root *yaml.Node) (bool, []string, error)
+}
+
+// migrations lists every upgrade step, ordered by From
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "move use_detected_monitors to monitor_detection.enabled",
+		Apply:       migrateMonitorDetection,
+	},
+}
+
+// MigrationResult is the outcome of upgrading a configuration file
+type MigrationResult struct {
+	Path     string
+	Original []byte
+	// Migrated is the upgraded file, equal to Original when nothing was applied
+	Migrated []byte
+	// Version is the version of the original file
+	Version int
+	// Applied lists the migrations that changed the file
+	Applied []Migration
+	// Notes point out settings the migrations kept but that need attention
+	Notes []string
+}
+
+// DocumentVersion returns the format version of a document
+func DocumentVersion(document *yaml.Node) (int, error) {
+	node := mappingValue(documentRoot(document), "version")
+	if node == nil {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || node.Tag != "!!int" || version < 1 {
+		return 0, fmt.Errorf("%d:%d: version: expected a positive integer, got %q", node.Line, node.Column, node.Value)
+	}
+	return version, nil
+}
+
+// Migrate upgrades a parsed document in place to CurrentVersion, one version at a
+// time, and returns the migrations that changed it along with their notes
+func Migrate(document *yaml.Node) ([]Migration, []string, error) {
+	root := documentRoot(document)
+	if root.Kind != yaml.MappingNode {
+		// Empty documents need no upgrade, and other values are left to the schema check
+		return nil, nil, nil
+	}
+
+	version, err := DocumentVersion(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("configuration version %d is newer than the supported version %d; upgrade i3-config-generator", version, CurrentVersion)
+	}
+
+	var applied []Migration
+	var notes []string
+	for _, migration := range migrations {
+		if migration.From < version {
+			continue
+		}
+		changed, migrationNotes, err := migration.Apply(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate from version %d: %w", migration.From, err)
+		}
+		if changed {
+			applied = append(applied, migration)
+		}
+		notes = append(notes, migrationNotes...)
+	}
+
+	if version < CurrentVersion {
+		versionNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+		if mappingValue(root, "version") != nil {
+			setMappingValue(root, "version", versionNode)
+		} else {
+			prependMappingEntry(root, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}, versionNode)
+		}
+	}
+	return applied, notes, nil
+}
+
+// MigrateFile upgrades the configuration file at path, or the one in the
+// configuration directory when path is empty, without writing it back
+// YAML files keep their comments and formatting outside the migrated entries
+func (l *Loader) MigrateFile(path string) (*MigrationResult, error) {
+	if path == "" {
+		found, err := l.findConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		path = found
+	}
+
+	data, document, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	format := FormatOf(path)
+	original, err := parseDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := DocumentVersion(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	applied, notes, err := Migrate(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	result := &MigrationResult{Path: path, Original: data, Migrated: data, Version: version, Applied: applied, Notes: notes}
+	if version >= CurrentVersion || documentRoot(document).Kind != yaml.MappingNode {
+		return result, nil
+	}
+
+	// YAML files only have the entries the migrations touched rewritten
+	if format == FormatYAML {
+		if spliced, ok := spliceDocument(data, original, document); ok {
+			result.Migrated = spliced
+			return result, nil
+		}
+	}
+	// Other files, and YAML the changes cannot be spliced into, are encoded again
+	// in their format
+	if result.Migrated, err = EncodeDocument(document, format); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return result, nil
+}
+
+// lineEdit replaces the lines from start up to end, numbered from 1, with text;
+// an edit with start equal to end inserts text before line start
+type lineEdit struct {
+	start, end int
+	text       string
+}
+
+// spliceDocument writes the differences between the original and the migrated
+// parse of a YAML file into its text, so entries no migration changed keep their
+// blank lines, comments and alignment
+// It reports false when the changes cannot be spliced, e.g. for flow mappings
+func spliceDocument(data []byte, original, migrated *yaml.Node) ([]byte, bool) {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+
+	var edits []lineEdit
+	if !spliceMapping(lines, documentRoot(original), documentRoot(migrated), len(lines)+1, &edits) {
+		return nil, false
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf strings.Builder
+	next := 1
+	for _, edit := range edits {
+		if edit.start < next {
+			return nil, false
+		}
+		buf.WriteString(strings.Join(lines[next-1:edit.start-1], ""))
+		buf.WriteString(edit.text)
+		next = edit.end
+	}
+	buf.WriteString(strings.Join(lines[next-1:], ""))
+
+	// The result must read back to the migrated document
+	var spliced, expected interface{}
+	if err := yaml.Unmarshal([]byte(buf.String()), &spliced); err != nil {
+		return nil, false
+	}
+	if err := migrated.Decode(&expected); err != nil || !reflect.DeepEqual(spliced, expected) {
+		return nil, false
+	}
+	return []byte(buf.String()), true
+}
+
+// spliceMapping adds the edits turning the lines of a block mapping, which end
+// before line end, into the migrated mapping
+// Unchanged entries are left alone, changed block mappings are spliced entry by
+// entry, and other changed entries are encoded again
+func spliceMapping(lines []string, original, migrated *yaml.Node, end int, edits *[]lineEdit) bool {
+	if original.Kind != yaml.MappingNode || migrated.Kind != yaml.MappingNode ||
+		original.Style&yaml.FlowStyle != 0 || len(original.Content) == 0 {
+		return false
+	}
+
+	// Each entry spans from its key to the next one, less the blank lines and
+	// comments that lead into the next entry
+	count := len(original.Content) / 2
+	starts := make([]int, count)
+	stops := make([]int, count)
+	index := map[string]int{}
+	for i := 0; i < count; i++ {
+		key := original.Content[2*i]
+		if key.Kind != yaml.ScalarNode || (i > 0 && key.Line <= original.Content[2*i-2].Line) {
+			return false
+		}
+		index[key.Value] = i
+		stop := end
+		if i+1 < count {
+			stop = original.Content[2*i+2].Line
+		}
+		for stop-1 > key.Line && isLeadingLine(lines[stop-2], key.Column, true) {
+			stop--
+		}
+		start := key.Line
+		lower := 1
+		if i > 0 {
+			lower = stops[i-1]
+		}
+		for start-1 >= lower && isLeadingLine(lines[start-2], key.Column, false) {
+			start--
+		}
+		starts[i], stops[i] = start, stop
+	}
+
+	// Entries separated by blank lines get a blank line around inserted ones too
+	separated := count > 1
+	for i := 1; i < count; i++ {
+		if !slices.ContainsFunc(lines[stops[i-1]-1:starts[i]-1], isBlankLine) {
+			separated = false
+		}
+	}
+
+	indent := original.Content[0].Column - 1
+	insertAt := starts[0]
+	afterEntry := false
+	kept := make([]bool, count)
+	last := -1
+	for j := 0; j+1 < len(migrated.Content); j += 2 {
+		key, value := migrated.Content[j], migrated.Content[j+1]
+		i, ok := index[key.Value]
+		if !ok {
+			text := encodeEntry(key, value, indent, true)
+			switch {
+			case separated && afterEntry:
+				text = "\n" + text
+			case separated:
+				text += "\n"
+			}
+			*edits = append(*edits, lineEdit{start: insertAt, end: insertAt, text: text})
+			continue
+		}
+		if i < last {
+			return false
+		}
+		last = i
+		kept[i] = true
+		insertAt = stops[i]
+		afterEntry = true
+
+		originalKey, originalValue := original.Content[2*i], original.Content[2*i+1]
+		if encodeEntry(originalKey, originalValue, 0, true) == encodeEntry(key, value, 0, true) {
+			continue
+		}
+		if originalValue.Kind == yaml.MappingNode && originalValue.Style&yaml.FlowStyle == 0 &&
+			len(originalValue.Content) > 0 && originalValue.Content[0].Line > originalKey.Line &&
+			spliceMapping(lines, originalValue, value, stops[i], edits) {
+			continue
+		}
+		// The comments above the key are still in place
+		*edits = append(*edits, lineEdit{start: originalKey.Line, end: stops[i], text: encodeEntry(key, value, indent, false)})
+	}
+
+	for i := 0; i < count; i++ {
+		if kept[i] {
+			continue
+		}
+		// Removed entries take their comments and one of the blank lines around them along
+		start, stop := starts[i], stops[i]
+		switch {
+		case stop < end && isBlankLine(lines[stop-1]):
+			stop++
+		case stop == end && start > 1 && isBlankLine(lines[start-2]):
+			start--
+		}
+		*edits = append(*edits, lineEdit{start: start, end: stop})
+	}
+	return true
+}
+
+// encodeEntry encodes a single mapping entry indented by indent spaces, with or
+// without the comments above its key
+func encodeEntry(key, value *yaml.Node, indent int, headComment bool) string {
+	entryKey := *key
+	if !headComment {
+		entryKey.HeadComment = ""
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{&entryKey, value}}); err != nil {
+		return ""
+	}
+	encoder.Close()
+
+	text := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range text {
+		if strings.TrimSpace(line) != "" {
+			text[i] = strings.Repeat(" ", indent) + line
+		}
+	}
+	return strings.Join(text, "")
+}
+
+// isLeadingLine reports whether a line is a comment indented less than column,
+// or with blank set a blank line, which lead into the entry that follows
+func isLeadingLine(line string, column int, blank bool) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	if isBlankLine(line) {
+		return blank
+	}
+	return strings.HasPrefix(trimmed, "#") && len(line)-len(trimmed) < column
+}
+
+// isBlankLine reports whether a line holds only whitespace
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// migrateMonitorDetection moves use_detected_monitors into monitor_detection as
+// enabled; a detection_command that use_native makes unused is kept and noted
+func migrateMonitorDetection(root *yaml.Node) (bool, []string, error) {
+	changed := false
+	if mappingValue(root, "use_detected_monitors") != nil {
+		detection := ensureMapping(root, "monitor_detection")
+		if mappingValue(detection, "enabled") != nil {
+			return false, nil, fmt.Errorf("use_detected_monitors and monitor_detection.enabled are both set")
+		}
+		key, value := removeMappingEntry(root, "use_detected_monitors")
+		key.Value = "enabled"
+		prependMappingEntry(detection, key, value)
+		changed = true
+	}
+
+	var notes []string
+	detection := mappingValue(root, "monitor_detection")
+	if native := mappingValue(detection, "use_native"); native != nil && native.Tag == "!!bool" {
+		command := mappingValue(detection, "detection_command")
+		if enabled, err := strconv.ParseBool(native.Value); err == nil && enabled && command != nil {
+			notes = append(notes, fmt.Sprintf(
+				"monitor_detection.detection_command %q is not used while use_native is true; remove it if it is no longer needed",
+				command.Value))
+		}
+	}
+	return changed, notes, nil
+}
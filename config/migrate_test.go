@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		applied  int
+		notes    []string
+		errorMsg string
+	}{
+		{
+			name:     "detection moved into monitor_detection",
+			input:    "use_detected_monitors: true\nmonitor_detection:\n  use_native: true\n  detection_command: xrandr\n",
+			expected: "version: 2\nmonitor_detection:\n  enabled: true\n  use_native: true\n  detection_command: xrandr\n",
+			applied:  1,
+			notes:    []string{`monitor_detection.detection_command "xrandr" is not used while use_native is true; remove it if it is no longer needed`},
+		},
+		{
+			name:     "monitor_detection created",
+			input:    "i3:\n  mod_key: Mod4\nuse_detected_monitors: false\n",
+			expected: "version: 2\ni3:\n  mod_key: Mod4\nmonitor_detection:\n  enabled: false\n",
+			applied:  1,
+		},
+		{
+			name:     "detection command kept without native detection",
+			input:    "monitor_detection:\n  detection_command: xrandr\n",
+			expected: "version: 2\nmonitor_detection:\n  detection_command: xrandr\n",
+		},
+		{
+			name:     "explicit old version",
+			input:    "version: 1\ni3:\n  mod_key: Mod4\n",
+			expected: "version: 2\ni3:\n  mod_key: Mod4\n",
+		},
+		{
+			name:     "current version",
+			input:    "version: 2\nuse_detected_monitors: true\n",
+			expected: "version: 2\nuse_detected_monitors: true\n",
+		},
+		{
+			name:     "newer version",
+			input:    "version: 3\n",
+			errorMsg: "configuration version 3 is newer than the supported version 2",
+		},
+		{
+			name:     "invalid version",
+			input:    "version: two\n",
+			errorMsg: `1:10: version: expected a positive integer, got "two"`,
+		},
+		{
+			name:     "enabled set twice",
+			input:    "use_detected_monitors: true\nmonitor_detection:\n  enabled: true\n",
+			errorMsg: "use_detected_monitors and monitor_detection.enabled are both set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.input), &document); err != nil {
+				t.Fatalf("Failed to parse input: %v", err)
+			}
+
+			applied, notes, err := Migrate(&document)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(applied) != tt.applied {
+				t.Errorf("Expected %d migrations applied, got %d", tt.applied, len(applied))
+			}
+			if !reflect.DeepEqual(notes, tt.notes) {
+				t.Errorf("Expected notes %q, got %q", tt.notes, notes)
+			}
+
+			var buf bytes.Buffer
+			encoder := yaml.NewEncoder(&buf)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(&document); err != nil {
+				t.Fatalf("Failed to encode document: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestLoader_MigrateFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := `# i3-config-generator settings
+
+# Host settings
+i3:
+  mod_key: "Mod4" # Super
+
+# Detect monitors on every run
+use_detected_monitors: true
+`
+	configPath := filepath.Join(tempDir, ConfigFileYAML)
+	if err := os.WriteFile(configPath, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// The config file is found in the loader's directory
+	result, err := NewLoader(tempDir).MigrateFile("")
+	if err != nil {
+		t.Fatalf("Failed to migrate config: %v", err)
+	}
+	if result.Path != configPath {
+		t.Errorf("Expected path %s, got %s", configPath, result.Path)
+	}
+	if result.Version != 1 || len(result.Applied) != 1 {
+		t.Errorf("Expected version 1 with 1 migration applied, got version %d with %d", result.Version, len(result.Applied))
+	}
+
+	// Comments move along with the settings they describe
+	expected := `# i3-config-generator settings
+
+version: 2
+
+# Host settings
+i3:
+  mod_key: "Mod4" # Super
+
+monitor_detection:
+  # Detect monitors on every run
+  enabled: true
+`
+	if string(result.Migrated) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result.Migrated)
+	}
+
+	// The file itself is left alone
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	if string(data) != original {
+		t.Error("Expected the config file to be unchanged")
+	}
+}
+
+func TestLoader_MigrateFile_KeepsFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		expected string
+	}{
+		{
+			name: "untouched entries",
+			original: `version: 1
+
+layouts:
+  one_mon:
+    move_workspace:
+      "Ctrl+Shift+1": "left_display"    # Aligned
+      "Ctrl+Shift+2": "primary_display" # comments
+
+  no_mon:
+    gaps_inner: 10
+
+use_detected_monitors: false
+monitor_detection:
+  use_native: true
+  display: ":0"
+
+  # Kept apart
+  detection_command: "xrandr --listmonitors"
+`,
+			expected: `version: 2
+
+layouts:
+  one_mon:
+    move_workspace:
+      "Ctrl+Shift+1": "left_display"    # Aligned
+      "Ctrl+Shift+2": "primary_display" # comments
+
+  no_mon:
+    gaps_inner: 10
+
+monitor_detection:
+  enabled: false
+  use_native: true
+  display: ":0"
+
+  # Kept apart
+  detection_command: "xrandr --listmonitors"
+`,
+		},
+		{
+			name:     "flow mapping",
+			original: "use_detected_monitors: true\nmonitor_detection: {use_native: true}\n",
+			expected: "version: 2\nmonitor_detection: {enabled: true, use_native: true}\n",
+		},
+		{
+			// Documents that are a flow mapping are encoded again
+			name:     "flow document",
+			original: "{use_detected_monitors: true}\n",
+			expected: "{version: 2, monitor_detection: {enabled: true}}\n",
+		},
+	}
+
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tempDir, ConfigFileYAML)
+			if err := os.WriteFile(configPath, []byte(tt.original), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			result, err := NewLoader(tempDir).MigrateFile("")
+			if err != nil {
+				t.Fatalf("Failed to migrate config: %v", err)
+			}
+			if string(result.Migrated) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result.Migrated)
+			}
+		})
+	}
+}
+
+func TestParse_MigratesOlderVersions(t *testing.T) {
+	cfg, err := Parse([]byte("i3:\n  mod_key: Mod4\nuse_detected_monitors: true\nmonitor_detection:\n  use_native: true\n"), "config.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if !cfg.MonitorDetection.Enabled {
+		t.Error("Expected monitor detection to be enabled")
+	}
+	if len(cfg.Warnings()) != 1 || !strings.Contains(cfg.Warnings()[0], `run "i3-config-generator migrate"`) {
+		t.Errorf("Expected a warning suggesting migrate, got %q", cfg.Warnings())
+	}
+
+	// Files without anything to upgrade load silently
+	cfg, err = Parse([]byte("i3:\n  mod_key: Mod4\n"), "config.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if len(cfg.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %q", cfg.Warnings())
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// documentRoot returns the top-level mapping of a YAML document node
+func documentRoot(document *yaml.Node) *yaml.Node {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+	return document
+}
+
+// mappingValue returns the value stored under key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue stores value under key in a mapping node, replacing an existing
+// value in place (keeping the key's comments) or appending a new entry
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			// Keep comments attached to the old value
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+}
+
+// ensureMapping returns the mapping stored under key, creating it if needed
+func ensureMapping(mapping *yaml.Node, key string) *yaml.Node {
+	if value := mappingValue(mapping, key); value != nil && value.Kind == yaml.MappingNode {
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(mapping, key, value)
+	return value
+}
+
+// stringNode returns a double-quoted scalar node
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+}
+
+// stringSequenceNode returns a sequence node of double-quoted scalars
+func stringSequenceNode(values []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range values {
+		node.Content = append(node.Content, stringNode(value))
+	}
+	return node
+}
+
+// encodeNode encodes v as a node, double-quoting string values like stringNode
+func encodeNode(v interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	quoteStrings(node)
+	return node, nil
+}
+
+// quoteStrings double-quotes the string values below node, leaving mapping keys plain
+func quoteStrings(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			quoteStrings(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			quoteStrings(child)
+		}
+	}
+}
+
+// removeMappingEntry removes key from a mapping node, returning the removed key and
+// value nodes, or nil when the key is not present
+func removeMappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			keyNode, valueNode := mapping.Content[i], mapping.Content[i+1]
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return keyNode, valueNode
+		}
+	}
+	return nil, nil
+}
+
+// prependMappingEntry adds an entry at the start of a mapping node
+func prependMappingEntry(mapping *yaml.Node, key, value *yaml.Node) {
+	mapping.Content = append([]*yaml.Node{key, value}, mapping.Content...)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MapEntry is a single key/value pair of an OrderedMap
+type MapEntry struct {
+	Key   string
+	Value string
+}
+
+// OrderedMap is a string-to-string mapping that preserves the order in which
+// its entries appear in the YAML document, so rendered output is stable
+type OrderedMap []MapEntry
+
+// UnmarshalYAML decodes a YAML mapping node, keeping document order
+func (m *OrderedMap) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping, got %s", node.Line, nodeKindName(node.Kind))
+	}
+
+	result := make(OrderedMap, 0, len(node.Content)/2)
+	seen := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if keyNode.Kind != yaml.ScalarNode || valueNode.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: keys and values must be scalars", keyNode.Line)
+		}
+		if seen[keyNode.Value] {
+			return fmt.Errorf("line %d: duplicate key %q", keyNode.Line, keyNode.Value)
+		}
+		seen[keyNode.Value] = true
+		result = append(result, MapEntry{Key: keyNode.Value, Value: valueNode.Value})
+	}
+
+	*m = result
+	return nil
+}
+
+// MarshalYAML encodes the map as a YAML mapping, keeping entry order
+func (m OrderedMap) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, entry := range m {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entry.Key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entry.Value},
+		)
+	}
+	return node, nil
+}
+
+// Get returns the value stored under key and whether it was present
+func (m OrderedMap) Get(key string) (string, bool) {
+	for _, entry := range m {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value stored under key, appending a new entry if needed
+func (m *OrderedMap) Set(key, value string) {
+	for i := range *m {
+		if (*m)[i].Key == key {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, MapEntry{Key: key, Value: value})
+}
+
+// Keys returns the keys of the map in order
+func (m OrderedMap) Keys() []string {
+	keys := make([]string, len(m))
+	for i, entry := range m {
+		keys[i] = entry.Key
+	}
+	return keys
+}
+
+// SortedNatural returns a copy of the map ordered by key using natural sort,
+// so that workspace "10" sorts after "2"
+func (m OrderedMap) SortedNatural() OrderedMap {
+	sorted := make(OrderedMap, len(m))
+	copy(sorted, m)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return NaturalLess(sorted[i].Key, sorted[j].Key)
+	})
+	return sorted
+}
+
+// NaturalLess compares two strings treating runs of digits as numbers
+func NaturalLess(a, b string) bool {
+	ar, br := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ar) && j < len(br) {
+		if unicode.IsDigit(ar[i]) && unicode.IsDigit(br[j]) {
+			si := i
+			for i < len(ar) && unicode.IsDigit(ar[i]) {
+				i++
+			}
+			sj := j
+			for j < len(br) && unicode.IsDigit(br[j]) {
+				j++
+			}
+			na, errA := strconv.ParseUint(string(ar[si:i]), 10, 64)
+			nb, errB := strconv.ParseUint(string(br[sj:j]), 10, 64)
+			if errA == nil && errB == nil && na != nb {
+				return na < nb
+			}
+			if errA != nil || errB != nil {
+				if sa, sb := string(ar[si:i]), string(br[sj:j]); sa != sb {
+					return sa < sb
+				}
+			}
+			continue
+		}
+		if ar[i] != br[j] {
+			return ar[i] < br[j]
+		}
+		i++
+		j++
+	}
+	return len(ar)-i < len(br)-j
+}
+
+// nodeKindName returns a human readable name for a YAML node kind
+func nodeKindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.DocumentNode:
+		return "document"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.AliasNode:
+		return "alias"
+	default:
+		return "unknown node"
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOrderedMap_UnmarshalYAML(t *testing.T) {
+	input := `
+"10": right_display
+"2": left_display
+"1": left_display
+`
+
+	var m OrderedMap
+	if err := yaml.Unmarshal([]byte(input), &m); err != nil {
+		t.Fatalf("Failed to unmarshal ordered map: %v", err)
+	}
+
+	expectedKeys := []string{"10", "2", "1"}
+	keys := m.Keys()
+	if len(keys) != len(expectedKeys) {
+		t.Fatalf("Expected %d keys, got %d", len(expectedKeys), len(keys))
+	}
+	for i, key := range expectedKeys {
+		if keys[i] != key {
+			t.Errorf("Expected key[%d] = %s, got %s", i, key, keys[i])
+		}
+	}
+
+	if value, ok := m.Get("2"); !ok || value != "left_display" {
+		t.Errorf("Expected Get(\"2\") = left_display, got %s (present: %v)", value, ok)
+	}
+
+	// Test: Duplicate keys are rejected
+	if err := yaml.Unmarshal([]byte("a: b\na: c\n"), &m); err == nil {
+		t.Error("Expected error for duplicate keys")
+	}
+
+	// Test: Non-mapping nodes are rejected
+	if err := yaml.Unmarshal([]byte("- a\n- b\n"), &m); err == nil {
+		t.Error("Expected error when decoding a sequence")
+	}
+}
+
+func TestOrderedMap_MarshalYAML(t *testing.T) {
+	m := OrderedMap{
+		{Key: "b", Value: "2"},
+		{Key: "a", Value: "1"},
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		t.Fatalf("Failed to marshal ordered map: %v", err)
+	}
+
+	expected := "b: \"2\"\na: \"1\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestOrderedMap_Set(t *testing.T) {
+	var m OrderedMap
+	m.Set("a", "1")
+	m.Set("b", "2")
+	m.Set("a", "3")
+
+	if len(m) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(m))
+	}
+	if m[0].Key != "a" || m[0].Value != "3" {
+		t.Errorf("Expected first entry a=3, got %s=%s", m[0].Key, m[0].Value)
+	}
+}
+
+func TestOrderedMap_SortedNatural(t *testing.T) {
+	m := OrderedMap{
+		{Key: "10", Value: "c"},
+		{Key: "2", Value: "b"},
+		{Key: "1", Value: "a"},
+		{Key: "web", Value: "d"},
+	}
+
+	sorted := m.SortedNatural()
+	expectedKeys := []string{"1", "2", "10", "web"}
+	for i, key := range expectedKeys {
+		if sorted[i].Key != key {
+			t.Errorf("Expected key[%d] = %s, got %s", i, key, sorted[i].Key)
+		}
+	}
+
+	// The original map must be left untouched
+	if m[0].Key != "10" {
+		t.Errorf("SortedNatural modified the original map: %v", m.Keys())
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"1", "2", true},
+		{"2", "10", true},
+		{"10", "2", false},
+		{"ws2", "ws10", true},
+		{"Ctrl+Shift+1", "Ctrl+Shift+2", true},
+		{"a", "a", false},
+		{"a", "ab", true},
+		{"ab", "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if result := NaturalLess(tt.a, tt.b); result != tt.less {
+				t.Errorf("NaturalLess(%q, %q) = %v, want %v", tt.a, tt.b, result, tt.less)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutOverrides replaces global settings while a layout is rendered
+// Sections given as mappings are merged field by field: keys that are left out keep
+// the global setting and keys set to an empty value clear it; lists replace the
+// global list, so [] removes every entry
+type LayoutOverrides struct {
+	I3                  *I3Config         `yaml:"i3"`
+	ApplicationBindings *OrderedMap       `yaml:"application_bindings"`
+	Assignments         *[]Assignment     `yaml:"assignments"`
+	StartupPrograms     *[]string         `yaml:"startup_programs"`
+	WindowOverrides     *[]WindowRule     `yaml:"window_overrides"`
+	Colors              *ColorConfig      `yaml:"colors"`
+	Keyboard            *KeyboardConfig   `yaml:"keyboard"`
+	Terminal            string            `yaml:"terminal"`
+	Launcher            *LauncherConfig   `yaml:"launcher"`
+	Locker              *LockerConfig     `yaml:"locker"`
+	Bar                 *BarConfig        `yaml:"bar"`
+	MediaKeys           *MediaKeysConfig  `yaml:"media_keys"`
+	Backlight           *BacklightConfig  `yaml:"backlight"`
+	Hotkeys             *[]HotkeyConfig   `yaml:"hotkeys"`
+	Extra               *ExtraConfig      `yaml:"extra"`
+	Workspaces          *WorkspacesConfig `yaml:"workspaces"`
+	GapKeys             *GapKeysConfig    `yaml:"gap_keys"`
+
+	// present holds the keys given in YAML, as "section.key" for keys of sections
+	present map[string]bool
+}
+
+// UnmarshalYAML decodes the overrides and records which keys are given, so that
+// keys set to an empty value can still override the global setting
+func (o *LayoutOverrides) UnmarshalYAML(node *yaml.Node) error {
+	type plain LayoutOverrides
+	if err := node.Decode((*plain)(o)); err != nil {
+		return err
+	}
+
+	o.present = map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		section, value := node.Content[i].Value, node.Content[i+1]
+		o.present[section] = true
+		if value.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			o.present[section+"."+value.Content[j].Value] = true
+		}
+	}
+	return nil
+}
+
+// isZero reports whether the overrides leave every global setting unchanged
+func (o LayoutOverrides) isZero() bool {
+	return len(o.present) == 0 && reflect.ValueOf(o).IsZero()
+}
+
+// ForLayout returns the configuration with the overrides of the named layout applied
+// The receiver is left unchanged
+func (c *Config) ForLayout(layoutName string) (*Config, error) {
+	layout, err := c.GetLayout(layoutName)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *c
+	layout.Overrides.apply(&merged)
+	return &merged, nil
+}
+
+// apply merges the overrides onto config
+func (o *LayoutOverrides) apply(config *Config) {
+	if o.I3 != nil {
+		o.merge(&config.I3, o.I3, "i3")
+	}
+	if o.ApplicationBindings != nil {
+		config.ApplicationBindings = *o.ApplicationBindings
+	}
+	if o.Assignments != nil {
+		config.Assignments = *o.Assignments
+	}
+	if o.StartupPrograms != nil {
+		config.StartupPrograms = *o.StartupPrograms
+	}
+	if o.WindowOverrides != nil {
+		config.WindowOverrides = *o.WindowOverrides
+	}
+	if o.Colors != nil {
+		o.merge(&config.Colors, o.Colors, "colors")
+	}
+	if o.Keyboard != nil {
+		o.merge(&config.Keyboard, o.Keyboard, "keyboard")
+	}
+	if o.Terminal != "" || o.present["terminal"] {
+		config.Terminal = o.Terminal
+	}
+	if o.Launcher != nil {
+		o.merge(&config.Launcher, o.Launcher, "launcher")
+	}
+	if o.Locker != nil {
+		o.merge(&config.Locker, o.Locker, "locker")
+	}
+	if o.Bar != nil {
+		o.merge(&config.Bar, o.Bar, "bar")
+	}
+	if o.MediaKeys != nil {
+		o.merge(&config.MediaKeys, o.MediaKeys, "media_keys")
+	}
+	if o.Backlight != nil {
+		o.merge(&config.Backlight, o.Backlight, "backlight")
+	}
+	if o.Hotkeys != nil {
+		config.Hotkeys = *o.Hotkeys
+	}
+	if o.Extra != nil {
+		config.Extra = mergeExtra(config.Extra, *o.Extra)
+	}
+	if o.Workspaces != nil {
+		o.merge(&config.Workspaces, o.Workspaces, "workspaces")
+	}
+	if o.GapKeys != nil {
+		o.merge(&config.GapKeys, o.GapKeys, "gap_keys")
+	}
+}
+
+// mergeExtra replaces the snippet lists of extra that override sets, including
+// the lists of individual modes
+func mergeExtra(extra, override ExtraConfig) ExtraConfig {
+	if override.BeforeKeybindings != nil {
+		extra.BeforeKeybindings = override.BeforeKeybindings
+	}
+	if override.AfterWorkspaces != nil {
+		extra.AfterWorkspaces = override.AfterWorkspaces
+	}
+	if override.EndOfFile != nil {
+		extra.EndOfFile = override.EndOfFile
+	}
+	if override.Modes != nil {
+		modes := make(map[string][]Snippet, len(extra.Modes)+len(override.Modes))
+		for mode, snippets := range extra.Modes {
+			modes[mode] = snippets
+		}
+		for mode, snippets := range override.Modes {
+			modes[mode] = snippets
+		}
+		extra.Modes = modes
+	}
+	return extra
+}
+
+// merge copies the fields of src onto dst, which must be pointers to the same
+// struct type, when they are non-zero or given in YAML under section
+func (o *LayoutOverrides) merge(dst, src interface{}, section string) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		key, _, _ := strings.Cut(s.Type().Field(i).Tag.Get("yaml"), ",")
+		if !s.Field(i).IsZero() || o.present[section+"."+key] {
+			d.Field(i).Set(s.Field(i))
+		}
+	}
+}
+
+// validateLayoutOverrides checks the settings each layout ends up with; global is
+// the result of validating the global settings, whose problems are not repeated
+func (c *Config) validateLayoutOverrides(global error) error {
+	reported := map[Problem]bool{}
+	if validationErr, ok := global.(*ValidationError); ok {
+		for _, problem := range validationErr.Problems {
+			reported[problem] = true
+		}
+	}
+
+	var problems problemList
+	for _, name := range c.LayoutNames() {
+		if c.Layouts[name].Overrides.isZero() {
+			continue
+		}
+		merged, err := c.ForLayout(name)
+		if err != nil {
+			return err
+		}
+		var layoutProblems problemList
+		layoutProblems.add("", merged.validateSettings())
+		for _, problem := range layoutProblems.problems {
+			if !reported[problem] {
+				problems.add("layouts."+name+".overrides", &ValidationError{Problems: []Problem{problem}})
+			}
+		}
+	}
+	return problems.err()
+}
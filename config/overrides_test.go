@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const overridesConfig = `
+i3:
+  mod_key: "Mod4"
+  font: "pango:DejaVu Sans Mono 8"
+startup_programs:
+  - "nm-applet"
+  - "~/bin/external-displays.sh"
+colors:
+  base00: "#1B2B34"
+  base01: "#343D46"
+extra:
+  end_of_file:
+    - "default_border pixel 1"
+  modes:
+    resize:
+      - "bindsym h resize shrink width 1 px"
+layouts:
+  two_mon: {}
+  no_mon:
+    overrides:
+      i3:
+        font: "pango:DejaVu Sans Mono 12"
+      startup_programs: []
+      colors:
+        base01: "#000000"
+      bar:
+        position: "top"
+      extra:
+        modes:
+          power:
+            - "bindsym x exec xset dpms force off"
+`
+
+func loadOverridesConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	path := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return NewLoader(tempDir).LoadFromFile(path)
+}
+
+func TestConfig_ForLayout(t *testing.T) {
+	config, err := loadOverridesConfig(t, overridesConfig)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	merged, err := config.ForLayout("no_mon")
+	if err != nil {
+		t.Fatalf("Failed to apply overrides: %v", err)
+	}
+
+	// Sections are merged field by field
+	if merged.I3.Font != "pango:DejaVu Sans Mono 12" || merged.I3.ModKey != "Mod4" {
+		t.Errorf("Expected font override with global mod key, got %+v", merged.I3)
+	}
+	if merged.Colors.Base00 != "#1B2B34" || merged.Colors.Base01 != "#000000" {
+		t.Errorf("Expected base01 override only, got %s %s", merged.Colors.Base00, merged.Colors.Base01)
+	}
+	if merged.Bar.Position != "top" || merged.Bar.StatusCommand != DefaultBarStatusCommand {
+		t.Errorf("Expected bar position override with default status command, got %+v", merged.Bar)
+	}
+
+	// Lists are replaced, so an empty list removes the global entries
+	if len(merged.StartupPrograms) != 0 {
+		t.Errorf("Expected no startup programs, got %v", merged.StartupPrograms)
+	}
+
+	// Extra snippets are replaced per list and per mode
+	if len(merged.Extra.EndOfFile) != 1 || len(merged.Extra.Modes[ExtraModeResize]) != 1 || len(merged.Extra.Modes[ExtraModePower]) != 1 {
+		t.Errorf("Expected global snippets with power mode added, got %+v", merged.Extra)
+	}
+
+	// The global configuration is left unchanged
+	if config.I3.Font != "pango:DejaVu Sans Mono 8" || len(config.StartupPrograms) != 2 || len(config.Extra.Modes) != 1 {
+		t.Errorf("Expected global settings to be unchanged, got %+v", config)
+	}
+
+	// Layouts without overrides use the global settings
+	unchanged, err := config.ForLayout("two_mon")
+	if err != nil {
+		t.Fatalf("Failed to apply overrides: %v", err)
+	}
+	if !reflect.DeepEqual(unchanged.StartupPrograms, config.StartupPrograms) || unchanged.I3 != config.I3 {
+		t.Errorf("Expected global settings for two_mon, got %+v", unchanged)
+	}
+
+	// Test: Unknown layout
+	if _, err := config.ForLayout("three_mon"); err == nil {
+		t.Error("Expected error for unknown layout")
+	}
+}
+
+func TestConfig_ForLayout_ClearValue(t *testing.T) {
+	content := `
+i3:
+  mod_key: "Mod4"
+bar:
+  command: "polybar main"
+  status_command: "i3blocks"
+terminal: "alacritty"
+layouts:
+  two_mon: {}
+  no_mon:
+    overrides:
+      bar:
+        command: ""
+      terminal: ""
+`
+	config, err := loadOverridesConfig(t, content)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	merged, err := config.ForLayout("no_mon")
+	if err != nil {
+		t.Fatalf("Failed to apply overrides: %v", err)
+	}
+
+	// Keys set to an empty value clear the global setting, others are kept
+	if merged.Bar.Command != "" || merged.Bar.StatusCommand != "i3blocks" {
+		t.Errorf("Expected bar command to be cleared with global status command, got %+v", merged.Bar)
+	}
+	if merged.Terminal != "" {
+		t.Errorf("Expected terminal to be cleared, got %s", merged.Terminal)
+	}
+	if config.Bar.Command != "polybar main" || config.Terminal != "alacritty" {
+		t.Errorf("Expected global settings to be unchanged, got %+v %s", config.Bar, config.Terminal)
+	}
+}
+
+func TestConfig_Validate_LayoutOverrides(t *testing.T) {
+	invalid := strings.Replace(overridesConfig, `position: "top"`, `position: "left"`, 1)
+	_, err := loadOverridesConfig(t, invalid)
+	if err == nil || !strings.Contains(err.Error(), `layouts.no_mon.overrides.bar.position: invalid value "left"`) {
+		t.Errorf("Expected bar position error for no_mon, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// xdgDir returns the directory in the environment variable name, or fallback
+// relative to the home directory when it is unset; the base directory
+// specification ignores relative paths
+func xdgDir(name, fallback string) string {
+	if dir := os.Getenv(name); filepath.IsAbs(dir) {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to the current directory if the home directory is not available
+		return fallback
+	}
+	return filepath.Join(homeDir, fallback)
+}
+
+// ConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config
+func ConfigHome() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateHome returns $XDG_STATE_HOME, defaulting to ~/.local/state
+func StateHome() string {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// MonitorStatePath returns the file the last detected monitors are kept in, for
+// rendering without access to the X server
+func MonitorStatePath() string {
+	return filepath.Join(StateHome(), AppName, "monitors.json")
+}
+
+// ConfigDirs returns the system configuration directories from $XDG_CONFIG_DIRS,
+// most important first, defaulting to /etc/xdg
+func ConfigDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return []string{"/etc/xdg"}
+	}
+	return dirs
+}
+
+// SearchDir is a directory searched for a configuration file
+type SearchDir struct {
+	Dir string
+	// System marks directories from $XDG_CONFIG_DIRS, whose settings the user's
+	// configuration overrides
+	System bool
+	// Path is the configuration file found in Dir, or empty when there is none
+	Path string
+}
+
+// SearchPath returns the directories searched for configuration files, lowest
+// priority first: the system directories, then the user's
+func (l *Loader) SearchPath() []SearchDir {
+	var search []SearchDir
+	for i := len(l.systemDirs) - 1; i >= 0; i-- {
+		search = append(search, SearchDir{Dir: l.systemDirs[i], System: true})
+	}
+	search = append(search, SearchDir{Dir: l.configDir})
+
+	for i := range search {
+		search[i].Path, _ = findConfigFile(search[i].Dir)
+	}
+	return search
+}
+
+// searchFiles returns the configuration files found on the search path, lowest
+// priority first
+func (l *Loader) searchFiles() ([]string, error) {
+	search := l.SearchPath()
+	var paths, dirs []string
+	for i := len(search) - 1; i >= 0; i-- {
+		if search[i].Path != "" {
+			paths = append([]string{search[i].Path}, paths...)
+		}
+		dirs = append(dirs, search[i].Dir)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no configuration file found in %s (tried: %s)",
+			strings.Join(dirs, ", "), strings.Join(configFileNames, ", "))
+	}
+	return paths, nil
+}
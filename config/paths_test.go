@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		expected []string
+	}{
+		{"unset", "", []string{"/etc/xdg"}},
+		{"list", "/opt/xdg:/etc/xdg", []string{"/opt/xdg", "/etc/xdg"}},
+		{"relative entries ignored", "xdg:/opt/xdg", []string{"/opt/xdg"}},
+		{"only relative entries", "xdg", []string{"/etc/xdg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_DIRS", tt.env)
+			if dirs := ConfigDirs(); !reflect.DeepEqual(dirs, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, dirs)
+			}
+		})
+	}
+}
+
+func TestConfigHome(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	if dir := ConfigHome(); dir != "/tmp/config" {
+		t.Errorf("Expected /tmp/config, got %s", dir)
+	}
+
+	// Relative values are ignored
+	t.Setenv("XDG_CONFIG_HOME", "config")
+	if dir := ConfigHome(); dir != "/home/user/.config" {
+		t.Errorf("Expected /home/user/.config, got %s", dir)
+	}
+}
+
+func TestLoader_Load_Layered(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	systemDir := filepath.Join(tempDir, "etc", AppName)
+	userDir := filepath.Join(tempDir, "home", AppName)
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(tempDir, "etc"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "home"))
+
+	// Team defaults, with a snippet next to them
+	system := `i3:
+  mod_key: "Mod4"
+  font: "pango:Sans 8"
+terminal: "alacritty"
+layouts:
+  two_mon:
+    description: "Two monitors"
+    gaps_inner: 10
+  one_mon:
+    description: "One monitor"
+extra:
+  end_of_file:
+    - file: "team.conf"
+`
+	user := `i3:
+  font: "pango:Mono 9"
+layouts:
+  two_mon:
+    gaps_inner: 20
+default_layout: one_mon
+`
+	for _, file := range []struct{ dir, content string }{{systemDir, system}, {userDir, user}} {
+		if err := os.MkdirAll(file.dir, 0755); err != nil {
+			t.Fatalf("Failed to create config dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(file.dir, ConfigFileYAML), []byte(file.content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	loader := NewLoader("")
+	search := loader.SearchPath()
+	if len(search) != 2 || !search[0].System || search[1].Path != filepath.Join(userDir, ConfigFileYAML) {
+		t.Errorf("Expected the system directory followed by the user's, got %+v", search)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Failed to load layered config: %v", err)
+	}
+
+	// The user's settings override the team's, which fill in the rest
+	if cfg.I3.ModKey != "Mod4" || cfg.I3.Font != "pango:Mono 9" || cfg.Terminal != "alacritty" {
+		t.Errorf("Expected merged i3 settings, got %+v and terminal %s", cfg.I3, cfg.Terminal)
+	}
+	layout := cfg.Layouts["two_mon"]
+	if layout.Description != "Two monitors" || layout.GapsInner != 20 {
+		t.Errorf("Expected two_mon merged from both files, got %+v", layout)
+	}
+	if cfg.DefaultLayout != "one_mon" {
+		t.Errorf("Expected default layout one_mon, got %s", cfg.DefaultLayout)
+	}
+	if snippet := cfg.Extra.EndOfFile[0].File; snippet != filepath.Join(systemDir, "team.conf") {
+		t.Errorf("Expected the snippet next to the system config, got %s", snippet)
+	}
+
+	layouts, err := loader.PeekLayouts("")
+	if err != nil {
+		t.Fatalf("Failed to peek layouts: %v", err)
+	}
+	if len(layouts) != 2 || !layouts[0].Default || layouts[0].Name != "one_mon" {
+		t.Errorf("Expected both layouts with one_mon as the default, got %+v", layouts)
+	}
+
+	// Problems are reported against the file the value came from
+	if err := os.WriteFile(filepath.Join(userDir, ConfigFileYAML), []byte("layouts:\n  two_mon:\n    gaps_inner: -5\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	_, err = loader.Load()
+	expected := filepath.Join(userDir, ConfigFileYAML) + ":3:17: layouts.two_mon.gaps_inner: must not be negative"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %v", expected, err)
+	}
+
+	// The system file is used on its own when the user has none
+	if err := os.Remove(filepath.Join(userDir, ConfigFileYAML)); err != nil {
+		t.Fatalf("Failed to remove config file: %v", err)
+	}
+	cfg, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Failed to load system config: %v", err)
+	}
+	if cfg.I3.Font != "pango:Sans 8" {
+		t.Errorf("Expected the system font, got %s", cfg.I3.Font)
+	}
+
+	if err := os.Remove(filepath.Join(systemDir, ConfigFileYAML)); err != nil {
+		t.Fatalf("Failed to remove config file: %v", err)
+	}
+	_, err = loader.Load()
+	if err == nil || !strings.Contains(err.Error(), userDir+", "+systemDir) {
+		t.Errorf("Expected error listing both directories, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an error or warning about a value in a configuration file
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+// String returns the problem as FILE:LINE:COLUMN: PATH: MESSAGE
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.File != "" {
+		b.WriteString(p.File + ":")
+	}
+	if p.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", p.Line, p.Column)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if p.Path != "" {
+		b.WriteString(p.Path + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ValidationError reports every problem found in a configuration file
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error lists the problems, one per line when there are several
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].String()
+	}
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d problems found", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sortProblems orders problems by file and by their position in it
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+}
+
+// problemList collects the problems found while validating a configuration
+type problemList struct {
+	problems []Problem
+}
+
+// add records err as a problem with the value at path; the problems of a
+// ValidationError are added individually, relative to path
+func (l *problemList) add(path string, err error) {
+	if err == nil {
+		return
+	}
+	if validationErr, ok := err.(*ValidationError); ok {
+		for _, problem := range validationErr.Problems {
+			problem.Path = joinPath(path, problem.Path)
+			l.problems = append(l.problems, problem)
+		}
+		return
+	}
+	l.problems = append(l.problems, Problem{Path: path, Message: err.Error()})
+}
+
+// addf records a problem with the value at path
+func (l *problemList) addf(path, format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the collected problems as a ValidationError, or nil when there are none
+func (l *problemList) err() error {
+	if len(l.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: l.problems}
+}
+
+// joinPath appends a relative path to a parent path
+func joinPath(parent, path string) string {
+	switch {
+	case parent == "":
+		return path
+	case path == "":
+		return parent
+	case strings.HasPrefix(path, "["):
+		return parent + path
+	default:
+		return parent + "." + path
+	}
+}
+
+// locateProblems fills in the file and position of problems from the values
+// their paths refer to in the document; fileOf names the file a node was read
+// from, and is given nil for problems whose path is not in the document
+func locateProblems(problems []Problem, document *yaml.Node, fileOf func(*yaml.Node) string) {
+	root := documentRoot(document)
+	for i := range problems {
+		node := nodeAtPath(root, problems[i].Path)
+		problems[i].File = fileOf(node)
+		if node != nil {
+			problems[i].Line = node.Line
+			problems[i].Column = node.Column
+		}
+	}
+	sortProblems(problems)
+}
+
+// nodeAtPath returns the deepest node along a path such as layouts.two_mon.gaps or
+// hotkeys[2].key, or nil when not even the first element of the path is present
+// Mapping keys may themselves contain dots, so the longest key that matches is used
+func nodeAtPath(node *yaml.Node, path string) *yaml.Node {
+	var found *yaml.Node
+	for path != "" && node != nil {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			matched := ""
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				rest, ok := strings.CutPrefix(path, key)
+				if !ok || rest != "" && rest[0] != '.' && rest[0] != '[' {
+					continue
+				}
+				if next == nil || len(key) > len(matched) {
+					matched, next = key, node.Content[i+1]
+				}
+			}
+			path = strings.TrimPrefix(path[len(matched):], ".")
+		case yaml.SequenceNode:
+			index, rest, ok := cutIndex(path)
+			if ok && index < len(node.Content) {
+				next = node.Content[index]
+				path = strings.TrimPrefix(rest, ".")
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, found = next, next
+	}
+	return found
+}
+
+// cutIndex splits a leading list index such as [2] from a path
+func cutIndex(path string) (index int, rest string, ok bool) {
+	inner, rest, ok := strings.Cut(strings.TrimPrefix(path, "["), "]")
+	if !ok || !strings.HasPrefix(path, "[") {
+		return 0, path, false
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return 0, path, false
+	}
+	return index, rest, true
+}
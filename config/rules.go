@@ -0,0 +1,471 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+// Criteria selects windows by their properties, see "Command criteria" in the
+// i3 user guide; string values other than window_type and urgent are regular expressions
+type Criteria struct {
+	Class      string `yaml:"class,omitempty"`
+	Instance   string `yaml:"instance,omitempty"`
+	Title      string `yaml:"title,omitempty"`
+	WindowRole string `yaml:"window_role,omitempty"`
+	WindowType string `yaml:"window_type,omitempty" schema:"window_type"`
+	Machine    string `yaml:"machine,omitempty"`
+	ConMark    string `yaml:"con_mark,omitempty"`
+	Workspace  string `yaml:"workspace,omitempty"`
+	// Urgent selects the latest or oldest urgent window
+	Urgent   string `yaml:"urgent,omitempty" schema:"urgent"`
+	Floating bool   `yaml:"floating,omitempty"`
+	Tiling   bool   `yaml:"tiling,omitempty"`
+	All      bool   `yaml:"all,omitempty"`
+}
+
+// windowTypes are the values i3 accepts for the window_type criterion
+var windowTypes = []string{
+	"normal", "dialog", "utility", "toolbar", "splash", "menu",
+	"dropdown_menu", "popup_menu", "tooltip", "notification",
+}
+
+// criterion is a criteria field with its i3 name, in the order fields are rendered
+type criterion struct {
+	key   string
+	value *string
+	flag  *bool
+	regex bool
+}
+
+// fields returns the criteria fields in rendering order
+func (c *Criteria) fields() []criterion {
+	return []criterion{
+		{key: "class", value: &c.Class, regex: true},
+		{key: "instance", value: &c.Instance, regex: true},
+		{key: "window_role", value: &c.WindowRole, regex: true},
+		{key: "window_type", value: &c.WindowType},
+		{key: "title", value: &c.Title, regex: true},
+		{key: "machine", value: &c.Machine, regex: true},
+		{key: "con_mark", value: &c.ConMark, regex: true},
+		{key: "workspace", value: &c.Workspace, regex: true},
+		{key: "urgent", value: &c.Urgent},
+		{key: "floating", flag: &c.Floating},
+		{key: "tiling", flag: &c.Tiling},
+		{key: "all", flag: &c.All},
+	}
+}
+
+// String renders the criteria in i3 syntax, e.g. [class="^Firefox$" floating]
+func (c Criteria) String() string {
+	var parts []string
+	for _, field := range c.fields() {
+		switch {
+		case field.flag != nil && *field.flag:
+			parts = append(parts, field.key)
+		case field.value != nil && *field.value != "":
+			parts = append(parts, field.key+"="+quoteValue(*field.value))
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// IsZero reports whether no criterion is set
+func (c Criteria) IsZero() bool {
+	return c == Criteria{}
+}
+
+// validate checks the criteria values, compiling the regular expressions as i3 would
+func (c Criteria) validate() error {
+	if c.IsZero() {
+		return fmt.Errorf("match needs at least one criterion")
+	}
+	if c.WindowType != "" && !slices.Contains(windowTypes, c.WindowType) {
+		return fmt.Errorf("invalid window_type '%s' (valid options: %s)", c.WindowType, strings.Join(windowTypes, ", "))
+	}
+	if c.Urgent != "" && c.Urgent != "latest" && c.Urgent != "oldest" {
+		return fmt.Errorf("invalid urgent '%s' (valid options: latest, oldest)", c.Urgent)
+	}
+	for _, field := range c.fields() {
+		if field.regex {
+			if err := validateCriterionRegex(field.key, *field.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateCriterionRegex checks that a criterion value is a valid regular expression
+// __focused__ is a special value matching the focused window, not a pattern
+func validateCriterionRegex(key, value string) error {
+	if value == "" || value == "__focused__" {
+		return nil
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("invalid %s regex %q: %w", key, value, err)
+	}
+	return nil
+}
+
+// ParseCriteria converts criteria in i3 syntax, e.g. [class="^Firefox$"], into Criteria
+// It fails for criteria that have no field, such as con_id
+func ParseCriteria(s string) (Criteria, error) {
+	parsed, err := i3conf.ParseCriteria(s)
+	if err != nil {
+		return Criteria{}, err
+	}
+
+	var criteria Criteria
+	fields := criteria.fields()
+	for _, c := range parsed {
+		found := false
+		for _, field := range fields {
+			if field.key != c.Key {
+				continue
+			}
+			found = true
+			if field.flag != nil {
+				*field.flag = true
+			} else {
+				*field.value = c.Value
+			}
+		}
+		if !found {
+			return Criteria{}, fmt.Errorf("unsupported criterion '%s'", c.Key)
+		}
+	}
+	return criteria, nil
+}
+
+// validateRawCriteria checks the regular expressions of criteria written in i3 syntax
+func validateRawCriteria(s string) error {
+	parsed, err := i3conf.ParseCriteria(s)
+	if err != nil {
+		return err
+	}
+	for _, c := range parsed {
+		for _, field := range (&Criteria{}).fields() {
+			if field.key == c.Key && field.regex {
+				if err := validateCriterionRegex(c.Key, c.Value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WindowRule applies actions to windows matching its criteria with for_window
+// In YAML a plain string is a rule in i3 syntax, e.g. '[class="mpv"] floating enable'
+type WindowRule struct {
+	// Raw is a rule written in i3 syntax; when set the other fields are unused
+	Raw string `yaml:"-"`
+
+	Match    Criteria      `yaml:"match"`
+	Floating *bool         `yaml:"floating,omitempty"`
+	Sticky   *bool         `yaml:"sticky,omitempty"`
+	Border   string        `yaml:"border,omitempty"`
+	Resize   *ResizeAction `yaml:"resize,omitempty"`
+	// At most one of the move actions may be set; MoveToOutput is a monitor role
+	MoveToScratchpad bool   `yaml:"move_to_scratchpad,omitempty"`
+	MoveToWorkspace  string `yaml:"move_to_workspace,omitempty"`
+	MoveToOutput     string `yaml:"move_to_output,omitempty" schema:"role"`
+	// Commands are further i3 commands run after the actions above
+	Commands []string `yaml:"commands,omitempty"`
+}
+
+// ResizeAction sets the size of matching windows in pixels
+type ResizeAction struct {
+	Width  int `yaml:"width"`
+	Height int `yaml:"height"`
+}
+
+// borderPattern matches the border styles i3 accepts
+var borderPattern = regexp.MustCompile(`^(none|(normal|pixel)( [0-9]+)?)$`)
+
+// UnmarshalYAML accepts either a rule in i3 syntax or a mapping
+func (r *WindowRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = WindowRule{Raw: node.Value}
+		return nil
+	}
+	type plain WindowRule
+	return node.Decode((*plain)(r))
+}
+
+// MarshalYAML writes raw rules back as plain strings
+func (r WindowRule) MarshalYAML() (interface{}, error) {
+	if r.Raw != "" {
+		return r.Raw, nil
+	}
+	type plain WindowRule
+	return plain(r), nil
+}
+
+// Actions returns the i3 commands the rule runs; output is the name of the
+// monitor that MoveToOutput refers to
+func (r WindowRule) Actions(output string) []string {
+	var actions []string
+	if r.Floating != nil {
+		actions = append(actions, "floating "+enableDisable(*r.Floating))
+	}
+	if r.Sticky != nil {
+		actions = append(actions, "sticky "+enableDisable(*r.Sticky))
+	}
+	if r.Border != "" {
+		actions = append(actions, "border "+r.Border)
+	}
+	if r.Resize != nil {
+		actions = append(actions, fmt.Sprintf("resize set %d px %d px", r.Resize.Width, r.Resize.Height))
+	}
+	actions = append(actions, r.Commands...)
+	switch {
+	case r.MoveToScratchpad:
+		actions = append(actions, "move scratchpad")
+	case r.MoveToWorkspace != "":
+		actions = append(actions, "move container to workspace "+quoteValueIfNeeded(r.MoveToWorkspace))
+	case r.MoveToOutput != "":
+		actions = append(actions, "move container to output "+output)
+	}
+	return actions
+}
+
+// validate checks a rule's criteria and actions
+func (r WindowRule) validate() error {
+	if r.Raw != "" {
+		parsed, err := i3conf.Parse(strings.NewReader("for_window "+r.Raw), "")
+		if err != nil || len(parsed.Statements) != 1 {
+			return fmt.Errorf("invalid rule %q", r.Raw)
+		}
+		rule, err := i3conf.ParseForWindow(parsed.Statements[0])
+		if err != nil {
+			return fmt.Errorf("expected '[criteria] command', got %q", r.Raw)
+		}
+		return validateRawCriteria(rule.Criteria)
+	}
+
+	if err := r.Match.validate(); err != nil {
+		return err
+	}
+	if r.Border != "" && !borderPattern.MatchString(r.Border) {
+		return fmt.Errorf("invalid border '%s' (valid options: none, normal [width], pixel [width])", r.Border)
+	}
+	if r.Resize != nil && (r.Resize.Width <= 0 || r.Resize.Height <= 0) {
+		return fmt.Errorf("resize needs a positive width and height")
+	}
+
+	moves := 0
+	for _, set := range []bool{r.MoveToScratchpad, r.MoveToWorkspace != "", r.MoveToOutput != ""} {
+		if set {
+			moves++
+		}
+	}
+	if moves > 1 {
+		return fmt.Errorf("only one of move_to_scratchpad, move_to_workspace and move_to_output may be set")
+	}
+	if r.MoveToOutput != "" && !slices.Contains(monitor.Roles, r.MoveToOutput) {
+		return fmt.Errorf("invalid move_to_output '%s' (valid options: %s)", r.MoveToOutput, strings.Join(monitor.Roles, ", "))
+	}
+
+	if len(r.Actions("")) == 0 {
+		return fmt.Errorf("rule has no actions")
+	}
+	return nil
+}
+
+// Assignment moves windows matching its criteria to a workspace or monitor when
+// they open; Layouts replaces the target in the named layouts
+type Assignment struct {
+	Match            Criteria `yaml:"match"`
+	AssignmentTarget `yaml:",inline"`
+	Layouts          map[string]AssignmentTarget `yaml:"layouts,omitempty" schema:"keys=layout"`
+}
+
+// AssignmentTarget is where assigned windows go: a workspace, or the monitor with a role
+type AssignmentTarget struct {
+	Workspace string `yaml:"workspace,omitempty"`
+	Output    string `yaml:"output,omitempty" schema:"role"`
+}
+
+// IsZero reports whether no target is set
+func (t AssignmentTarget) IsZero() bool {
+	return t == AssignmentTarget{}
+}
+
+// Target returns the assign target in i3 syntax; output is the name of the
+// monitor that Output refers to
+func (t AssignmentTarget) Target(output string) string {
+	if t.Output != "" {
+		return "output " + output
+	}
+	return "workspace " + quoteValueIfNeeded(t.Workspace)
+}
+
+// validate checks that at most one target is set and that outputs are monitor roles
+func (t AssignmentTarget) validate() error {
+	if t.Workspace != "" && t.Output != "" {
+		return fmt.Errorf("only one of workspace and output may be set")
+	}
+	if t.Output != "" && !slices.Contains(monitor.Roles, t.Output) {
+		return fmt.Errorf("invalid output '%s' (valid options: %s)", t.Output, strings.Join(monitor.Roles, ", "))
+	}
+	return nil
+}
+
+// TargetFor returns the target that applies in the named layout, which is zero
+// when windows are not assigned there
+func (a Assignment) TargetFor(layoutName string) AssignmentTarget {
+	if target, ok := a.Layouts[layoutName]; ok {
+		return target
+	}
+	return a.AssignmentTarget
+}
+
+// AssignmentsFor returns the assignments with the targets that apply in the named layout
+func (c *Config) AssignmentsFor(layoutName string) []Assignment {
+	assignments := make([]Assignment, 0, len(c.Assignments))
+	for _, assignment := range c.Assignments {
+		assignments = append(assignments, Assignment{
+			Match:            assignment.Match,
+			AssignmentTarget: assignment.TargetFor(layoutName),
+		})
+	}
+	return assignments
+}
+
+// validateAssignment checks an assignment's criteria, its targets and the layouts it names
+func (c *Config) validateAssignment(a Assignment) error {
+	if err := a.Match.validate(); err != nil {
+		return err
+	}
+	if a.AssignmentTarget.IsZero() && len(a.Layouts) == 0 {
+		return fmt.Errorf("workspace or output is required")
+	}
+	if err := a.AssignmentTarget.validate(); err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range a.Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := c.GetLayout(name); err != nil {
+			return fmt.Errorf("layouts: %w", err)
+		}
+		if err := a.Layouts[name].validate(); err != nil {
+			return fmt.Errorf("layouts.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateWindowRules checks window_overrides, assignments and the criteria of
+// application_bindings
+func (c *Config) validateWindowRules() error {
+	var problems problemList
+	for i, rule := range c.WindowOverrides {
+		problems.add(fmt.Sprintf("window_overrides[%d]", i), rule.validate())
+	}
+	for i, assignment := range c.Assignments {
+		problems.add(fmt.Sprintf("assignments[%d]", i), c.validateAssignment(assignment))
+	}
+	for _, entry := range c.ApplicationBindings {
+		problems.add("application_bindings."+entry.Key, validateRawCriteria(entry.Key))
+	}
+	return problems.err()
+}
+
+// quoteValue quotes a criterion value, escaping embedded quotes
+func quoteValue(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// quoteValueIfNeeded quotes a command argument that i3 would otherwise split
+func quoteValueIfNeeded(value string) string {
+	if strings.ContainsAny(value, " \t\",;") {
+		return quoteValue(value)
+	}
+	return value
+}
+
+// enableDisable returns the i3 word for switching a property on or off
+func enableDisable(enabled bool) string {
+	if enabled {
+		return "enable"
+	}
+	return "disable"
+}
+
+// ruleFromI3 converts a for_window rule into a typed rule where possible,
+// falling back to the rule in i3 syntax. Typed rules run their actions in a
+// fixed order, so rules whose commands are in a different order stay raw
+func ruleFromI3(criteria, command string) WindowRule {
+	raw := WindowRule{Raw: criteria + " " + command}
+	match, err := ParseCriteria(criteria)
+	if err != nil || match.IsZero() || strings.Contains(command, `"`) {
+		return raw
+	}
+
+	rule := WindowRule{Match: match}
+	var actions []string
+	for _, action := range strings.Split(command, ",") {
+		fields := strings.Fields(action)
+		switch {
+		case len(fields) == 0:
+			return raw
+		case len(fields) == 2 && fields[0] == "floating" && rule.Floating == nil && (fields[1] == "enable" || fields[1] == "disable"):
+			enabled := fields[1] == "enable"
+			rule.Floating = &enabled
+		case len(fields) == 2 && fields[0] == "sticky" && rule.Sticky == nil && (fields[1] == "enable" || fields[1] == "disable"):
+			enabled := fields[1] == "enable"
+			rule.Sticky = &enabled
+		case fields[0] == "border" && rule.Border == "" && borderPattern.MatchString(strings.Join(fields[1:], " ")):
+			rule.Border = strings.Join(fields[1:], " ")
+		case strings.Join(fields, " ") == "move scratchpad" || strings.Join(fields, " ") == "move to scratchpad":
+			rule.MoveToScratchpad = true
+			fields = []string{"move", "scratchpad"}
+		default:
+			rule.Commands = append(rule.Commands, strings.Join(fields, " "))
+		}
+		actions = append(actions, strings.Join(fields, " "))
+	}
+
+	if !slices.Equal(rule.Actions(""), actions) {
+		return raw
+	}
+	return rule
+}
+
+// assignmentFromI3 converts an assign rule targeting a workspace by name into
+// a typed assignment, reporting false for other rules
+func assignmentFromI3(criteria, target string) (Assignment, bool) {
+	match, err := ParseCriteria(criteria)
+	if err != nil || match.IsZero() {
+		return Assignment{}, false
+	}
+
+	workspace := target
+	if fields := strings.Fields(target); len(fields) > 1 && fields[0] == "workspace" {
+		workspace = strings.TrimSpace(strings.TrimPrefix(target, "workspace"))
+	}
+	if fields := strings.Fields(workspace); len(fields) == 0 || fields[0] == "output" || fields[0] == "number" {
+		return Assignment{}, false
+	}
+	if unquoted, err := strconv.Unquote(workspace); err == nil {
+		workspace = unquoted
+	}
+	if workspace == "" || strings.Contains(workspace, `"`) {
+		return Assignment{}, false
+	}
+	return Assignment{Match: match, AssignmentTarget: AssignmentTarget{Workspace: workspace}}, true
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCriteria_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria Criteria
+		expected string
+	}{
+		{"single class", Criteria{Class: "^Firefox$"}, `[class="^Firefox$"]`},
+		{"several fields in i3 order", Criteria{Title: "Library", Class: "Firefox", Floating: true}, `[class="Firefox" title="Library" floating]`},
+		{"quotes escaped", Criteria{Title: `say "hi"`}, `[title="say \"hi\""]`},
+		{"window type", Criteria{WindowType: "dialog", Urgent: "latest"}, `[window_type="dialog" urgent="latest"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.criteria.String(); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseCriteria(t *testing.T) {
+	criteria, err := ParseCriteria(`[class="^Firefox$" title="a \"b\"" floating]`)
+	if err != nil {
+		t.Fatalf("Failed to parse criteria: %v", err)
+	}
+	expected := Criteria{Class: "^Firefox$", Title: `a "b"`, Floating: true}
+	if criteria != expected {
+		t.Errorf("Expected %+v, got %+v", expected, criteria)
+	}
+
+	// Rendering the parsed criteria gives back the same i3 syntax
+	if criteria.String() != `[class="^Firefox$" title="a \"b\"" floating]` {
+		t.Errorf("Expected criteria to round-trip, got %s", criteria.String())
+	}
+
+	// Test: Criterion without a field
+	if _, err := ParseCriteria(`[con_id=12]`); err == nil {
+		t.Error("Expected error for unsupported criterion")
+	}
+}
+
+func TestWindowRule_UnmarshalYAML(t *testing.T) {
+	input := `
+- "[class=\"mpv\"] floating enable"
+- match:
+    class: "^Pavucontrol$"
+  floating: true
+  resize: {width: 800, height: 600}
+  move_to_output: right_display
+`
+	var rules []WindowRule
+	if err := yaml.Unmarshal([]byte(input), &rules); err != nil {
+		t.Fatalf("Failed to unmarshal rules: %v", err)
+	}
+
+	floating := true
+	expected := []WindowRule{
+		{Raw: `[class="mpv"] floating enable`},
+		{
+			Match:        Criteria{Class: "^Pavucontrol$"},
+			Floating:     &floating,
+			Resize:       &ResizeAction{Width: 800, Height: 600},
+			MoveToOutput: "right_display",
+		},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, rules)
+	}
+
+	expectedActions := []string{"floating enable", "resize set 800 px 600 px", "move container to output DP-1"}
+	if actions := rules[1].Actions("DP-1"); !reflect.DeepEqual(actions, expectedActions) {
+		t.Errorf("Expected actions %q, got %q", expectedActions, actions)
+	}
+
+	// Raw rules are written back as plain strings
+	data, err := yaml.Marshal(rules[:1])
+	if err != nil {
+		t.Fatalf("Failed to marshal rules: %v", err)
+	}
+	if string(data) != "- '[class=\"mpv\"] floating enable'\n" {
+		t.Errorf("Expected raw rule as a string, got %q", string(data))
+	}
+}
+
+func TestConfig_validateWindowRules(t *testing.T) {
+	sticky := false
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name: "valid rules",
+			config: Config{
+				WindowOverrides: []WindowRule{
+					{Raw: `[class="^mpv$"] floating enable`},
+					{Match: Criteria{Class: "(?i)^firefox$", WindowRole: "pop-up"}, Sticky: &sticky, Border: "pixel 1"},
+					{Match: Criteria{Title: "__focused__"}, MoveToScratchpad: true},
+				},
+				Assignments:         []Assignment{{Match: Criteria{Class: "^Slack$"}, AssignmentTarget: AssignmentTarget{Workspace: "3: chat"}}},
+				ApplicationBindings: OrderedMap{{Key: `[class="^Firefox$"]`, Value: "1"}},
+			},
+		},
+		{
+			name:     "invalid regex",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "^Fire(fox$"}, MoveToScratchpad: true}}},
+			expected: "window_overrides[0]: invalid class regex",
+		},
+		{
+			name:     "invalid regex in raw rule",
+			config:   Config{WindowOverrides: []WindowRule{{Raw: `[title="[unclosed"] floating enable`}}},
+			expected: "window_overrides[0]: invalid title regex",
+		},
+		{
+			name:     "raw rule without criteria",
+			config:   Config{WindowOverrides: []WindowRule{{Raw: "floating enable"}}},
+			expected: "window_overrides[0]: expected '[criteria] command'",
+		},
+		{
+			name:     "empty match",
+			config:   Config{WindowOverrides: []WindowRule{{Border: "none"}}},
+			expected: "match needs at least one criterion",
+		},
+		{
+			name:     "invalid window type",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{WindowType: "popup"}, Border: "none"}}},
+			expected: "invalid window_type 'popup'",
+		},
+		{
+			name:     "invalid urgent",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Urgent: "newest"}, Border: "none"}}},
+			expected: "invalid urgent 'newest'",
+		},
+		{
+			name:     "invalid border",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "x"}, Border: "thick"}}},
+			expected: "invalid border 'thick'",
+		},
+		{
+			name:     "invalid resize",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "x"}, Resize: &ResizeAction{Width: 800}}}},
+			expected: "resize needs a positive width and height",
+		},
+		{
+			name:     "several move actions",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "x"}, MoveToScratchpad: true, MoveToWorkspace: "2"}}},
+			expected: "only one of move_to_scratchpad",
+		},
+		{
+			name:     "unknown monitor role",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "x"}, MoveToOutput: "HDMI-1"}}},
+			expected: "invalid move_to_output 'HDMI-1'",
+		},
+		{
+			name:     "no actions",
+			config:   Config{WindowOverrides: []WindowRule{{Match: Criteria{Class: "x"}}}},
+			expected: "rule has no actions",
+		},
+		{
+			name:     "assignment without target",
+			config:   Config{Assignments: []Assignment{{Match: Criteria{Class: "x"}}}},
+			expected: "assignments[0]: workspace or output is required",
+		},
+		{
+			name: "assignment with both targets",
+			config: Config{Assignments: []Assignment{
+				{Match: Criteria{Class: "x"}, AssignmentTarget: AssignmentTarget{Workspace: "1", Output: "primary_display"}},
+			}},
+			expected: "only one of workspace and output",
+		},
+		{
+			name: "assignment to output name",
+			config: Config{Assignments: []Assignment{
+				{Match: Criteria{Class: "x"}, AssignmentTarget: AssignmentTarget{Output: "HDMI-1"}},
+			}},
+			expected: "invalid output 'HDMI-1'",
+		},
+		{
+			name: "assignment for unknown layout",
+			config: Config{
+				Layouts: map[string]LayoutConfig{"two_mon": {}},
+				Assignments: []Assignment{
+					{Match: Criteria{Class: "x"}, Layouts: map[string]AssignmentTarget{"two_mom": {Output: "right_display"}}},
+				},
+			},
+			expected: "did you mean 'two_mon'",
+		},
+		{
+			name: "invalid per-layout target",
+			config: Config{
+				Layouts: map[string]LayoutConfig{"no_mon": {}},
+				Assignments: []Assignment{
+					{Match: Criteria{Class: "x"}, Layouts: map[string]AssignmentTarget{"no_mon": {Output: "laptop"}}},
+				},
+			},
+			expected: "assignments[0]: layouts.no_mon: invalid output 'laptop'",
+		},
+		{
+			name:     "invalid regex in application binding",
+			config:   Config{ApplicationBindings: OrderedMap{{Key: `[class="*Firefox"]`, Value: "1"}}},
+			expected: "application_bindings.[class=\"*Firefox\"]: invalid class regex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateWindowRules()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestAssignmentTarget_Target(t *testing.T) {
+	tests := []struct {
+		target   AssignmentTarget
+		expected string
+	}{
+		{AssignmentTarget{Workspace: "1"}, "workspace 1"},
+		{AssignmentTarget{Workspace: "3: chat"}, `workspace "3: chat"`},
+		{AssignmentTarget{Output: "right_display"}, "output DP-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := tt.target.Target("DP-1"); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestConfig_AssignmentsFor(t *testing.T) {
+	input := `
+- match: {class: "^Slack$"}
+  output: right_display
+  layouts:
+    no_mon: {output: primary_display}
+    one_mon: {}
+- match: {class: "^Firefox$"}
+  layouts:
+    two_mon: {workspace: "web"}
+`
+	var assignments []Assignment
+	if err := yaml.Unmarshal([]byte(input), &assignments); err != nil {
+		t.Fatalf("Failed to unmarshal assignments: %v", err)
+	}
+	config := &Config{Assignments: assignments}
+
+	tests := []struct {
+		layout   string
+		expected []AssignmentTarget
+	}{
+		{"two_mon", []AssignmentTarget{{Output: "right_display"}, {Workspace: "web"}}},
+		{"one_mon", []AssignmentTarget{{}, {}}},
+		{"no_mon", []AssignmentTarget{{Output: "primary_display"}, {}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			var targets []AssignmentTarget
+			for _, assignment := range config.AssignmentsFor(tt.layout) {
+				targets = append(targets, assignment.AssignmentTarget)
+				if assignment.Layouts != nil {
+					t.Error("Expected per-layout targets to be resolved")
+				}
+			}
+			if !reflect.DeepEqual(targets, tt.expected) {
+				t.Errorf("Expected targets %+v, got %+v", tt.expected, targets)
+			}
+		})
+	}
+}
+
+func TestAssignmentFromI3(t *testing.T) {
+	tests := []struct {
+		criteria string
+		target   string
+		expected string
+		ok       bool
+	}{
+		{`[class="Slack"]`, "3", "3", true},
+		{`[class="Slack"]`, "workspace 3", "3", true},
+		{`[class="Slack"]`, `workspace "3: chat"`, "3: chat", true},
+		{`[class="Slack"]`, "workspace number 3", "", false},
+		{`[class="Slack"]`, "output HDMI-1", "", false},
+		{`[con_id=1]`, "3", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			assignment, ok := assignmentFromI3(tt.criteria, tt.target)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok %v, got %v", tt.ok, ok)
+			}
+			if ok && assignment.Workspace != tt.expected {
+				t.Errorf("Expected workspace %q, got %q", tt.expected, assignment.Workspace)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// ScalingConfig scales fonts, borders and gaps by the DPI of the primary display
+type ScalingConfig struct {
+	Rules []ScalingRule `yaml:"rules"`
+}
+
+// ScalingRule sets the scale factors used from a minimum DPI upwards
+// A factor of 0 leaves the setting unscaled
+type ScalingRule struct {
+	MinDPI  float64 `yaml:"min_dpi"`
+	Fonts   float64 `yaml:"fonts"`
+	Borders float64 `yaml:"borders"`
+	Gaps    float64 `yaml:"gaps"`
+}
+
+// fontSizePattern matches the trailing size of a pango font description
+var fontSizePattern = regexp.MustCompile(`^(pango:.*\s)(\d+(?:\.\d+)?)(px)?$`)
+
+// For returns the rule with the highest min_dpi not above dpi; an unknown DPI of 0
+// matches no rule, so nothing is scaled
+func (s ScalingConfig) For(dpi float64) ScalingRule {
+	var match ScalingRule
+	found := false
+	for _, rule := range s.Rules {
+		if dpi <= 0 || rule.MinDPI > dpi {
+			continue
+		}
+		if !found || rule.MinDPI > match.MinDPI {
+			match = rule
+			found = true
+		}
+	}
+	return match
+}
+
+// FontFactor returns the font scale factor, 1 when unset
+func (r ScalingRule) FontFactor() float64 {
+	return factorOrOne(r.Fonts)
+}
+
+// BorderFactor returns the border width scale factor, 1 when unset
+func (r ScalingRule) BorderFactor() float64 {
+	return factorOrOne(r.Borders)
+}
+
+// GapFactor returns the gap size scale factor, 1 when unset
+func (r ScalingRule) GapFactor() float64 {
+	return factorOrOne(r.Gaps)
+}
+
+// ScaleFont multiplies the size of a pango font description by factor
+// X core fonts have no trailing size and are returned unchanged
+func ScaleFont(font string, factor float64) string {
+	if factor == 1 {
+		return font
+	}
+	match := fontSizePattern.FindStringSubmatch(font)
+	if match == nil {
+		return font
+	}
+	size, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return font
+	}
+	return match[1] + strconv.Itoa(ScaleSize(size, factor)) + match[3]
+}
+
+// ScaleSize multiplies a size by factor, rounded to the nearest whole pixel
+func ScaleSize(size, factor float64) int {
+	return int(math.Round(size * factor))
+}
+
+// factorOrOne returns factor, or 1 when it is unset
+func factorOrOne(factor float64) float64 {
+	if factor == 0 {
+		return 1
+	}
+	return factor
+}
+
+// validate checks the scaling rules for negative factors and duplicate thresholds
+func (s ScalingConfig) validate() error {
+	seen := make(map[float64]bool)
+	for i, rule := range s.Rules {
+		if rule.MinDPI < 0 {
+			return fmt.Errorf("rules[%d]: min_dpi must not be negative", i)
+		}
+		if rule.Fonts < 0 || rule.Borders < 0 || rule.Gaps < 0 {
+			return fmt.Errorf("rules[%d]: scale factors must not be negative", i)
+		}
+		if seen[rule.MinDPI] {
+			return fmt.Errorf("rules[%d]: duplicate min_dpi %g", i, rule.MinDPI)
+		}
+		seen[rule.MinDPI] = true
+	}
+	return nil
+}
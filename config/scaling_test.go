@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScalingConfig_For(t *testing.T) {
+	scaling := ScalingConfig{Rules: []ScalingRule{
+		{MinDPI: 192, Fonts: 2},
+		{MinDPI: 120, Fonts: 1.5},
+	}}
+
+	tests := []struct {
+		dpi      float64
+		expected float64
+	}{
+		{0, 1},
+		{96, 1},
+		{120, 1.5},
+		{163.2, 1.5},
+		{282.4, 2},
+	}
+
+	for _, tt := range tests {
+		if factor := scaling.For(tt.dpi).FontFactor(); factor != tt.expected {
+			t.Errorf("Expected font factor %g at %g dpi, got %g", tt.expected, tt.dpi, factor)
+		}
+	}
+}
+
+func TestScaleFont(t *testing.T) {
+	tests := []struct {
+		font     string
+		factor   float64
+		expected string
+	}{
+		{"pango:DejaVu Sans Mono 8", 1.5, "pango:DejaVu Sans Mono 12"},
+		{"pango:monospace 9", 1.25, "pango:monospace 11"},
+		{"pango:Terminus 10.5", 2, "pango:Terminus 21"},
+		{"pango:Terminus 12px", 2, "pango:Terminus 24px"},
+		{"pango:DejaVu Sans Mono 8", 1, "pango:DejaVu Sans Mono 8"},
+		{"-misc-fixed-medium-r-normal--13-120-75-75-C-70-iso10646-1", 2, "-misc-fixed-medium-r-normal--13-120-75-75-C-70-iso10646-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.font, func(t *testing.T) {
+			if result := ScaleFont(tt.font, tt.factor); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestScalingConfig_validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scaling  ScalingConfig
+		expected string
+	}{
+		{"valid", ScalingConfig{Rules: []ScalingRule{{MinDPI: 120, Fonts: 1.5}, {MinDPI: 192, Borders: 2}}}, ""},
+		{"negative factor", ScalingConfig{Rules: []ScalingRule{{MinDPI: 120, Gaps: -1}}}, "rules[0]: scale factors must not be negative"},
+		{"duplicate threshold", ScalingConfig{Rules: []ScalingRule{{MinDPI: 120}, {MinDPI: 120}}}, "rules[1]: duplicate min_dpi 120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scaling.validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
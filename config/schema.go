@@ -0,0 +1,383 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaURI identifies the JSON Schema dialect of the generated schema
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema describing part of the configuration file
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+
+	// closed marks objects that accept no properties besides Properties
+	closed bool
+	// enumName names the list Enum was taken from, for error messages
+	enumName string
+}
+
+// schemaEnums are the named value lists that the schema tags of config fields refer to
+var schemaEnums = map[string][]string{
+	"role":              monitor.Roles,
+	"bar_position":      {"top", "bottom"},
+	"ordering":          {OrderingDocument, OrderingNatural},
+	"extra_mode":        ExtraModes,
+	"window_type":       windowTypes,
+	"urgent":            {"latest", "oldest"},
+	"workspace_scheme":  WorkspaceSchemes,
+	"smart_gaps":        SmartGapsModes,
+	"smart_borders":     SmartBordersModes,
+	"hide_edge_borders": HideEdgeBordersModes,
+}
+
+// enumNouns name what the values of an enum refer to, for "unknown X" errors
+var enumNouns = map[string]string{
+	"role":       "role",
+	"layout":     "layout",
+	"extra_mode": "mode",
+}
+
+// scalarForms are the types that may also be written as a plain string
+var scalarForms = map[reflect.Type]bool{
+	reflect.TypeOf(Snippet{}):    true,
+	reflect.TypeOf(WindowRule{}): true,
+}
+
+// MarshalJSON writes closed objects with additionalProperties set to false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if s.closed {
+		return json.Marshal(struct {
+			*plain
+			AdditionalProperties bool `json:"additionalProperties"`
+		}{(*plain)(s), false})
+	}
+	return json.Marshal((*plain)(s))
+}
+
+// GenerateSchema returns the JSON Schema of the configuration file; layout names,
+// if given, are the values accepted where a layout is referred to
+func GenerateSchema(layoutNames []string) *Schema {
+	enums := make(map[string][]string, len(schemaEnums)+1)
+	for name, values := range schemaEnums {
+		enums[name] = values
+	}
+	if len(layoutNames) > 0 {
+		enums["layout"] = layoutNames
+	}
+
+	schema := schemaFor(reflect.TypeOf(Config{}), "", enums)
+	schema.Schema = SchemaURI
+	schema.Title = "i3-config-generator configuration"
+	return schema
+}
+
+// schemaFor builds the schema of a Go type; tag is the schema struct tag of the
+// field holding it, naming the enum its values (or with "keys=", its keys) come from
+func schemaFor(t reflect.Type, tag string, enums map[string][]string) *Schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if scalarForms[t] {
+		object := structSchema(t, enums)
+		return &Schema{OneOf: []*Schema{{Type: "string"}, object}}
+	}
+
+	if t == reflect.TypeOf(OrderedMap{}) {
+		return &Schema{Type: "object", AdditionalProperties: enumSchema(&Schema{Type: "string"}, tag, enums)}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, enums)
+	case reflect.Map:
+		schema := &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), "", enums)}
+		if name, ok := strings.CutPrefix(tag, "keys="); ok {
+			schema.PropertyNames = enumSchema(&Schema{Type: "string"}, name, enums)
+		}
+		return schema
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), tag, enums)}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	case reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return enumSchema(&Schema{Type: "string"}, tag, enums)
+	}
+}
+
+// structSchema builds the closed object schema of a struct from its YAML field names
+func structSchema(t reflect.Type, enums map[string][]string) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, closed: true}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if strings.Contains(options, "inline") {
+			for key, property := range structSchema(field.Type, enums).Properties {
+				schema.Properties[key] = property
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		schema.Properties[name] = schemaFor(field.Type, field.Tag.Get("schema"), enums)
+	}
+	return schema
+}
+
+// enumSchema restricts schema to the named enum, when there is one
+func enumSchema(schema *Schema, name string, enums map[string][]string) *Schema {
+	if values, ok := enums[name]; ok {
+		schema.Enum = values
+		schema.enumName = name
+	}
+	return schema
+}
+
+// ValidateDocument checks a parsed configuration document against the schema and
+// returns every problem found, positioned in the file called name; unknown keys are
+// problems unless lenient is set, in which case they are returned as warnings
+func ValidateDocument(document *yaml.Node, name string, lenient bool) (warnings []Problem, err error) {
+	return validateDocument(document, name, documentLayoutNames(documentRoot(document)), lenient)
+}
+
+// validateDocument checks a document against the schema, accepting the given
+// layout names wherever a layout is selected
+func validateDocument(document *yaml.Node, name string, layoutNames []string, lenient bool) (warnings []Problem, err error) {
+	root := documentRoot(document)
+	if root.Kind == 0 {
+		// An empty document leaves everything unset
+		return nil, nil
+	}
+
+	check := &schemaCheck{file: name}
+	check.validate(GenerateSchema(layoutNames), root, "")
+
+	problems := check.problems
+	if lenient {
+		warnings = check.unknown
+		sortProblems(warnings)
+	} else {
+		problems = append(problems, check.unknown...)
+	}
+	if len(problems) > 0 {
+		sortProblems(problems)
+		return warnings, &ValidationError{Problems: problems}
+	}
+	return warnings, nil
+}
+
+// documentLayoutNames returns the names of the layouts defined in a document
+func documentLayoutNames(root *yaml.Node) []string {
+	layouts := mappingValue(root, "layouts")
+	if layouts == nil || layouts.Kind != yaml.MappingNode {
+		return nil
+	}
+	var names []string
+	for i := 0; i < len(layouts.Content); i += 2 {
+		names = append(names, layouts.Content[i].Value)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemaCheck collects the problems found while checking a document against the schema
+type schemaCheck struct {
+	file     string
+	problems []Problem
+	// unknown holds the keys that no schema property describes
+	unknown []Problem
+}
+
+// report records a problem with the value at node
+func (c *schemaCheck) report(node *yaml.Node, path, format string, args ...interface{}) {
+	c.problems = append(c.problems, c.problem(node, path, format, args...))
+}
+
+// problem builds a problem positioned at node
+func (c *schemaCheck) problem(node *yaml.Node, path, format string, args ...interface{}) Problem {
+	return Problem{
+		File:    c.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// validate checks node against schema
+func (c *schemaCheck) validate(schema *Schema, node *yaml.Node, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	// An empty value leaves the setting unset
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	if len(schema.OneOf) > 0 {
+		c.validateOneOf(schema, node, path)
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		c.validateObject(schema, node, path)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			c.report(node, path, "expected a list, got %s", nodeKindName(node.Kind))
+			return
+		}
+		for i, item := range node.Content {
+			c.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		c.validateScalar(schema, node, path)
+	}
+}
+
+// validateOneOf checks node against the alternative that matches its kind
+func (c *schemaCheck) validateOneOf(schema *Schema, node *yaml.Node, path string) {
+	for _, option := range schema.OneOf {
+		if option.matchesKind(node) {
+			c.validate(option, node, path)
+			return
+		}
+	}
+	var kinds []string
+	for _, option := range schema.OneOf {
+		kinds = append(kinds, option.kindName())
+	}
+	c.report(node, path, "expected a %s, got %s", strings.Join(kinds, " or "), nodeKindName(node.Kind))
+}
+
+// matchesKind reports whether the schema describes the kind of node
+func (s *Schema) matchesKind(node *yaml.Node) bool {
+	switch s.Type {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	default:
+		return node.Kind == yaml.ScalarNode
+	}
+}
+
+// kindName names the YAML node kind the schema describes
+func (s *Schema) kindName() string {
+	switch s.Type {
+	case "object":
+		return "mapping"
+	case "array":
+		return "list"
+	default:
+		return s.Type
+	}
+}
+
+// validateObject checks the keys and values of a mapping
+func (c *schemaCheck) validateObject(schema *Schema, node *yaml.Node, path string) {
+	if node.Kind != yaml.MappingNode {
+		c.report(node, path, "expected a mapping, got %s", nodeKindName(node.Kind))
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := key.Value
+		if path != "" {
+			keyPath = path + "." + key.Value
+		}
+		if schema.PropertyNames != nil {
+			if err := schema.PropertyNames.checkEnum(key.Value); err != nil {
+				c.report(key, keyPath, "%v", err)
+				continue
+			}
+		}
+		property, ok := schema.Properties[key.Value]
+		if !ok {
+			property = schema.AdditionalProperties
+		}
+		if property == nil {
+			c.unknown = append(c.unknown, c.problem(key, keyPath, "%v", unknownKeyError(key.Value, schema)))
+			continue
+		}
+		c.validate(property, value, keyPath)
+	}
+}
+
+// unknownKeyError describes a key the schema has no property for, suggesting the closest one
+func unknownKeyError(key string, schema *Schema) error {
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if suggestion := closestMatch(key, names); suggestion != "" {
+		return fmt.Errorf("unknown key, did you mean %q?", suggestion)
+	}
+	return fmt.Errorf("unknown key")
+}
+
+// validateScalar checks the type and allowed values of a scalar
+func (c *schemaCheck) validateScalar(schema *Schema, node *yaml.Node, path string) {
+	if node.Kind != yaml.ScalarNode {
+		c.report(node, path, "expected a %s, got %s", schema.Type, nodeKindName(node.Kind))
+		return
+	}
+	switch schema.Type {
+	case "boolean":
+		if node.Tag != "!!bool" {
+			c.report(node, path, "expected true or false, got %q", node.Value)
+			return
+		}
+	case "integer":
+		if node.Tag != "!!int" {
+			c.report(node, path, "expected an integer, got %q", node.Value)
+			return
+		}
+	case "number":
+		if node.Tag != "!!int" && node.Tag != "!!float" {
+			c.report(node, path, "expected a number, got %q", node.Value)
+			return
+		}
+	}
+	if err := schema.checkEnum(node.Value); err != nil {
+		c.report(node, path, "%v", err)
+	}
+}
+
+// checkEnum checks value against the values the schema allows
+func (s *Schema) checkEnum(value string) error {
+	if len(s.Enum) == 0 || slices.Contains(s.Enum, value) {
+		return nil
+	}
+	if noun, ok := enumNouns[s.enumName]; ok {
+		return fmt.Errorf("unknown %s %q (valid options: %s)", noun, value, strings.Join(s.Enum, ", "))
+	}
+	return fmt.Errorf("invalid value %q (valid options: %s)", value, strings.Join(s.Enum, ", "))
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	schema := GenerateSchema([]string{"no_mon", "two_mon"})
+
+	if schema.Schema != SchemaURI || schema.Type != "object" {
+		t.Errorf("Expected a root object schema, got %s %s", schema.Schema, schema.Type)
+	}
+
+	// Layout references and monitor roles are enums
+	if enum := schema.Properties["default_layout"].Enum; !reflect.DeepEqual(enum, []string{"no_mon", "two_mon"}) {
+		t.Errorf("Expected layout names for default_layout, got %v", enum)
+	}
+	layout := schema.Properties["layouts"].AdditionalProperties
+	if enum := layout.Properties["workspace_to_display"].AdditionalProperties.Enum; !reflect.DeepEqual(enum, []string{"primary_display", "left_display", "right_display"}) {
+		t.Errorf("Expected monitor roles for workspace_to_display, got %v", enum)
+	}
+
+	// Inline fields are merged into their parent
+	assignment := schema.Properties["assignments"].Items
+	for _, key := range []string{"match", "workspace", "output", "layouts"} {
+		if _, ok := assignment.Properties[key]; !ok {
+			t.Errorf("Expected assignment property %s", key)
+		}
+	}
+
+	// Rules may be written as plain strings
+	if rule := schema.Properties["window_overrides"].Items; len(rule.OneOf) != 2 || rule.OneOf[0].Type != "string" {
+		t.Errorf("Expected window rules to accept a string, got %+v", rule)
+	}
+
+	data, err := json.Marshal(schema.Properties["i3"])
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if !strings.Contains(string(data), `"additionalProperties":false`) {
+		t.Errorf("Expected closed objects to reject other properties, got %s", data)
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "valid",
+			content: `
+default_layout: two_mon
+layouts:
+  two_mon:
+    gaps_inner: 10
+    workspace_to_display:
+      3: right_display
+window_overrides:
+  - '[class="mpv"] floating enable'
+  - {match: {class: "^Pavucontrol$"}, floating: true}
+extra:
+  end_of_file: ["default_border pixel 1"]
+startup_programs:
+`,
+		},
+		{
+			name: "misspelled role",
+			content: `
+layouts:
+  two_mon:
+    workspace_to_display:
+      3: rigth_display
+`,
+			expected: `layouts.two_mon.workspace_to_display.3: unknown role "rigth_display"`,
+		},
+		{
+			name:     "unknown default layout",
+			content:  "default_layout: three_mon\nlayouts:\n  two_mon: {}\n",
+			expected: `default_layout: unknown layout "three_mon" (valid options: two_mon)`,
+		},
+		{
+			name:     "unknown layout in assignment",
+			content:  "layouts:\n  no_mon: {}\nassignments:\n  - match: {class: x}\n    layouts:\n      two_mon: {workspace: \"1\"}\n",
+			expected: `assignments[0].layouts.two_mon: unknown layout "two_mon"`,
+		},
+		{
+			name:     "wrong scalar type",
+			content:  "layouts:\n  two_mon:\n    gaps_inner: wide\n",
+			expected: `layouts.two_mon.gaps_inner: expected an integer, got "wide"`,
+		},
+		{
+			name:     "list instead of mapping",
+			content:  "i3:\n  - Mod4\n",
+			expected: "i3: expected a mapping, got sequence",
+		},
+		{
+			name:     "invalid rule object",
+			content:  "window_overrides:\n  - {match: {window_type: popup}, floating: true}\n",
+			expected: `window_overrides[0].match.window_type: invalid value "popup"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.content), &document); err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+			_, err := ValidateDocument(&document, "config.yaml", false)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFromFile_UnknownKeys(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := `i3:
+  mod_key: "Mod4"
+  fnot: "pango:monospace 8"
+layouts:
+  two_mon:
+    workspace_to_dispaly:
+      "1": left_display
+`
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// Every unknown key is reported with its position
+	_, err = NewLoader(tempDir).LoadFromFile(configPath)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	expected := []string{
+		configPath + `:3:3: i3.fnot: unknown key, did you mean "font"?`,
+		configPath + `:6:5: layouts.two_mon.workspace_to_dispaly: unknown key, did you mean "workspace_to_display"?`,
+	}
+	if len(validationErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), validationErr.Problems)
+	}
+	for i, problem := range validationErr.Problems {
+		if problem.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], problem.String())
+		}
+	}
+
+	// Lenient loading turns them into warnings
+	loader := NewLoader(tempDir)
+	loader.SetLenient(true)
+	config, err := loader.LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Expected lenient loading to succeed, got %v", err)
+	}
+	if !reflect.DeepEqual(config.Warnings(), expected) {
+		t.Errorf("Expected warnings %q, got %q", expected, config.Warnings())
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedPlaceholder replaces secret values in anything shown to the user
+const RedactedPlaceholder = "********"
+
+// minSecretLength is the shortest secret value Redact hides wherever it appears;
+// shorter values such as "1" or "on" are only hidden where they stand on their
+// own, as they would otherwise hide unrelated parts of the output
+const minSecretLength = 4
+
+// secretRefPattern matches secret references such as ${env:HOOK_TOKEN},
+// ${file:~/.secrets/hook} or ${cmd:pass show hook}; a leading $$ escapes them
+var secretRefPattern = regexp.MustCompile(`\$?\$\{(env|file|cmd):([^}]*)\}`)
+
+// resolveSecrets replaces secret references in every scalar value of the node tree
+// and returns the secret values that were substituted, along with warnings about
+// values too short to be redacted everywhere
+func resolveSecrets(node *yaml.Node) ([]string, []string, error) {
+	var secrets, warnings []string
+	var resolveErr error
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if resolveErr != nil {
+			return
+		}
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range n.Content {
+				walk(child)
+			}
+		case yaml.MappingNode:
+			// Only values may hold secrets, keys are left untouched
+			for i := 1; i < len(n.Content); i += 2 {
+				walk(n.Content[i])
+			}
+		case yaml.ScalarNode:
+			if !strings.Contains(n.Value, "${") {
+				return
+			}
+			n.Value = secretRefPattern.ReplaceAllStringFunc(n.Value, func(ref string) string {
+				if strings.HasPrefix(ref, "$$") {
+					return ref[1:]
+				}
+				match := secretRefPattern.FindStringSubmatch(ref)
+				value, err := resolveSecret(match[1], strings.TrimSpace(match[2]))
+				if err != nil {
+					if resolveErr == nil {
+						resolveErr = fmt.Errorf("line %d: failed to resolve %s: %w", n.Line, ref, err)
+					}
+					return ref
+				}
+				if value != "" {
+					secrets = append(secrets, value)
+				}
+				if value != "" && len(value) < minSecretLength {
+					warnings = append(warnings, fmt.Sprintf(
+						"line %d: %s resolves to fewer than %d characters, so it is only redacted where it stands on its own",
+						n.Line, ref, minSecretLength))
+				}
+				return value
+			})
+		}
+	}
+	walk(node)
+
+	if resolveErr != nil {
+		return nil, nil, resolveErr
+	}
+
+	return secrets, warnings, nil
+}
+
+// resolveSecret looks up a single secret of the given kind
+func resolveSecret(kind, arg string) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("empty %s reference", kind)
+	}
+
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", arg)
+		}
+		return value, nil
+	case "file":
+		path, err := expandHome(arg)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "cmd":
+		var stderr bytes.Buffer
+		cmd := exec.Command("sh", "-c", arg)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return "", fmt.Errorf("command failed: %w: %s", err, message)
+			}
+			return "", fmt.Errorf("command failed: %w", err)
+		}
+		return strings.TrimRight(string(output), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("unknown secret kind %q", kind)
+	}
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// HasSecrets reports whether any secret references were resolved while loading
+func (c *Config) HasSecrets() bool {
+	return len(c.secrets) > 0
+}
+
+// Redact replaces every resolved secret value in s with RedactedPlaceholder
+// Use it for anything derived from the configuration that is shown to the user
+func (c *Config) Redact(s string) string {
+	return redact(s, c.secrets)
+}
+
+// Redact replaces the secret values resolved by the last load in s with
+// RedactedPlaceholder; use it for errors of a load that failed
+func (l *Loader) Redact(s string) string {
+	return redact(s, l.secrets)
+}
+
+// redact hides every occurrence of the secrets in s, longest first, with a single
+// placeholder for secrets that are next to each other; secrets shorter than
+// minSecretLength are only hidden where they are not part of a longer word
+func redact(s string, secrets []string) string {
+	if len(secrets) == 0 {
+		return s
+	}
+	sorted := slices.Clone(secrets)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	// Mark the secret bytes in the original string, so that placeholders already
+	// written cannot match a short secret
+	hidden := make([]bool, len(s))
+	for _, secret := range sorted {
+		for start := 0; start < len(s); {
+			i := strings.Index(s[start:], secret)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(secret)
+			if len(secret) >= minSecretLength || standsAlone(s, i, end) {
+				for j := i; j < end; j++ {
+					hidden[j] = true
+				}
+			}
+			start = i + 1
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case !hidden[i]:
+			b.WriteByte(s[i])
+		case i == 0 || !hidden[i-1]:
+			b.WriteString(RedactedPlaceholder)
+		}
+	}
+	return b.String()
+}
+
+// standsAlone reports whether s[start:end] is not part of a longer word or number
+func standsAlone(s string, start, end int) bool {
+	return !(isWordByte(s[start]) && start > 0 && isWordByte(s[start-1])) &&
+		!(isWordByte(s[end-1]) && end < len(s) && isWordByte(s[end]))
+}
+
+// isWordByte reports whether c can be part of a word or number
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
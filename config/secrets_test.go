@@ -0,0 +1,235 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "secrets_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	secretFile := filepath.Join(tempDir, "hook")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+	t.Setenv("I3CG_TEST_TOKEN", "env-secret")
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		secrets  int
+		warnings int
+		wantErr  bool
+		errorMsg string
+	}{
+		{
+			name:     "plain value",
+			value:    "curl -u user:pass",
+			expected: "curl -u user:pass",
+		},
+		{
+			name:     "environment variable",
+			value:    "curl -u \"hook:${env:I3CG_TEST_TOKEN}\"",
+			expected: "curl -u \"hook:env-secret\"",
+			secrets:  1,
+		},
+		{
+			name:     "file",
+			value:    "token=${file:" + secretFile + "}",
+			expected: "token=file-secret",
+			secrets:  1,
+		},
+		{
+			name:     "command",
+			value:    "${cmd:echo cmd-secret}",
+			expected: "cmd-secret",
+			secrets:  1,
+		},
+		{
+			name:     "escaped reference",
+			value:    "$${env:I3CG_TEST_TOKEN}",
+			expected: "${env:I3CG_TEST_TOKEN}",
+		},
+		{
+			name:     "i3 variables are left alone",
+			value:    "$mod+Return",
+			expected: "$mod+Return",
+		},
+		{
+			name:     "too short to redact everywhere",
+			value:    "numlock ${cmd:echo on}",
+			expected: "numlock on",
+			secrets:  1,
+			warnings: 1,
+		},
+		{
+			name:     "command error output",
+			value:    "${cmd:echo no such entry >&2; exit 1}",
+			wantErr:  true,
+			errorMsg: "command failed: exit status 1: no such entry",
+		},
+		{
+			name:    "missing environment variable",
+			value:   "${env:I3CG_TEST_UNSET_VARIABLE}",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			value:   "${file:" + filepath.Join(tempDir, "missing") + "}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "key"},
+				{Kind: yaml.ScalarNode, Value: tt.value},
+			}}
+
+			secrets, warnings, err := resolveSecrets(node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if tt.errorMsg != "" && !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+
+			if node.Content[1].Value != tt.expected {
+				t.Errorf("Expected value %q, got %q", tt.expected, node.Content[1].Value)
+			}
+			if len(secrets) != tt.secrets {
+				t.Errorf("Expected %d secrets, got %d", tt.secrets, len(secrets))
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %q", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadFromFile_Secrets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	t.Setenv("I3CG_TEST_TOKEN", "s3cr3t")
+
+	configContent := `
+i3:
+  mod_key: "Mod4"
+hotkeys:
+  - key: "$mod+XF86Launch7"
+    command: "curl -u \"webhook:${env:I3CG_TEST_TOKEN}\" https://example.com/hook"
+`
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := NewLoader(tempDir).LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if !config.HasSecrets() {
+		t.Error("Expected config to report secrets")
+	}
+
+	command := config.Hotkeys[0].Command
+	if !strings.Contains(command, "webhook:s3cr3t") {
+		t.Errorf("Expected secret to be resolved, got %q", command)
+	}
+
+	redacted := config.Redact(command)
+	if strings.Contains(redacted, "s3cr3t") {
+		t.Errorf("Expected secret to be redacted, got %q", redacted)
+	}
+	if !strings.Contains(redacted, "webhook:"+RedactedPlaceholder) {
+		t.Errorf("Expected redacted placeholder, got %q", redacted)
+	}
+}
+
+func TestConfig_Redact(t *testing.T) {
+	config := &Config{secrets: []string{"hunter2", "s3cr3t-token", "on", "s3cr3t"}}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"curl -u \"webhook:s3cr3t-token\"", "curl -u \"webhook:" + RedactedPlaceholder + "\""},
+		{"hunter2 and hunter2", RedactedPlaceholder + " and " + RedactedPlaceholder},
+		// Secrets are hidden wherever they appear, including next to other text
+		{"Bearerhunter2 /hunter2abc", "Bearer" + RedactedPlaceholder + " /" + RedactedPlaceholder + "abc"},
+		{"hunter2s3cr3t-token", RedactedPlaceholder},
+		// Short secrets are only hidden where they stand on their own
+		{"numlock on, font mono", "numlock " + RedactedPlaceholder + ", font mono"},
+	}
+
+	for _, tt := range tests {
+		if redacted := config.Redact(tt.input); redacted != tt.expected {
+			t.Errorf("Redact(%q): expected %q, got %q", tt.input, tt.expected, redacted)
+		}
+	}
+}
+
+func TestLoader_Redact(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	t.Setenv("I3CG_TEST_TOKEN", "s3cretTOKEN")
+	t.Setenv("I3CG_TEST_SHORT", "ab")
+
+	configContent := `
+i3:
+  mod_key: "Mod4"
+window_overrides:
+  - "[class=\"${env:I3CG_TEST_TOKEN}(\"] floating enable"
+startup_programs:
+  - "xset ${env:I3CG_TEST_SHORT}"
+`
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// Validation errors quote the resolved value, so they are redacted before being shown
+	loader := NewLoader(tempDir)
+	_, err = loader.LoadFromFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), "s3cretTOKEN") {
+		t.Fatalf("Expected validation error quoting the secret, got %v", err)
+	}
+	redacted := loader.Redact(err.Error())
+	if strings.Contains(redacted, "s3cretTOKEN") || !strings.Contains(redacted, RedactedPlaceholder+"(") {
+		t.Errorf("Expected secret to be redacted, got %q", redacted)
+	}
+
+	// Short secrets still count as secrets, so that the output file is kept private
+	if err := os.WriteFile(configPath, []byte("i3:\n  mod_key: \"Mod4\"\nstartup_programs:\n  - \"xset ${env:I3CG_TEST_SHORT}\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := loader.LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !config.HasSecrets() {
+		t.Error("Expected config with a short secret to report secrets")
+	}
+}
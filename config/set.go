@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of environment variables that set configuration
+// values; "__" separates the keys of the path, so I3CG_LAYOUTS__TWO_MON__GAPS_INNER
+// sets layouts.two_mon.gaps_inner
+const EnvPrefix = "I3CG_"
+
+// Override sets a single configuration value from outside the configuration
+// files, overriding what they say
+type Override struct {
+	// Source names the override in messages, like --set or I3CG_TERMINAL
+	Source string
+	// Path is the dot-separated key path, such as layouts.two_mon.gaps_inner
+	Path string
+	// Value is read as YAML, so numbers, booleans and flow sequences keep their
+	// type; an empty value resets the setting to its default
+	Value string
+}
+
+// ParseOverride parses a --set argument of the form key.path=value; an "=" within
+// [criteria] belongs to the path, so window rule selectors can be used as keys
+func ParseOverride(arg string) (Override, error) {
+	path, value, ok := arg, "", false
+	depth := 0
+	for i, r := range arg {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case r == '=' && depth == 0:
+			path, value, ok = arg[:i], arg[i+1:], true
+		}
+		if ok {
+			break
+		}
+	}
+	path = strings.TrimSpace(path)
+	if !ok || path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+		return Override{}, fmt.Errorf("invalid --set argument %q (expected key.path=value)", arg)
+	}
+	return Override{Source: "--set", Path: path, Value: value}, nil
+}
+
+// EnvOverrides returns the overrides made by I3CG_ variables in environ,
+// which holds KEY=value entries like os.Environ, ordered by name
+func EnvOverrides(environ []string) []Override {
+	var overrides []Override
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		key, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok || key == "" {
+			continue
+		}
+		overrides = append(overrides, Override{
+			Source: name,
+			Path:   strings.ToLower(strings.ReplaceAll(key, "__", ".")),
+			Value:  value,
+		})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Source < overrides[j].Source
+	})
+	return overrides
+}
+
+// SetOverrides makes the loader apply overrides, in order, on top of the
+// configuration files
+func (l *Loader) SetOverrides(overrides []Override) {
+	l.overrides = overrides
+}
+
+// overrideLayer builds a layer holding a single override, with its key
+// path resolved against the layers below it
+func overrideLayer(override Override, below []*configLayer) (*configLayer, error) {
+	value, err := overrideValue(override.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid value for %s: %w", override.Source, override.Path, err)
+	}
+
+	roots := make([]*yaml.Node, 0, len(below))
+	for _, layer := range below {
+		roots = append(roots, documentRoot(layer.document))
+	}
+	keys := resolveKeyPath(override.Path, roots, GenerateSchema(nil))
+
+	// Wrap the value in one mapping per key, innermost first
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Tag:     "!!map",
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[i]}, value},
+		}
+	}
+	document := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{value}}
+
+	secrets, warnings, err := resolveSecrets(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets in %s: %w", override.Source, err)
+	}
+	layer := &configLayer{name: override.Source, document: document, secrets: secrets}
+	for _, warning := range warnings {
+		layer.warnings = append(layer.warnings, fmt.Sprintf("%s: %s", override.Source, warning))
+	}
+	return layer, nil
+}
+
+// overrideValue reads the value of an override as YAML, keeping plain strings as
+// given so that colors like #285577 are not taken for comments
+func overrideValue(value string) (*yaml.Node, error) {
+	if strings.TrimSpace(value) == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil || len(document.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+	node := document.Content[0]
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+	if node.Kind == yaml.AliasNode {
+		return nil, fmt.Errorf("aliases are not supported")
+	}
+	clearPositions(node)
+	return node, nil
+}
+
+// clearPositions removes the line and column of every node below node, as they
+// refer to the value rather than to a file
+func clearPositions(node *yaml.Node) {
+	node.Line, node.Column = 0, 0
+	for _, child := range node.Content {
+		clearPositions(child)
+	}
+}
+
+// resolveKeyPath splits a dot-separated path into mapping keys, preferring the
+// longest key already present in the layers, or known to the schema, so keys
+// containing dots, such as window rule selectors, can still be addressed
+// Keys match regardless of case, as environment variable names are read in lower
+// case; the case of the layers, and then of the schema, is kept
+func resolveKeyPath(path string, roots []*yaml.Node, schema *Schema) []string {
+	var keys []string
+	for path != "" {
+		key, _, _ := strings.Cut(path, ".")
+		var candidates []string
+		for name := range schema.objectSchema().Properties {
+			candidates = append(candidates, name)
+		}
+		for _, root := range roots {
+			if root == nil || root.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				candidates = append(candidates, root.Content[i].Value)
+			}
+		}
+		for _, candidate := range candidates {
+			if len(candidate) < len(key) || len(candidate) > len(path) || candidate == key {
+				continue
+			}
+			if strings.EqualFold(path[:len(candidate)], candidate) && (len(candidate) == len(path) || path[len(candidate)] == '.') {
+				key = candidate
+			}
+		}
+		keys = append(keys, key)
+		path = strings.TrimPrefix(path[len(key):], ".")
+
+		next := make([]*yaml.Node, 0, len(roots))
+		for _, root := range roots {
+			if value := mappingValue(root, key); value != nil {
+				next = append(next, resolveAlias(value))
+			}
+		}
+		roots = next
+		schema = schema.objectSchema().propertySchema(key)
+	}
+	return keys
+}
+
+// objectSchema returns the object form of a schema that may also be written as a
+// string, or the schema itself
+func (s *Schema) objectSchema() *Schema {
+	if s == nil {
+		return nil
+	}
+	for _, form := range s.OneOf {
+		if form.Type == "object" {
+			return form
+		}
+	}
+	return s
+}
+
+// propertySchema returns the schema of the value stored under key in an object,
+// or nil when the schema does not describe one
+func (s *Schema) propertySchema(key string) *Schema {
+	if s == nil {
+		return nil
+	}
+	if property, ok := s.Properties[key]; ok {
+		return property
+	}
+	return s.AdditionalProperties
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		arg      string
+		path     string
+		value    string
+		errorMsg string
+	}{
+		{arg: "terminal=xterm", path: "terminal", value: "xterm"},
+		{arg: "layouts.two_mon.gaps_inner=20", path: "layouts.two_mon.gaps_inner", value: "20"},
+		{arg: "i3.font=pango:Mono 8", path: "i3.font", value: "pango:Mono 8"},
+		{arg: "terminal=", path: "terminal", value: ""},
+		{arg: "launcher.command=rofi -show=drun", path: "launcher.command", value: "rofi -show=drun"},
+		{arg: `application_bindings.[class="Firefox"]=2`, path: `application_bindings.[class="Firefox"]`, value: "2"},
+		{arg: "terminal", errorMsg: `invalid --set argument "terminal"`},
+		{arg: "=xterm", errorMsg: "expected key.path=value"},
+		{arg: "layouts..gaps_inner=2", errorMsg: "expected key.path=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			override, err := ParseOverride(tt.arg)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if override.Source != "--set" || override.Path != tt.path || override.Value != tt.value {
+				t.Errorf("Expected --set %s=%s, got %+v", tt.path, tt.value, override)
+			}
+		})
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"I3CG_TERMINAL=xterm",
+		"I3CG_LAYOUTS__TWO_MON__GAPS_INNER=20",
+		"I3CG_=ignored",
+		"I3CG_COLORS__BASE0A=#000000",
+	}
+
+	expected := []Override{
+		{Source: "I3CG_COLORS__BASE0A", Path: "colors.base0a", Value: "#000000"},
+		{Source: "I3CG_LAYOUTS__TWO_MON__GAPS_INNER", Path: "layouts.two_mon.gaps_inner", Value: "20"},
+		{Source: "I3CG_TERMINAL", Path: "terminal", Value: "xterm"},
+	}
+	if overrides := EnvOverrides(environ); !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, overrides)
+	}
+}
+
+func TestResolveKeyPath(t *testing.T) {
+	var document yaml.Node
+	content := `application_bindings:
+  "[class=\"^Firefox.*$\"]": "1"
+`
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	roots := []*yaml.Node{documentRoot(&document)}
+	schema := GenerateSchema(nil)
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		// Keys that are not in the document take their case from the schema
+		{"colors.base0a", []string{"colors", "base0A"}},
+		{"layouts.two_mon.gaps_inner", []string{"layouts", "two_mon", "gaps_inner"}},
+		{`application_bindings.[class="^firefox.*$"]`, []string{"application_bindings", `[class="^Firefox.*$"]`}},
+		{"i3.unknown", []string{"i3", "unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if keys := resolveKeyPath(tt.path, roots, schema); !reflect.DeepEqual(keys, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestLoader_SetOverrides(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	configContent := `i3:
+  mod_key: "Mod4"
+terminal: "alacritty"
+startup_programs:
+  - "nm-applet"
+colors:
+  base0A: "#FAC863"
+layouts:
+  two_mon:
+    description: "Two monitors"
+    gaps_inner: 10
+application_bindings:
+  "[class=\"^Firefox.*$\"]": "1"
+  "[class=\"^Slack$\"]": "2"
+`
+	configPath := filepath.Join(tempDir, ConfigFileYAML)
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	parse := func(args ...string) []Override {
+		var overrides []Override
+		for _, arg := range args {
+			override, err := ParseOverride(arg)
+			if err != nil {
+				t.Fatalf("Failed to parse override: %v", err)
+			}
+			overrides = append(overrides, override)
+		}
+		return overrides
+	}
+
+	loader := NewLoader(tempDir)
+	overrides := EnvOverrides([]string{
+		"I3CG_LAYOUTS__TWO_MON__GAPS_INNER=15",
+		"I3CG_COLORS__BASE0A=#000000",
+		"I3CG_COLORS__BASE0B=#111111",
+		"I3CG_TERMINAL=xterm",
+	})
+	// Later overrides win, so --set beats the environment
+	loader.SetOverrides(append(overrides, parse(
+		"layouts.two_mon.gaps_inner=20",
+		"i3.font=pango:Mono 8",
+		"startup_programs=[dunst, picom --daemon]",
+		"terminal=",
+		`application_bindings.[class="^Firefox.*$"]=3`,
+	)...))
+
+	cfg, err := loader.LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	layout := cfg.Layouts["two_mon"]
+	if layout.GapsInner != 20 || layout.Description != "Two monitors" {
+		t.Errorf("Expected two_mon with gaps_inner 20 and its description kept, got %+v", layout)
+	}
+	if cfg.I3.Font != "pango:Mono 8" || cfg.I3.ModKey != "Mod4" {
+		t.Errorf("Expected the font set and the mod key kept, got %+v", cfg.I3)
+	}
+	if cfg.Colors.Base0A != "#000000" {
+		t.Errorf("Expected base0A #000000, got %q", cfg.Colors.Base0A)
+	}
+	// Keys missing from the file are matched against the schema
+	if cfg.Colors.Base0B != "#111111" {
+		t.Errorf("Expected base0B #111111, got %q", cfg.Colors.Base0B)
+	}
+	if !reflect.DeepEqual(cfg.StartupPrograms, []string{"dunst", "picom --daemon"}) {
+		t.Errorf("Expected startup programs to be replaced, got %q", cfg.StartupPrograms)
+	}
+	if cfg.Terminal != DefaultTerminal {
+		t.Errorf("Expected an empty value to restore the default terminal, got %s", cfg.Terminal)
+	}
+	expectedBindings := []string{`[class="^Firefox.*$"]`, `[class="^Slack$"]`}
+	if keys := cfg.ApplicationBindings.Keys(); !reflect.DeepEqual(keys, expectedBindings) {
+		t.Errorf("Expected bindings %q, got %q", expectedBindings, keys)
+	}
+	if workspace, _ := cfg.ApplicationBindings.Get(`[class="^Firefox.*$"]`); workspace != "3" {
+		t.Errorf("Expected Firefox on workspace 3, got %s", workspace)
+	}
+
+	// Problems name the override they came from
+	tests := []struct {
+		override string
+		errorMsg string
+	}{
+		{"layouts.two_mon.gaps_inner=-5", "--set: layouts.two_mon.gaps_inner: must not be negative"},
+		{"i3.fnt=pango:Mono 8", `--set: i3.fnt: unknown key, did you mean "font"?`},
+		{"startup_programs=nm-applet", "--set: startup_programs: expected a list"},
+	}
+	for _, tt := range tests {
+		loader.SetOverrides(parse(tt.override))
+		_, err := loader.LoadFromFile(configPath)
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.override, tt.errorMsg, err)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed starter.yaml
+var starterConfig []byte
+
+// starterWorkspaces is the number of workspaces distributed over the displays of a generated layout
+const starterWorkspaces = 10
+
+// StarterOptions customises the starter configuration written by init
+type StarterOptions struct {
+	// Display is the X display the monitors were detected on
+	Display string
+	// Monitors are the connected monitors without dummy padding; nil keeps the generic layouts
+	Monitors *monitor.DetectedMonitors
+	// Imported holds settings taken from an existing i3 config, if any
+	Imported *ImportedSettings
+}
+
+// StarterConfig returns the contents of the generic starter configuration file
+func StarterConfig() []byte {
+	return starterConfig
+}
+
+// BuildStarterConfig returns a starter configuration with layouts for the detected
+// monitors and any imported settings applied, keeping the comments of the generic starter
+func BuildStarterConfig(opts StarterOptions) ([]byte, error) {
+	if opts.Monitors == nil && opts.Imported == nil {
+		return starterConfig, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(starterConfig, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse starter config: %w", err)
+	}
+	root := documentRoot(&document)
+
+	if opts.Display != "" {
+		setMappingValue(ensureMapping(root, "monitor_detection"), "display", stringNode(opts.Display))
+	}
+
+	if opts.Monitors != nil && len(opts.Monitors.All) > 0 {
+		layouts, defaultLayout := starterLayouts(opts.Monitors)
+		setMappingValue(root, "layouts", layouts)
+		setMappingValue(root, "default_layout", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: defaultLayout})
+	}
+
+	if opts.Imported != nil {
+		if err := applyImportedSettings(root, opts.Imported); err != nil {
+			return nil, fmt.Errorf("failed to apply imported settings: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to encode starter config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode starter config: %w", err)
+	}
+	return spaceSections(buf.Bytes()), nil
+}
+
+// spaceSections restores the blank line before each top-level comment, which the
+// YAML encoder drops when re-encoding the starter config
+func spaceSections(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if i > 0 && strings.HasPrefix(line, "#") && lines[i-1] != "" && !strings.HasPrefix(lines[i-1], "#") {
+			result = append(result, "")
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n"))
+}
+
+// starterLayouts builds one layout per number of connected displays up to what is
+// currently connected, and returns the name of the layout matching the current setup
+func starterLayouts(monitors *monitor.DetectedMonitors) (*yaml.Node, string) {
+	// Roles are listed left to right as they are usually arranged on a desk
+	candidates := []struct {
+		name  string
+		roles []string
+	}{
+		{"no_mon", []string{"primary_display"}},
+		{"one_mon", []string{"left_display", "primary_display"}},
+		{"two_mon", []string{"left_display", "primary_display", "right_display"}},
+	}
+
+	layouts := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	defaultLayout := candidates[0].name
+	for i, candidate := range candidates {
+		if i >= len(monitors.All) {
+			break
+		}
+		defaultLayout = candidate.name
+
+		var outputs []string
+		for _, role := range candidate.roles {
+			// The candidate roles are all known roles
+			name, _ := monitors.GetMonitorByRole(role)
+			outputs = append(outputs, fmt.Sprintf("%s (%s)", name, strings.TrimSuffix(role, "_display")))
+		}
+
+		layout := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(layout, "description", stringNode(strings.Join(outputs, " + ")))
+		setMappingValue(layout, "gaps_inner", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "10"})
+		setMappingValue(layout, "gaps_outer", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"})
+
+		moveWorkspace := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		workspaceToDisplay := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if len(candidate.roles) > 1 {
+			for n, role := range candidate.roles {
+				setMappingValue(moveWorkspace, fmt.Sprintf("Ctrl+Shift+%d", n+1), stringNode(role))
+			}
+			for workspace := 1; workspace <= starterWorkspaces; workspace++ {
+				// Spread the workspaces in contiguous blocks, earlier displays taking the remainder
+				role := candidate.roles[(workspace-1)*len(candidate.roles)/starterWorkspaces]
+				setMappingValue(workspaceToDisplay, strconv.Itoa(workspace), stringNode(role))
+			}
+		}
+		setMappingValue(layout, "move_workspace", moveWorkspace)
+		setMappingValue(layout, "workspace_to_display", workspaceToDisplay)
+
+		// Keys are quoted so workspace numbers stay strings
+		for _, mapping := range []*yaml.Node{moveWorkspace, workspaceToDisplay} {
+			for k := 0; k < len(mapping.Content); k += 2 {
+				mapping.Content[k].Style = yaml.DoubleQuotedStyle
+			}
+		}
+
+		setMappingValue(layouts, candidate.name, layout)
+	}
+
+	return layouts, defaultLayout
+}
+
+// applyImportedSettings stores the settings imported from an i3 config in the
+// starter document, leaving the starter values where nothing was imported
+func applyImportedSettings(root *yaml.Node, imported *ImportedSettings) error {
+	setStringFields(root, "i3",
+		"mod_key", imported.ModKey,
+		"font", imported.Font,
+		"bar_font", imported.BarFont)
+	if imported.Terminal != "" {
+		setMappingValue(root, "terminal", stringNode(imported.Terminal))
+	}
+	setStringFields(root, "keyboard",
+		"layout", imported.Keyboard.Layout,
+		"variant", imported.Keyboard.Variant,
+		"options", imported.Keyboard.Options)
+	if imported.Launcher.Command != "" {
+		setStringFields(root, "launcher", "key", imported.Launcher.Key, "command", imported.Launcher.Command)
+	}
+	if imported.Locker.Command != "" {
+		setStringFields(root, "locker", "key", imported.Locker.Key, "command", imported.Locker.Command)
+	}
+	setStringFields(root, "bar",
+		"status_command", imported.Bar.StatusCommand,
+		"position", imported.Bar.Position)
+	if imported.Bar.Disabled {
+		setMappingValue(ensureMapping(root, "bar"), "disabled", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	setStringFields(root, "media_keys",
+		"volume_down", imported.MediaKeys.VolumeDown,
+		"volume_up", imported.MediaKeys.VolumeUp,
+		"volume_mute", imported.MediaKeys.VolumeMute,
+		"play_pause", imported.MediaKeys.PlayPause,
+		"next", imported.MediaKeys.Next,
+		"previous", imported.MediaKeys.Previous)
+	setStringFields(root, "backlight",
+		"down", imported.Backlight.Down,
+		"up", imported.Backlight.Up,
+		"down_large", imported.Backlight.DownLarge,
+		"up_large", imported.Backlight.UpLarge)
+
+	if len(imported.ApplicationBindings) > 0 {
+		bindings := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, entry := range imported.ApplicationBindings {
+			setMappingValue(bindings, entry.Key, stringNode(entry.Value))
+			bindings.Content[len(bindings.Content)-2].Style = yaml.SingleQuotedStyle
+		}
+		setMappingValue(root, "application_bindings", bindings)
+	}
+	if len(imported.Assignments) > 0 {
+		assignments, err := encodeNode(imported.Assignments)
+		if err != nil {
+			return err
+		}
+		setMappingValue(root, "assignments", assignments)
+	}
+	if len(imported.StartupPrograms) > 0 {
+		setMappingValue(root, "startup_programs", stringSequenceNode(imported.StartupPrograms))
+	}
+	if len(imported.WindowOverrides) > 0 {
+		rules, err := encodeNode(imported.WindowOverrides)
+		if err != nil {
+			return err
+		}
+		setMappingValue(root, "window_overrides", rules)
+	}
+
+	if len(imported.Hotkeys) > 0 {
+		hotkeys := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, hotkey := range imported.Hotkeys {
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(node, "key", stringNode(hotkey.Key))
+			setMappingValue(node, "command", stringNode(hotkey.Command))
+			if hotkey.NoStartupID {
+				setMappingValue(node, "no_startup_id", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+			}
+			hotkeys.Content = append(hotkeys.Content, node)
+		}
+		setMappingValue(root, "hotkeys", hotkeys)
+	}
+
+	// Gaps and placements replace those of the layout used by default
+	if layout := defaultLayoutNode(root); layout != nil {
+		if imported.GapsInner != nil {
+			setMappingValue(layout, "gaps_inner", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(*imported.GapsInner)})
+		}
+		if imported.GapsOuter != nil {
+			setMappingValue(layout, "gaps_outer", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(*imported.GapsOuter)})
+		}
+		if len(imported.WorkspaceDisplays) > 0 {
+			workspaceToDisplay := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for _, entry := range imported.WorkspaceDisplays {
+				setMappingValue(workspaceToDisplay, entry.Key, stringNode(entry.Value))
+			}
+			// Keys are quoted so workspace numbers stay strings
+			for k := 0; k < len(workspaceToDisplay.Content); k += 2 {
+				workspaceToDisplay.Content[k].Style = yaml.DoubleQuotedStyle
+			}
+			setMappingValue(layout, "workspace_to_display", workspaceToDisplay)
+		}
+	}
+
+	if snippets := imported.PassthroughSnippets(); len(snippets) > 0 {
+		endOfFile := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, snippet := range snippets {
+			node := stringNode(snippet)
+			if strings.Contains(snippet, "\n") {
+				node.Style = yaml.LiteralStyle
+			}
+			endOfFile.Content = append(endOfFile.Content, node)
+		}
+		setMappingValue(ensureMapping(root, "extra"), "end_of_file", endOfFile)
+	}
+
+	return nil
+}
+
+// defaultLayoutNode returns the mapping of the layout named by default_layout, if any
+func defaultLayoutNode(root *yaml.Node) *yaml.Node {
+	defaultLayout := mappingValue(root, "default_layout")
+	layouts := mappingValue(root, "layouts")
+	if defaultLayout == nil || layouts == nil {
+		return nil
+	}
+	if layout := mappingValue(layouts, defaultLayout.Value); layout != nil && layout.Kind == yaml.MappingNode {
+		return layout
+	}
+	return nil
+}
+
+// setStringFields stores the non-empty fields, given as name/value pairs, in the
+// mapping under key; the mapping is only created when there is something to store
+func setStringFields(root *yaml.Node, key string, fields ...string) {
+	var mapping *yaml.Node
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			continue
+		}
+		if mapping == nil {
+			mapping = ensureMapping(root, key)
+		}
+		setMappingValue(mapping, fields[i], stringNode(fields[i+1]))
+	}
+}
+
+// WriteStarterConfig writes content to path, refusing to replace an existing
+// file unless force is set
+func WriteStarterConfig(path string, content []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("configuration file %s already exists (use --force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory %s: %w", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+)
+
+func TestWriteStarterConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "nested", ConfigFileYAML)
+
+	// Test: Starter config is written and loads cleanly
+	if err := WriteStarterConfig(configPath, StarterConfig(), false); err != nil {
+		t.Fatalf("Failed to write starter config: %v", err)
+	}
+
+	config, err := NewLoader(filepath.Dir(configPath)).Load()
+	if err != nil {
+		t.Fatalf("Starter config does not load: %v", err)
+	}
+	for _, name := range []string{"two_mon", "one_mon", "no_mon"} {
+		if _, err := config.GetLayout(name); err != nil {
+			t.Errorf("Expected starter config to define layout %s: %v", name, err)
+		}
+	}
+
+	// Test: Existing files are not replaced without force
+	if err := os.WriteFile(configPath, []byte("custom"), 0644); err != nil {
+		t.Fatalf("Failed to overwrite config: %v", err)
+	}
+	if err := WriteStarterConfig(configPath, StarterConfig(), false); err == nil {
+		t.Error("Expected error when config already exists")
+	}
+
+	// Test: Force replaces the file
+	if err := WriteStarterConfig(configPath, StarterConfig(), true); err != nil {
+		t.Fatalf("Failed to force-write starter config: %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if string(data) != string(StarterConfig()) {
+		t.Error("Expected forced write to replace the file contents")
+	}
+}
+
+func TestBuildStarterConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		monitors        []string
+		expectedLayouts []string
+		expectedDefault string
+	}{
+		{"laptop only", []string{"eDP-1"}, []string{"no_mon"}, "no_mon"},
+		{"one external", []string{"eDP-1", "HDMI-1"}, []string{"no_mon", "one_mon"}, "one_mon"},
+		{"two external", []string{"eDP-1", "HDMI-1", "DP-1"}, []string{"no_mon", "one_mon", "two_mon"}, "two_mon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitors := &monitor.DetectedMonitors{Primary: tt.monitors[0], All: tt.monitors}
+			if len(tt.monitors) >= 2 {
+				monitors.Left = tt.monitors[1]
+			}
+			if len(tt.monitors) >= 3 {
+				monitors.Right = tt.monitors[2]
+			}
+
+			content, err := BuildStarterConfig(StarterOptions{Display: ":1", Monitors: monitors})
+			if err != nil {
+				t.Fatalf("Failed to build starter config: %v", err)
+			}
+			config := loadStarter(t, content)
+
+			if !reflect.DeepEqual(config.LayoutNames(), tt.expectedLayouts) {
+				t.Errorf("Expected layouts %v, got %v", tt.expectedLayouts, config.LayoutNames())
+			}
+			if config.DefaultLayout != tt.expectedDefault {
+				t.Errorf("Expected default layout %s, got %s", tt.expectedDefault, config.DefaultLayout)
+			}
+			if config.MonitorDetection.Display != ":1" {
+				t.Errorf("Expected display :1, got %s", config.MonitorDetection.Display)
+			}
+
+			layout, _ := config.GetLayout(tt.expectedDefault)
+			if len(tt.monitors) > 1 && len(layout.WorkspaceToDisplay) != starterWorkspaces {
+				t.Errorf("Expected %d workspace assignments, got %d", starterWorkspaces, len(layout.WorkspaceToDisplay))
+			}
+			if !strings.Contains(layout.Description, tt.monitors[0]+" (primary)") {
+				t.Errorf("Expected description to name the primary output, got %s", layout.Description)
+			}
+			// Comments from the generic starter survive
+			if !strings.Contains(string(content), "# Color scheme") {
+				t.Error("Expected starter comments to be preserved")
+			}
+		})
+	}
+
+	// Test: Workspaces are split into contiguous blocks
+	content, err := BuildStarterConfig(StarterOptions{Monitors: &monitor.DetectedMonitors{
+		Primary: "eDP-1", Left: "HDMI-1", Right: "DP-1", All: []string{"eDP-1", "HDMI-1", "DP-1"},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build starter config: %v", err)
+	}
+	layout, _ := loadStarter(t, content).GetLayout("two_mon")
+	expected := map[string]string{"1": "left_display", "4": "left_display", "5": "primary_display", "8": "right_display", "10": "right_display"}
+	for workspace, role := range expected {
+		if got, _ := layout.WorkspaceToDisplay.Get(workspace); got != role {
+			t.Errorf("Expected workspace %s on %s, got %s", workspace, role, got)
+		}
+	}
+
+	// Test: Without detection or import the generic starter is returned unchanged
+	content, err = BuildStarterConfig(StarterOptions{})
+	if err != nil {
+		t.Fatalf("Failed to build starter config: %v", err)
+	}
+	if string(content) != string(StarterConfig()) {
+		t.Error("Expected generic starter config")
+	}
+}
+
+// loadStarter writes a starter config to a temp dir and loads it
+func loadStarter(t *testing.T, content []byte) *Config {
+	t.Helper()
+	tempDir, err := os.MkdirTemp("", "config_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := WriteStarterConfig(filepath.Join(tempDir, ConfigFileYAML), content, false); err != nil {
+		t.Fatalf("Failed to write starter config: %v", err)
+	}
+	config, err := NewLoader(tempDir).Load()
+	if err != nil {
+		t.Fatalf("Starter config does not load: %v\n%s", err, content)
+	}
+	return config
+}
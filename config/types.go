@@ -2,27 +2,124 @@ package config
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
 	"github.com/a7d-corp/i3-config-generator-go/monitor"
 )
 
+const (
+	// OrderingDocument keeps map entries in YAML document order
+	OrderingDocument = "document"
+	// OrderingNatural sorts map entries by key using natural sort order
+	OrderingNatural = "natural"
+)
+
 type Config struct {
+	// Version is the configuration format version, see CurrentVersion
+	Version             int                     `yaml:"version"`
 	I3                  I3Config                `yaml:"i3"`
-	UseDetectedMonitors bool                    `yaml:"use_detected_monitors"`
+	DefaultLayout       string                  `yaml:"default_layout" schema:"layout"`
 	MonitorDetection    MonitorConfig           `yaml:"monitor_detection"`
 	Layouts             map[string]LayoutConfig `yaml:"layouts"`
-	ApplicationBindings map[string]string       `yaml:"application_bindings"`
+	ApplicationBindings OrderedMap              `yaml:"application_bindings"`
+	Assignments         []Assignment            `yaml:"assignments"`
 	StartupPrograms     []string                `yaml:"startup_programs"`
-	WindowOverrides     []string                `yaml:"window_overrides"`
+	WindowOverrides     []WindowRule            `yaml:"window_overrides"`
 	Colors              ColorConfig             `yaml:"colors"`
+	Keyboard            KeyboardConfig          `yaml:"keyboard"`
+	Terminal            string                  `yaml:"terminal"`
+	Launcher            LauncherConfig          `yaml:"launcher"`
+	Locker              LockerConfig            `yaml:"locker"`
+	Bar                 BarConfig               `yaml:"bar"`
+	MediaKeys           MediaKeysConfig         `yaml:"media_keys"`
+	Backlight           BacklightConfig         `yaml:"backlight"`
+	Hotkeys             []HotkeyConfig          `yaml:"hotkeys"`
+	Extra               ExtraConfig             `yaml:"extra"`
+	Scaling             ScalingConfig           `yaml:"scaling"`
+	Workspaces          WorkspacesConfig        `yaml:"workspaces"`
+	GapKeys             GapKeysConfig           `yaml:"gap_keys"`
+
+	// Ordering controls how map-driven sections are ordered in the output:
+	// "document" (default) keeps YAML order, "natural" sorts keys naturally
+	Ordering string `yaml:"ordering" schema:"ordering"`
+
+	// secrets holds the values substituted for secret references while loading
+	secrets []string
+	// warnings holds the problems that were tolerated while loading
+	warnings []string
 }
 
 type I3Config struct {
 	ModKey  string `yaml:"mod_key"`
+	Font    string `yaml:"font"`
 	BarFont string `yaml:"bar_font"`
+	// BorderWidth is the window border in pixels; DefaultBorderWidth when not set
+	BorderWidth *int `yaml:"border_width"`
+}
+
+// KeyboardConfig holds the X keyboard layout applied with setxkbmap
+type KeyboardConfig struct {
+	Layout  string `yaml:"layout"`
+	Variant string `yaml:"variant"`
+	Options string `yaml:"options"`
+}
+
+// LauncherConfig holds the application launcher keybinding
+type LauncherConfig struct {
+	Key     string `yaml:"key"`
+	Command string `yaml:"command"`
+}
+
+// LockerConfig holds the screen locker command and its keybinding
+type LockerConfig struct {
+	Key     string `yaml:"key"`
+	Command string `yaml:"command"`
+}
+
+// BarConfig selects between an external bar launch command (e.g. polybar)
+// and i3's built-in bar
+type BarConfig struct {
+	// Command is run with exec_always; when empty the built-in i3bar is used
+	Command       string `yaml:"command"`
+	StatusCommand string `yaml:"status_command"`
+	Position      string `yaml:"position" schema:"bar_position"`
+	// Disabled leaves the bar out, for setups that start their bar elsewhere
+	Disabled bool `yaml:"disabled"`
+}
+
+// MediaKeysConfig holds the commands bound to the volume and player keys
+type MediaKeysConfig struct {
+	VolumeDown string `yaml:"volume_down"`
+	VolumeUp   string `yaml:"volume_up"`
+	VolumeMute string `yaml:"volume_mute"`
+	PlayPause  string `yaml:"play_pause"`
+	Next       string `yaml:"next"`
+	Previous   string `yaml:"previous"`
+}
+
+// BacklightConfig holds the commands bound to the brightness keys
+// The large variants are bound to the same keys with Shift held
+type BacklightConfig struct {
+	Down      string `yaml:"down"`
+	Up        string `yaml:"up"`
+	DownLarge string `yaml:"down_large"`
+	UpLarge   string `yaml:"up_large"`
+}
+
+// HotkeyConfig binds a key chord to an arbitrary command
+type HotkeyConfig struct {
+	Key         string `yaml:"key"`
+	Command     string `yaml:"command"`
+	Description string `yaml:"description"`
+	NoStartupID bool   `yaml:"no_startup_id"`
 }
 
 type MonitorConfig struct {
+	// Enabled detects the connected monitors instead of using a static configuration
+	Enabled bool `yaml:"enabled"`
+
 	// Native X11 detection settings (preferred)
 	UseNative bool   `yaml:"use_native"`
 	Display   string `yaml:"display"`
@@ -36,10 +133,17 @@ type MonitorConfig struct {
 }
 
 type LayoutConfig struct {
-	GapsInner          int               `yaml:"gaps_inner"`
-	GapsOuter          int               `yaml:"gaps_outer"`
-	MoveWorkspace      map[string]string `yaml:"move_workspace"`
-	WorkspaceToDisplay map[string]string `yaml:"workspace_to_display"`
+	Description        string     `yaml:"description"`
+	GapsInner          int        `yaml:"gaps_inner"`
+	GapsOuter          int        `yaml:"gaps_outer"`
+	MoveWorkspace      OrderedMap `yaml:"move_workspace" schema:"role"`
+	WorkspaceToDisplay OrderedMap `yaml:"workspace_to_display" schema:"role"`
+	// Gaps holds the full gaps model; gaps_inner and gaps_outer are shorthands
+	// for gaps.inner and gaps.outer
+	Gaps GapsConfig `yaml:"gaps"`
+
+	// Overrides replaces global settings while this layout is rendered
+	Overrides LayoutOverrides `yaml:"overrides"`
 }
 
 type ColorConfig struct {
@@ -61,34 +165,129 @@ type ColorConfig struct {
 	Base0F string `yaml:"base0F"`
 }
 
-// Validate checks if the configuration is valid
+// Warnings returns the problems that were tolerated while loading, such as
+// unknown keys in lenient mode
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// Validate checks if the configuration is valid, returning a ValidationError
+// that lists every problem found
 func (c *Config) Validate() error {
+	var problems problemList
+
 	if c.I3.ModKey == "" {
-		return fmt.Errorf("i3.mod_key is required")
+		problems.addf("i3.mod_key", "must be set")
+	}
+
+	problems.add("monitor_detection", c.MonitorDetection.validate())
+
+	problems.add("default_layout", c.validateDefaultLayout())
+
+	for _, name := range c.LayoutNames() {
+		problems.add("layouts."+name, c.Layouts[name].validate())
 	}
 
-	if c.UseDetectedMonitors {
-		// Check if either native detection is enabled or command detection is configured
-		if !c.MonitorDetection.UseNative && c.MonitorDetection.DetectionCommand == "" {
-			return fmt.Errorf("monitor detection is enabled but neither use_native nor detection_command is configured")
+	settings := c.validateSettings()
+	problems.add("", settings)
+	problems.add("", c.validateLayoutOverrides(settings))
+
+	problems.add("scaling", c.Scaling.validate())
+
+	switch c.Ordering {
+	case "", OrderingDocument, OrderingNatural:
+	default:
+		problems.addf("ordering", "invalid value '%s' (valid options: %s, %s)", c.Ordering, OrderingDocument, OrderingNatural)
+	}
+
+	return problems.err()
+}
+
+// validateSettings checks the settings that layouts can override
+func (c *Config) validateSettings() error {
+	var problems problemList
+
+	if c.I3.ModKey != "" && !slices.Contains(i3conf.Modifiers, strings.ToLower(c.I3.ModKey)) {
+		problems.addf("i3.mod_key", "unknown modifier '%s' (e.g. Mod1 for Alt, Mod4 for Super)", c.I3.ModKey)
+	}
+
+	for i, hotkey := range c.Hotkeys {
+		path := fmt.Sprintf("hotkeys[%d]", i)
+		if hotkey.Key == "" || hotkey.Command == "" {
+			problems.addf(path, "both key and command are required")
+			continue
 		}
+		problems.add(path+".key", validateKey(hotkey.Key))
+	}
+	problems.add("launcher.key", validateKey(c.Launcher.Key))
+	problems.add("locker.key", validateKey(c.Locker.Key))
+
+	if c.I3.BorderWidth != nil && *c.I3.BorderWidth < 0 {
+		problems.addf("i3.border_width", "must not be negative")
+	}
+
+	switch c.Bar.Position {
+	case "", "top", "bottom":
+	default:
+		problems.addf("bar.position", "invalid value '%s' (valid options: top, bottom)", c.Bar.Position)
 	}
 
-	return nil
+	problems.add("workspaces", c.Workspaces.validate())
+	problems.add("gap_keys", c.GapKeys.validate())
+	problems.add("", c.validateWindowRules())
+	problems.add("", c.validateWorkspaceReferences())
+	problems.add("extra", c.Extra.validate())
+
+	return problems.err()
+}
+
+// validate checks that enabled detection has a method, and that min_monitors can be
+// reached by padding a single connected monitor with the listed dummy monitors
+func (m MonitorConfig) validate() error {
+	var problems problemList
+	// Check if either native detection is enabled or command detection is configured
+	if m.Enabled && !m.UseNative && m.DetectionCommand == "" {
+		problems.addf("", "detection is enabled but neither use_native nor detection_command is configured")
+	}
+	if m.MinMonitors < 0 {
+		problems.addf("min_monitors", "must not be negative")
+	}
+	if m.MinMonitors > len(m.DummyMonitors)+1 {
+		problems.addf("min_monitors", "is %d but only %d dummy_monitors are listed, so a single monitor is padded to %d",
+			m.MinMonitors, len(m.DummyMonitors), len(m.DummyMonitors)+1)
+	}
+	return problems.err()
+}
+
+// validateKey checks that an optional key binding is a key combination i3 can parse
+func validateKey(key string) error {
+	if key == "" || key == NoKey {
+		return nil
+	}
+	_, _, err := i3conf.ParseKey(key)
+	return err
+}
+
+// OrderEntries returns the entries of m in the order requested by the configuration
+func (c *Config) OrderEntries(m OrderedMap) OrderedMap {
+	if c.Ordering == OrderingNatural {
+		return m.SortedNatural()
+	}
+	return m
 }
 
 // GetLayout returns the layout configuration for the given name
 func (c *Config) GetLayout(layoutName string) (*LayoutConfig, error) {
 	layout, exists := c.Layouts[layoutName]
 	if !exists {
-		return nil, fmt.Errorf("layout '%s' not found", layoutName)
+		return nil, c.unknownLayoutError(layoutName)
 	}
 	return &layout, nil
 }
 
 // CreateDetector creates an appropriate monitor detector based on configuration
 func (c *Config) CreateDetector() (monitor.MonitorDetector, error) {
-	if !c.UseDetectedMonitors {
+	if !c.MonitorDetection.Enabled {
 		return nil, fmt.Errorf("monitor detection is disabled")
 	}
 
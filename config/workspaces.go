@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// Workspace numbering schemes
+const (
+	// WorkspaceSchemeShared uses one set of workspaces spread over the displays
+	// by the layout's workspace_to_display
+	WorkspaceSchemeShared = "shared"
+	// WorkspaceSchemePerOutput gives each output its own numbered range, with
+	// $mod+N switching to workspace N of the focused output
+	WorkspaceSchemePerOutput = "per_output"
+)
+
+// WorkspaceSchemes lists the accepted workspaces.scheme values
+var WorkspaceSchemes = []string{WorkspaceSchemeShared, WorkspaceSchemePerOutput}
+
+// WorkspacesConfig selects how workspaces are numbered and bound to keys
+type WorkspacesConfig struct {
+	Scheme string `yaml:"scheme" schema:"workspace_scheme"`
+	// PerOutput is the size of each output's range in the per_output scheme
+	PerOutput int `yaml:"per_output"`
+	// Command is run by the per_output key bindings with --per-output, --move
+	// and the workspace number appended
+	Command string `yaml:"command"`
+}
+
+// PerOutputEnabled reports whether each output gets its own workspace range
+func (w WorkspacesConfig) PerOutputEnabled() bool {
+	return w.Scheme == WorkspaceSchemePerOutput
+}
+
+// Range returns the first and last workspace number of the output at index,
+// counting from 0 in the order the monitors were detected
+func (w WorkspacesConfig) Range(index int) (int, int) {
+	return index*w.PerOutput + 1, (index + 1) * w.PerOutput
+}
+
+// OnOutputOf returns workspace n of the range the workspace numbered current
+// belongs to, so the result is on the same output as current
+func (w WorkspacesConfig) OnOutputOf(current, n int) int {
+	if current < 1 {
+		return n
+	}
+	return (current-1)/w.PerOutput*w.PerOutput + n
+}
+
+// validate checks the workspace scheme and range size
+func (w WorkspacesConfig) validate() error {
+	var problems problemList
+	if w.Scheme != "" && !slices.Contains(WorkspaceSchemes, w.Scheme) {
+		problems.addf("scheme", "invalid value '%s' (valid options: shared, per_output)", w.Scheme)
+	}
+	if w.PerOutput < 0 {
+		problems.addf("per_output", "must not be negative")
+	}
+	return problems.err()
+}
+
+// sharedWorkspaces is the number of workspaces bound to keys in the shared scheme
+const sharedWorkspaces = 10
+
+// validateWorkspaceReferences checks that the numbered workspaces that windows and
+// gaps refer to exist: in the shared scheme these are the workspaces bound to keys
+// and those placed by a layout, in the per_output scheme any positive number
+// Named workspaces are created when first used, so any name is accepted
+func (c *Config) validateWorkspaceReferences() error {
+	known := map[string]bool{}
+	for n := 1; n <= sharedWorkspaces; n++ {
+		known[strconv.Itoa(n)] = true
+	}
+	for _, layout := range c.Layouts {
+		for _, entry := range layout.WorkspaceToDisplay {
+			known[entry.Key] = true
+		}
+	}
+
+	var problems problemList
+	check := func(path, workspace string) {
+		number, err := strconv.Atoi(workspace)
+		if err != nil || known[workspace] || c.Workspaces.PerOutputEnabled() && number > 0 {
+			return
+		}
+		problems.addf(path, "unknown workspace %s", workspace)
+	}
+
+	for i, rule := range c.WindowOverrides {
+		check(fmt.Sprintf("window_overrides[%d].move_to_workspace", i), rule.MoveToWorkspace)
+	}
+	for i, assignment := range c.Assignments {
+		path := fmt.Sprintf("assignments[%d]", i)
+		check(path+".workspace", assignment.Workspace)
+		var names []string
+		for name := range assignment.Layouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			check(path+".layouts."+name+".workspace", assignment.Layouts[name].Workspace)
+		}
+	}
+	for _, entry := range c.ApplicationBindings {
+		check("application_bindings."+entry.Key, entry.Value)
+	}
+	for _, name := range c.LayoutNames() {
+		for i, gaps := range c.Layouts[name].Gaps.Workspaces {
+			check(fmt.Sprintf("layouts.%s.gaps.workspaces[%d].workspace", name, i), gaps.Workspace)
+		}
+	}
+	return problems.err()
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWorkspacesConfig_Range(t *testing.T) {
+	workspaces := WorkspacesConfig{PerOutput: 10}
+
+	tests := []struct {
+		index       int
+		first, last int
+	}{
+		{0, 1, 10},
+		{1, 11, 20},
+		{2, 21, 30},
+	}
+
+	for _, tt := range tests {
+		first, last := workspaces.Range(tt.index)
+		if first != tt.first || last != tt.last {
+			t.Errorf("Expected range %d-%d for output %d, got %d-%d", tt.first, tt.last, tt.index, first, last)
+		}
+	}
+}
+
+func TestWorkspacesConfig_OnOutputOf(t *testing.T) {
+	workspaces := WorkspacesConfig{PerOutput: 10}
+
+	tests := []struct {
+		current  int
+		n        int
+		expected int
+	}{
+		{1, 3, 3},
+		{10, 3, 3},
+		{11, 3, 13},
+		{20, 10, 20},
+		{27, 1, 21},
+		{0, 4, 4},
+	}
+
+	for _, tt := range tests {
+		if result := workspaces.OnOutputOf(tt.current, tt.n); result != tt.expected {
+			t.Errorf("Expected workspace %d for %d from %d, got %d", tt.expected, tt.n, tt.current, result)
+		}
+	}
+}
+
+func TestWorkspacesConfig_validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		workspaces WorkspacesConfig
+		expected   string
+	}{
+		{"unset", WorkspacesConfig{}, ""},
+		{"per output", WorkspacesConfig{Scheme: WorkspaceSchemePerOutput, PerOutput: 5}, ""},
+		{"unknown scheme", WorkspacesConfig{Scheme: "per_monitor"}, "scheme: invalid value 'per_monitor'"},
+		{"negative range", WorkspacesConfig{Scheme: WorkspaceSchemePerOutput, PerOutput: -1}, "per_output: must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.workspaces.validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
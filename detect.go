@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/a7d-corp/i3-config-generator-go/cli"
+	"github.com/a7d-corp/i3-config-generator-go/monitor"
+	"gopkg.in/yaml.v3"
+)
+
+// detectReport is everything the detect command prints
+type detectReport struct {
+	Roles   *monitor.DetectedMonitors `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Display *monitor.DisplayInfo      `json:"display" yaml:"display"`
+}
+
+// runDetect prints the full monitor inventory and the roles assigned to the monitors
+func runDetect(args *cli.Args) error {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		// Without an explicit config the inventory is still useful, e.g. for bug reports
+		if args.ConfigPath != "" {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v; showing inventory only\n", err)
+		cfg = nil
+	}
+
+	display := ""
+	var dummyMonitors []string
+	minMonitors := 0
+	if cfg != nil {
+		display = cfg.MonitorDetection.Display
+		dummyMonitors = cfg.MonitorDetection.DummyMonitors
+		minMonitors = cfg.MonitorDetection.MinMonitors
+	}
+
+	// The inventory always comes from RandR, whichever detection method is configured
+	native := monitor.NewNativeDetector(display, dummyMonitors, minMonitors)
+	if args.Fixture != "" {
+		fixture, err := monitor.LoadFixture(args.Fixture)
+		if err != nil {
+			return err
+		}
+		native = monitor.NewNativeDetector(fixture.Display, dummyMonitors, minMonitors)
+		native.SetRandR(monitor.NewFakeRandR(fixture))
+	}
+	info, err := native.GetDisplayInfo()
+	if err != nil {
+		return fmt.Errorf("failed to query displays: %w", err)
+	}
+
+	report := detectReport{Display: info}
+	if cfg != nil && cfg.MonitorDetection.Enabled {
+		// A replayed display can only be detected natively
+		var detector monitor.MonitorDetector = native
+		if args.Fixture == "" {
+			if detector, err = cfg.CreateDetector(); err != nil {
+				return fmt.Errorf("failed to create monitor detector: %w", err)
+			}
+		}
+		if report.Roles, err = detector.DetectMonitors(); err != nil {
+			return fmt.Errorf("failed to detect monitors: %w", err)
+		}
+		for _, warning := range report.Roles.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+
+	return writeDetectReport(os.Stdout, &report, args.Format)
+}
+
+// runRecord writes the RandR state of the display as a fixture
+func runRecord(args *cli.Args) error {
+	display := args.Display
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+	if display == "" {
+		display = ":0"
+	}
+
+	r, err := monitor.DialRandR(display)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fixture, err := monitor.Record(r, display)
+	if err != nil {
+		return fmt.Errorf("failed to record display %s: %w", display, err)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(fixture); err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	if args.OutputPath == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(args.OutputPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture %s: %w", args.OutputPath, err)
+	}
+	fmt.Printf("✓ Recorded display %s to %s\n", display, args.OutputPath)
+	return nil
+}
+
+// writeDetectReport writes the report in the requested format
+func writeDetectReport(w io.Writer, report *detectReport, format string) error {
+	switch format {
+	case cli.FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case cli.FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return writeDetectTable(w, report)
+	}
+}
+
+// writeDetectTable writes the report as human readable tables
+func writeDetectTable(w io.Writer, report *detectReport) error {
+	info := report.Display
+	fmt.Fprintf(w, "Display %s (screen %dx%d)\n\n", info.Display, info.ScreenWidth, info.ScreenHeight)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OUTPUT\tSTATE\tPRIMARY\tGEOMETRY\tMODE\tSIZE\tMONITOR")
+	for _, output := range info.Outputs {
+		primary := ""
+		if output.Primary {
+			primary = "yes"
+		}
+		geometry, mode := "-", "-"
+		if output.Geometry != nil {
+			g := output.Geometry
+			geometry = fmt.Sprintf("%dx%d+%d+%d", g.Width, g.Height, g.X, g.Y)
+			if g.Rotation != "normal" {
+				geometry += " " + g.Rotation
+			}
+		}
+		if output.CurrentMode != nil {
+			mode = output.CurrentMode.String()
+		}
+		size := "-"
+		if output.WidthMM > 0 && output.HeightMM > 0 {
+			size = fmt.Sprintf("%dx%d mm", output.WidthMM, output.HeightMM)
+			if dpi := output.DPI(); dpi > 0 {
+				size += fmt.Sprintf(" (%.0f dpi)", dpi)
+			}
+		}
+		identity := "-"
+		if output.EDID != nil {
+			identity = output.EDID.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			output.Name, output.Connection, primary, geometry, mode, size, identity)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Failed queries are listed so that reports from broken setups stay useful
+	for _, output := range info.Outputs {
+		if output.Error != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", output.Name, output.Error)
+		}
+	}
+
+	// Modes are grouped by resolution with their refresh rates; * marks preferred modes
+	for _, output := range info.Outputs {
+		if len(output.Modes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s modes:\n", output.Name)
+		for _, line := range groupModes(output.Modes) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	if report.Roles != nil {
+		fmt.Fprintf(w, "\nRoles:\n")
+		fmt.Fprintf(w, "  primary_display: %s\n", report.Roles.Primary)
+		fmt.Fprintf(w, "  left_display:    %s\n", report.Roles.Left)
+		fmt.Fprintf(w, "  right_display:   %s\n", report.Roles.Right)
+		if len(report.Roles.Disabled) > 0 {
+			fmt.Fprintf(w, "  disabled:        %s (connected, no role)\n", strings.Join(report.Roles.Disabled, ", "))
+		}
+		if len(report.Roles.Stale) > 0 {
+			fmt.Fprintf(w, "  stale:           %s (unplugged but still enabled, no role)\n", strings.Join(report.Roles.Stale, ", "))
+		}
+	}
+
+	return nil
+}
+
+// groupModes formats modes as one line per resolution listing its refresh rates
+// from fastest to slowest, keeping the order in which the resolutions were reported
+func groupModes(modes []monitor.ModeInfo) []string {
+	var resolutions []string
+	byResolution := make(map[string][]monitor.ModeInfo)
+	for _, mode := range modes {
+		resolution := fmt.Sprintf("%dx%d", mode.Width, mode.Height)
+		if _, seen := byResolution[resolution]; !seen {
+			resolutions = append(resolutions, resolution)
+		}
+		byResolution[resolution] = append(byResolution[resolution], mode)
+	}
+
+	lines := make([]string, 0, len(resolutions))
+	for _, resolution := range resolutions {
+		group := byResolution[resolution]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Refresh > group[j].Refresh })
+
+		var rates []string
+		for i, mode := range group {
+			if i > 0 && mode.Refresh == group[i-1].Refresh && !mode.Preferred {
+				continue
+			}
+			rate := fmt.Sprintf("%.2f", mode.Refresh)
+			if mode.Preferred {
+				rate += "*"
+			}
+			rates = append(rates, rate)
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %s", resolution, strings.Join(rates, " ")))
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	if result := Unified("a", "b", "same\ntext\n", "same\ntext\n", DefaultContext); result != "" {
+		t.Errorf("Expected empty diff for equal texts, got:\n%s", result)
+	}
+}
+
+func TestUnified_Change(t *testing.T) {
+	oldText := "set $mod Mod4\n\nworkspace 1 output HDMI-1\nworkspace 2 output HDMI-1\n\ngaps inner 20\n"
+	newText := "set $mod Mod4\n\nworkspace 1 output DP-1\nworkspace 2 output HDMI-1\n\ngaps inner 20\n"
+
+	expected := `--- current
++++ generated
+@@ -1,6 +1,6 @@
+ set $mod Mod4
+ 
+-workspace 1 output HDMI-1
++workspace 1 output DP-1
+ workspace 2 output HDMI-1
+ 
+ gaps inner 20
+`
+
+	if result := Unified("current", "generated", oldText, newText, DefaultContext); result != expected {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", result, expected)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := strings.Repeat("x", i+1)
+		oldLines = append(oldLines, line)
+		if i == 2 || i == 17 {
+			line = "changed"
+		}
+		newLines = append(newLines, line)
+	}
+
+	result := Unified("a", "b", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n", 1)
+
+	if count := strings.Count(result, "@@ -"); count != 2 {
+		t.Errorf("Expected 2 hunks, got %d:\n%s", count, result)
+	}
+	if !strings.Contains(result, "@@ -2,3 +2,3 @@") {
+		t.Errorf("Expected first hunk header '@@ -2,3 +2,3 @@', got:\n%s", result)
+	}
+	if !strings.Contains(result, "@@ -17,3 +17,3 @@") {
+		t.Errorf("Expected second hunk header '@@ -17,3 +17,3 @@', got:\n%s", result)
+	}
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	result := Unified("/dev/null", "config", "", "line 1\nline 2\n", DefaultContext)
+
+	expected := "--- /dev/null\n+++ config\n@@ -0,0 +1,2 @@\n+line 1\n+line 2\n"
+	if result != expected {
+		t.Errorf("Unexpected diff:\n%q\nwant:\n%q", result, expected)
+	}
+}
@@ -4,4 +4,6 @@ go 1.24.5
 
 require gopkg.in/yaml.v3 v3.0.1
 
-require github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc // indirect
+require github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
+
+require github.com/BurntSushi/toml v1.6.0
@@ -0,0 +1,466 @@
+package i3conf
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Variable is a variable defined with set or set_from_resource
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// Binding is a key binding defined with bindsym or bindcode
+type Binding struct {
+	// Mode is the binding mode the binding belongs to, empty for the default mode
+	Mode string
+	// Type is bindsym or bindcode
+	Type string
+	// Flags are options such as --release or --whole-window
+	Flags []string
+	Key   string
+	// Command is the i3 command run by the binding, as written
+	Command string
+}
+
+// Exec is a program started with exec or exec_always
+type Exec struct {
+	Always      bool
+	NoStartupID bool
+	Command     string
+}
+
+// ForWindow runs a command for windows matching the criteria
+type ForWindow struct {
+	// Criteria is the bracketed criteria list as written, e.g. [class="Firefox"]
+	Criteria string
+	Command  string
+}
+
+// Assign moves windows matching the criteria to a workspace or output
+type Assign struct {
+	Criteria string
+	// Target is the destination as written, e.g. "number 3" or "output HDMI-1", without any arrow
+	Target string
+}
+
+// Criterion is a single condition of a criteria list, e.g. class="^Firefox$"
+// Value is empty for conditions without one, such as floating
+type Criterion struct {
+	Key   string
+	Value string
+}
+
+// WorkspaceOutput places a workspace on the first available of a list of outputs
+type WorkspaceOutput struct {
+	Workspace string
+	Outputs   []string
+}
+
+// Variables returns the variables defined at the top level, in definition order
+// A variable set several times is listed once with its last value
+func (c *Config) Variables() []Variable {
+	var variables []Variable
+	index := map[string]int{}
+	for _, s := range c.Statements {
+		variable, err := ParseVariable(s)
+		if err != nil {
+			continue
+		}
+		if i, ok := index[variable.Name]; ok {
+			variables[i].Value = variable.Value
+			continue
+		}
+		index[variable.Name] = len(variables)
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// Expand replaces the variables of the config in s, longest names first like i3
+func (c *Config) Expand(s string) string {
+	variables := c.Variables()
+	sort.SliceStable(variables, func(i, j int) bool {
+		return len(variables[i].Name) > len(variables[j].Name)
+	})
+	for _, variable := range variables {
+		s = strings.ReplaceAll(s, variable.Name, variable.Value)
+	}
+	return s
+}
+
+// Bindings returns all key bindings, including those inside mode blocks
+// Malformed bindings are skipped
+func (c *Config) Bindings() []Binding {
+	var bindings []Binding
+	for _, s := range c.Statements {
+		if mode, err := ParseModeName(s); err == nil {
+			for _, child := range s.Block {
+				if binding, err := ParseBinding(child); err == nil {
+					binding.Mode = mode
+					bindings = append(bindings, binding)
+				}
+			}
+			continue
+		}
+		if binding, err := ParseBinding(s); err == nil {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// Execs returns the programs started by the config
+func (c *Config) Execs() []Exec {
+	var execs []Exec
+	for _, s := range c.Statements {
+		if exec, err := ParseExec(s); err == nil {
+			execs = append(execs, exec)
+		}
+	}
+	return execs
+}
+
+// ForWindows returns the for_window rules of the config
+func (c *Config) ForWindows() []ForWindow {
+	var rules []ForWindow
+	for _, s := range c.Statements {
+		if rule, err := ParseForWindow(s); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Assigns returns the assign rules of the config
+func (c *Config) Assigns() []Assign {
+	var assigns []Assign
+	for _, s := range c.Statements {
+		if assign, err := ParseAssign(s); err == nil {
+			assigns = append(assigns, assign)
+		}
+	}
+	return assigns
+}
+
+// WorkspaceOutputs returns the workspace to output assignments of the config
+func (c *Config) WorkspaceOutputs() []WorkspaceOutput {
+	var assignments []WorkspaceOutput
+	for _, s := range c.Statements {
+		if assignment, err := ParseWorkspaceOutput(s); err == nil {
+			assignments = append(assignments, assignment)
+		}
+	}
+	return assignments
+}
+
+// ParseVariable interprets a set or set_from_resource statement
+// For set_from_resource the fallback value is returned
+func ParseVariable(s Statement) (Variable, error) {
+	switch s.Keyword {
+	case "set":
+		if len(s.Args) < 2 || !strings.HasPrefix(s.Args[0], "$") {
+			return Variable{}, s.errorf("expected 'set $name value'")
+		}
+		return Variable{Name: s.Args[0], Value: s.RawFrom(1)}, nil
+	case "set_from_resource":
+		if len(s.Args) < 2 || !strings.HasPrefix(s.Args[0], "$") {
+			return Variable{}, s.errorf("expected 'set_from_resource $name resource [fallback]'")
+		}
+		return Variable{Name: s.Args[0], Value: s.RawFrom(2)}, nil
+	}
+	return Variable{}, s.errorf("not a variable definition")
+}
+
+// ParseBinding interprets a bindsym or bindcode statement
+func ParseBinding(s Statement) (Binding, error) {
+	if s.Keyword != "bindsym" && s.Keyword != "bindcode" {
+		return Binding{}, s.errorf("not a key binding")
+	}
+
+	binding := Binding{Type: s.Keyword}
+	i := 0
+	for i < len(s.Args) && strings.HasPrefix(s.Args[i], "--") {
+		binding.Flags = append(binding.Flags, s.Args[i])
+		i++
+	}
+	if i+1 >= len(s.Args) {
+		return Binding{}, s.errorf("expected '%s [flags] key command'", s.Keyword)
+	}
+	binding.Key = s.Args[i]
+	binding.Command = s.RawFrom(i + 1)
+	return binding, nil
+}
+
+// ParseModeName returns the name of the binding mode opened by a mode block
+func ParseModeName(s Statement) (string, error) {
+	if s.Keyword != "mode" || !s.IsBlock {
+		return "", s.errorf("not a mode block")
+	}
+	for _, arg := range s.Args {
+		if !strings.HasPrefix(arg, "--") {
+			return arg, nil
+		}
+	}
+	return "", s.errorf("mode block without a name")
+}
+
+// ParseExec interprets an exec or exec_always statement
+func ParseExec(s Statement) (Exec, error) {
+	if s.Keyword != "exec" && s.Keyword != "exec_always" {
+		return Exec{}, s.errorf("not an exec statement")
+	}
+
+	exec := Exec{Always: s.Keyword == "exec_always"}
+	i := 0
+	if len(s.Args) > 0 && s.Args[0] == "--no-startup-id" {
+		exec.NoStartupID = true
+		i++
+	}
+	if i >= len(s.Args) {
+		return Exec{}, s.errorf("%s without a command", s.Keyword)
+	}
+	exec.Command = s.RawFrom(i)
+	// A single quoted command is passed to the shell without the quotes
+	if i+1 == len(s.Args) && strings.HasPrefix(exec.Command, `"`) {
+		exec.Command = s.Args[i]
+	}
+	return exec, nil
+}
+
+// ParseForWindow interprets a for_window statement
+func ParseForWindow(s Statement) (ForWindow, error) {
+	if s.Keyword != "for_window" {
+		return ForWindow{}, s.errorf("not a for_window rule")
+	}
+	if len(s.Args) < 2 || !isCriteria(s.Args[0]) {
+		return ForWindow{}, s.errorf("expected 'for_window [criteria] command'")
+	}
+	return ForWindow{Criteria: s.Args[0], Command: s.RawFrom(1)}, nil
+}
+
+// ParseAssign interprets an assign statement
+func ParseAssign(s Statement) (Assign, error) {
+	if s.Keyword != "assign" {
+		return Assign{}, s.errorf("not an assign rule")
+	}
+	if len(s.Args) < 2 || !isCriteria(s.Args[0]) {
+		return Assign{}, s.errorf("expected 'assign [criteria] target'")
+	}
+	i := 1
+	if s.Args[1] == "→" {
+		i++
+	}
+	if i >= len(s.Args) {
+		return Assign{}, s.errorf("assign without a target")
+	}
+	return Assign{Criteria: s.Args[0], Target: s.RawFrom(i)}, nil
+}
+
+// ParseWorkspaceOutput interprets a 'workspace <name> output <outputs>' statement
+func ParseWorkspaceOutput(s Statement) (WorkspaceOutput, error) {
+	if s.Keyword != "workspace" || len(s.Args) < 3 || s.Args[1] != "output" {
+		return WorkspaceOutput{}, s.errorf("not a workspace output assignment")
+	}
+	return WorkspaceOutput{Workspace: s.Args[0], Outputs: s.Args[2:]}, nil
+}
+
+// repeatableKeywords are the directives that add to the config each time they
+// are given, rather than replacing an earlier setting
+var repeatableKeywords = []string{
+	"assign", "bar", "bindcode", "bindsym", "exec", "exec_always", "for_window",
+	"include", "mode", "no_focus", "set", "set_from_resource", "workspace",
+}
+
+// SettingKey returns the option a statement sets, such as floating_modifier or
+// "gaps inner", where a later statement for the same option replaces it
+// It reports false for blocks and for directives that add to the config, such as bindings
+func (s Statement) SettingKey() (string, bool) {
+	if s.IsBlock || slices.Contains(repeatableKeywords, s.Keyword) {
+		return "", false
+	}
+	if s.Keyword == "gaps" && len(s.Args) > 0 {
+		return s.Keyword + " " + s.Args[0], true
+	}
+	return s.Keyword, true
+}
+
+// ParseCriteria splits a bracketed criteria list such as [class="^Firefox$" floating]
+// into its conditions, with quoted values unescaped
+func ParseCriteria(criteria string) ([]Criterion, error) {
+	if !isCriteria(criteria) {
+		return nil, fmt.Errorf("criteria must be enclosed in brackets: %s", criteria)
+	}
+
+	inner := criteria[1 : len(criteria)-1]
+	var result []Criterion
+	i := 0
+	for i < len(inner) {
+		if inner[i] == ' ' || inner[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(inner) && inner[i] != '=' && inner[i] != ' ' && inner[i] != '\t' {
+			i++
+		}
+		criterion := Criterion{Key: inner[start:i]}
+		if i < len(inner) && inner[i] == '=' {
+			i++
+			if i < len(inner) && inner[i] == '"' {
+				end, err := closingQuote(inner, i)
+				if err != nil {
+					return nil, err
+				}
+				criterion.Value = strings.ReplaceAll(inner[i+1:end], `\"`, `"`)
+				i = end + 1
+			} else {
+				start = i
+				for i < len(inner) && inner[i] != ' ' && inner[i] != '\t' {
+					i++
+				}
+				criterion.Value = inner[start:i]
+			}
+		}
+		if criterion.Key == "" {
+			return nil, fmt.Errorf("criterion without a name in %s", criteria)
+		}
+		result = append(result, criterion)
+	}
+	return result, nil
+}
+
+// isCriteria reports whether a word is a bracketed criteria list
+func isCriteria(word string) bool {
+	return strings.HasPrefix(word, "[") && strings.HasSuffix(word, "]")
+}
+
+// errorf returns an error prefixed with the statement's location
+func (s Statement) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", s.File, s.Line, fmt.Sprintf(format, args...))
+}
+
+// ExpandVariables returns a copy of the config with variables replaced in every
+// statement except their own definitions; names listed in keep are left as written
+func (c *Config) ExpandVariables(keep ...string) (*Config, error) {
+	var variables []Variable
+	for _, variable := range c.Variables() {
+		if !slices.Contains(keep, variable.Name) {
+			variables = append(variables, variable)
+		}
+	}
+	sort.SliceStable(variables, func(i, j int) bool {
+		return len(variables[i].Name) > len(variables[j].Name)
+	})
+
+	statements, err := expandStatements(c.Statements, variables)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Statements: statements, Files: c.Files}, nil
+}
+
+// expandStatements replaces variables in statements and their blocks
+func expandStatements(statements []Statement, variables []Variable) ([]Statement, error) {
+	expanded := make([]Statement, len(statements))
+	for i, s := range statements {
+		if s.Keyword != "set" && s.Keyword != "set_from_resource" {
+			raw := s.Raw
+			for _, variable := range variables {
+				raw = strings.ReplaceAll(raw, variable.Name, variable.Value)
+			}
+			if raw != s.Raw {
+				tokens, err := tokenize(raw)
+				if err != nil {
+					return nil, s.errorf("%v", err)
+				}
+				if s.IsBlock {
+					tokens = tokens[:len(tokens)-1]
+				}
+				s.Raw = raw
+				s.Keyword = tokens[0].value
+				s.Args = tokenValues(tokens[1:])
+				s.tokens = tokens
+			}
+		}
+		if len(s.Block) > 0 {
+			block, err := expandStatements(s.Block, variables)
+			if err != nil {
+				return nil, err
+			}
+			s.Block = block
+		}
+		expanded[i] = s
+	}
+	return expanded, nil
+}
+
+// Exec interprets the binding's command as a single exec command
+// It reports false for other commands and for chains such as 'exec foo, mode "default"'
+func (b Binding) Exec() (Exec, bool) {
+	tokens, err := tokenize(b.Command)
+	if err != nil || len(tokens) < 2 || tokens[0].text != "exec" {
+		return Exec{}, false
+	}
+	for _, t := range tokens {
+		if !strings.HasPrefix(t.text, `"`) && strings.ContainsAny(t.text, ",;") {
+			return Exec{}, false
+		}
+	}
+	statement := Statement{Keyword: "exec", Args: tokenValues(tokens[1:]), Raw: b.Command, tokens: tokens}
+	exec, err := ParseExec(statement)
+	if err != nil {
+		return Exec{}, false
+	}
+	return exec, true
+}
+
+// NormalizedKey returns the key in a canonical form for comparing bindings:
+// lower case with modifiers sorted, so Shift+$mod+a and $mod+shift+A compare equal
+func (b Binding) NormalizedKey() string {
+	return NormalizeKey(b.Key)
+}
+
+// NormalizeKey returns a key combination in canonical form, see Binding.NormalizedKey
+func NormalizeKey(key string) string {
+	parts := strings.Split(strings.ToLower(key), "+")
+	modifiers := parts[:len(parts)-1]
+	sort.Strings(modifiers)
+	return strings.Join(append(modifiers, parts[len(parts)-1]), "+")
+}
+
+// Modifiers lists the modifier names i3 accepts in key combinations, in lower case
+var Modifiers = []string{
+	"shift", "control", "ctrl", "lock", "mode_switch",
+	"mod1", "mod2", "mod3", "mod4", "mod5",
+	"group1", "group2", "group3", "group4",
+}
+
+// ParseKey splits a key combination such as $mod+Shift+Return into its modifiers
+// and key; modifiers are names i3 accepts, in any case, or variables such as $mod
+func ParseKey(key string) (modifiers []string, keysym string, err error) {
+	if strings.TrimSpace(key) == "" {
+		return nil, "", fmt.Errorf("empty key")
+	}
+	parts := strings.Split(key, "+")
+	keysym = parts[len(parts)-1]
+	if keysym == "" {
+		return nil, "", fmt.Errorf("key %q has no key after the modifiers", key)
+	}
+	if strings.ContainsAny(keysym, " \t") {
+		return nil, "", fmt.Errorf("key %q contains whitespace", key)
+	}
+	for _, modifier := range parts[:len(parts)-1] {
+		if strings.HasPrefix(modifier, "$") && len(modifier) > 1 {
+			continue
+		}
+		if !slices.Contains(Modifiers, strings.ToLower(modifier)) {
+			return nil, "", fmt.Errorf("key %q has unknown modifier %q", key, modifier)
+		}
+	}
+	return parts[:len(parts)-1], keysym, nil
+}
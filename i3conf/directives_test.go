@@ -0,0 +1,383 @@
+package i3conf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseStatement parses a single statement for the directive tests
+func parseStatement(t *testing.T, input string) Statement {
+	t.Helper()
+	cfg, err := Parse(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", input, err)
+	}
+	if len(cfg.Statements) != 1 {
+		t.Fatalf("Expected 1 statement in %q, got %d", input, len(cfg.Statements))
+	}
+	return cfg.Statements[0]
+}
+
+func TestParseVariable(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Variable
+		expectError bool
+	}{
+		{"set $mod Mod4", Variable{"$mod", "Mod4"}, false},
+		{"set $ws1 \"1: web\"", Variable{"$ws1", "\"1: web\""}, false},
+		{"set $term   alacritty -e tmux", Variable{"$term", "alacritty -e tmux"}, false},
+		{"set_from_resource $bg i3wm.background #000000", Variable{"$bg", "#000000"}, false},
+		{"set mod Mod4", Variable{}, true},
+		{"set $mod", Variable{}, true},
+		{"font monospace", Variable{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			variable, err := ParseVariable(parseStatement(t, tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if variable != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, variable)
+			}
+		})
+	}
+}
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Binding
+		expectError bool
+	}{
+		{
+			input:    "bindsym $mod+Return exec i3-sensible-terminal",
+			expected: Binding{Type: "bindsym", Key: "$mod+Return", Command: "exec i3-sensible-terminal"},
+		},
+		{
+			input:    "bindcode --release 214 exec \"scrot -s\"",
+			expected: Binding{Type: "bindcode", Flags: []string{"--release"}, Key: "214", Command: "exec \"scrot -s\""},
+		},
+		{
+			input:    "bindsym --whole-window --border button2 kill",
+			expected: Binding{Type: "bindsym", Flags: []string{"--whole-window", "--border"}, Key: "button2", Command: "kill"},
+		},
+		{
+			input:    "bindsym $mod+r mode \"resize\"",
+			expected: Binding{Type: "bindsym", Key: "$mod+r", Command: "mode \"resize\""},
+		},
+		{input: "bindsym $mod+x", expectError: true},
+		{input: "bindsym --release", expectError: true},
+		{input: "exec foo", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			binding, err := ParseBinding(parseStatement(t, tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(binding, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, binding)
+			}
+		})
+	}
+}
+
+func TestParseExec(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Exec
+		expectError bool
+	}{
+		{"exec nm-applet", Exec{Command: "nm-applet"}, false},
+		{"exec --no-startup-id dunst -config ~/.dunstrc", Exec{NoStartupID: true, Command: "dunst -config ~/.dunstrc"}, false},
+		{"exec_always --no-startup-id $HOME/bin/bar.sh", Exec{Always: true, NoStartupID: true, Command: "$HOME/bin/bar.sh"}, false},
+		{"exec \"sleep 1; notify-send hi\"", Exec{Command: "sleep 1; notify-send hi"}, false},
+		{"exec --no-startup-id", Exec{}, true},
+		{"bindsym x exec foo", Exec{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			exec, err := ParseExec(parseStatement(t, tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if exec != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, exec)
+			}
+		})
+	}
+}
+
+func TestParseForWindow(t *testing.T) {
+	rule, err := ParseForWindow(parseStatement(t, `for_window [class="Pavucontrol" title="Volume Control"] floating enable, resize set 800 600`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := ForWindow{
+		Criteria: `[class="Pavucontrol" title="Volume Control"]`,
+		Command:  "floating enable, resize set 800 600",
+	}
+	if rule != expected {
+		t.Errorf("Expected %+v, got %+v", expected, rule)
+	}
+
+	if _, err := ParseForWindow(parseStatement(t, "for_window floating enable")); err == nil {
+		t.Error("Expected error for missing criteria")
+	}
+	if _, err := ParseForWindow(parseStatement(t, `for_window [class="x"]`)); err == nil {
+		t.Error("Expected error for missing command")
+	}
+}
+
+func TestParseAssign(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Assign
+		expectError bool
+	}{
+		{`assign [class="Firefox"] 2`, Assign{`[class="Firefox"]`, "2"}, false},
+		{`assign [class="Firefox"] → number 2`, Assign{`[class="Firefox"]`, "number 2"}, false},
+		{`assign [class="Slack"] workspace "9: chat"`, Assign{`[class="Slack"]`, `workspace "9: chat"`}, false},
+		{`assign [class="Spotify"] output HDMI-1`, Assign{`[class="Spotify"]`, "output HDMI-1"}, false},
+		{`assign [class="Firefox"] →`, Assign{}, true},
+		{`assign Firefox 2`, Assign{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assign, err := ParseAssign(parseStatement(t, tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if assign != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, assign)
+			}
+		})
+	}
+}
+
+func TestParseCriteria(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    []Criterion
+		expectError bool
+	}{
+		{`[class="^Firefox$"]`, []Criterion{{"class", "^Firefox$"}}, false},
+		{`[class="mpv" title="a \"b\" c" floating]`, []Criterion{{"class", "mpv"}, {"title", `a "b" c`}, {"floating", ""}}, false},
+		{`[urgent=latest con_id=12]`, []Criterion{{"urgent", "latest"}, {"con_id", "12"}}, false},
+		{`[]`, nil, false},
+		{`class="mpv"`, nil, true},
+		{`[title="open]`, nil, true},
+		{`[="x"]`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			criteria, err := ParseCriteria(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(criteria, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, criteria)
+			}
+		})
+	}
+}
+
+func TestParseWorkspaceOutput(t *testing.T) {
+	assignment, err := ParseWorkspaceOutput(parseStatement(t, `workspace "1: web" output DP-1 eDP-1`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := WorkspaceOutput{Workspace: "1: web", Outputs: []string{"DP-1", "eDP-1"}}
+	if !reflect.DeepEqual(assignment, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, assignment)
+	}
+
+	for _, input := range []string{"workspace 1 gaps inner 10", "workspace 1 output", "workspace_layout tabbed"} {
+		if _, err := ParseWorkspaceOutput(parseStatement(t, input)); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestStatement_SettingKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"floating_modifier $mod", "floating_modifier", true},
+		{"gaps inner 10", "gaps inner", true},
+		{"gaps outer 5px", "gaps outer", true},
+		{"client.focused #000000 #000000 #ffffff", "client.focused", true},
+		{"workspace 4 gaps inner 0", "", false},
+		{"bindsym $mod+Return exec alacritty", "", false},
+		{"exec --no-startup-id nm-applet", "", false},
+		{"for_window [class=\"^.*\"] border pixel 2", "", false},
+		{"bar {\n  position top\n}", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			key, ok := parseStatement(t, tt.input).SettingKey()
+			if ok != tt.ok {
+				t.Fatalf("Expected ok %v, got %v", tt.ok, ok)
+			}
+			if key != tt.expected {
+				t.Errorf("Expected key %q, got %q", tt.expected, key)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input       string
+		modifiers   []string
+		keysym      string
+		expectError bool
+	}{
+		{"$mod+Shift+Return", []string{"$mod", "Shift"}, "Return", false},
+		{"Ctrl+Shift+1", []string{"Ctrl", "Shift"}, "1", false},
+		{"MOD4+mode_switch+a", []string{"MOD4", "mode_switch"}, "a", false},
+		{"XF86AudioMute", nil, "XF86AudioMute", false},
+		{"", nil, "", true},
+		{"$mod+", nil, "", true},
+		{"Hyper+a", nil, "", true},
+		{"$+a", nil, "", true},
+		{"$mod+Page Up", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			modifiers, keysym, err := ParseKey(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(modifiers) != len(tt.modifiers) || len(modifiers) > 0 && !reflect.DeepEqual(modifiers, tt.modifiers) {
+				t.Errorf("Expected modifiers %v, got %v", tt.modifiers, modifiers)
+			}
+			if keysym != tt.keysym {
+				t.Errorf("Expected key %s, got %s", tt.keysym, keysym)
+			}
+		})
+	}
+}
+
+func TestParseModeName(t *testing.T) {
+	cfg, err := Parse(strings.NewReader("mode --pango_markup \"<b>resize</b>\" {\n}\nmode foo\n"), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	name, err := ParseModeName(cfg.Statements[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "<b>resize</b>" {
+		t.Errorf("Expected mode <b>resize</b>, got %s", name)
+	}
+
+	// A mode command outside a block is not a mode definition
+	if _, err := ParseModeName(cfg.Statements[1]); err == nil {
+		t.Error("Expected error for mode statement without a block")
+	}
+}
+
+func TestConfig_Accessors(t *testing.T) {
+	input := `set $mod Mod4
+set $term alacritty
+set $terminal kitty
+set $mod Mod1
+exec --no-startup-id nm-applet
+exec_always picom
+bindsym $mod+Return exec $term
+bindsym $mod+x
+mode "resize" {
+	bindsym h resize shrink width 10 px
+	bindsym Escape mode "default"
+}
+for_window [class="mpv"] floating enable
+assign [class="Firefox"] 2
+workspace 1 output HDMI-1
+workspace 2 gaps inner 0
+`
+	cfg, err := Parse(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	expectedVariables := []Variable{{"$mod", "Mod1"}, {"$term", "alacritty"}, {"$terminal", "kitty"}}
+	if !reflect.DeepEqual(cfg.Variables(), expectedVariables) {
+		t.Errorf("Expected variables %+v, got %+v", expectedVariables, cfg.Variables())
+	}
+
+	// Longer names are replaced first so $terminal is not read as $term + "inal"
+	if got := cfg.Expand("$mod+Return exec $terminal"); got != "Mod1+Return exec kitty" {
+		t.Errorf("Expected expanded string, got %s", got)
+	}
+
+	bindings := cfg.Bindings()
+	if len(bindings) != 3 {
+		t.Fatalf("Expected 3 valid bindings, got %d: %+v", len(bindings), bindings)
+	}
+	if bindings[0].Mode != "" || bindings[1].Mode != "resize" || bindings[2].Key != "Escape" {
+		t.Errorf("Expected mode bindings to record their mode, got %+v", bindings)
+	}
+
+	if execs := cfg.Execs(); len(execs) != 2 || !execs[1].Always {
+		t.Errorf("Expected 2 execs, got %+v", execs)
+	}
+	if rules := cfg.ForWindows(); len(rules) != 1 || rules[0].Command != "floating enable" {
+		t.Errorf("Expected 1 for_window rule, got %+v", rules)
+	}
+	if assigns := cfg.Assigns(); len(assigns) != 1 || assigns[0].Target != "2" {
+		t.Errorf("Expected 1 assign rule, got %+v", assigns)
+	}
+	if outputs := cfg.WorkspaceOutputs(); len(outputs) != 1 || outputs[0].Outputs[0] != "HDMI-1" {
+		t.Errorf("Expected 1 workspace output, got %+v", outputs)
+	}
+}
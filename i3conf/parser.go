@@ -0,0 +1,360 @@
+// Package i3conf parses hand-written i3 configuration files.
+//
+// The parser understands the line structure of an i3 config (comments, line
+// continuations, blocks such as mode and bar, include directives) and leaves
+// the meaning of individual statements to the typed helpers in this package.
+package i3conf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Statement is a single directive of an i3 config
+type Statement struct {
+	// File and Line locate the start of the statement
+	File string
+	Line int
+	// Keyword is the first word, such as bindsym, set or mode
+	Keyword string
+	// Args are the remaining words with quotes removed
+	Args []string
+	// Raw is the statement as written, with continuation lines joined
+	Raw string
+	// Block holds the statements between { and } of block statements such as mode and bar
+	Block []Statement
+	// IsBlock is set for statements that open a block, even an empty one
+	IsBlock bool
+
+	tokens []token
+}
+
+// Config is a parsed i3 config
+type Config struct {
+	// Statements are the top-level statements in file order, with included files inlined
+	Statements []Statement
+	// Files lists every file read, starting with the main config
+	Files []string
+}
+
+// RawFrom returns the statement text starting at argument i, as written
+// This keeps the quoting of commands, which have their own syntax
+func (s Statement) RawFrom(i int) string {
+	// tokens[0] is the keyword
+	if i+1 >= len(s.tokens) {
+		return ""
+	}
+	text := s.Raw
+	if s.IsBlock {
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "{"))
+	}
+	return strings.TrimSpace(text[s.tokens[i+1].start:])
+}
+
+// Lines returns the statement formatted as config lines, including its block
+func (s Statement) Lines() []string {
+	if !s.IsBlock {
+		return []string{s.Raw}
+	}
+	lines := []string{s.Raw}
+	for _, child := range s.Block {
+		for _, line := range child.Lines() {
+			lines = append(lines, "\t"+line)
+		}
+	}
+	return append(lines, "}")
+}
+
+// Parse reads an i3 config from r; name is used in error messages
+// Include directives are kept as statements rather than followed
+func Parse(r io.Reader, name string) (*Config, error) {
+	p := &parser{}
+	statements, err := p.parse(r, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Statements: statements, Files: []string{name}}, nil
+}
+
+// ParseFile reads the i3 config at path, following include directives
+// Like i3, each file is only included once
+func ParseFile(path string) (*Config, error) {
+	p := &parser{followIncludes: true, seen: map[string]bool{}}
+	statements, err := p.parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Statements: statements, Files: p.files}, nil
+}
+
+// parser holds state shared between included files
+type parser struct {
+	followIncludes bool
+	seen           map[string]bool
+	files          []string
+}
+
+// parseFile parses a single file, recording it as seen
+func (p *parser) parseFile(path string) ([]Statement, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	p.seen[absolute] = true
+	p.files = append(p.files, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open i3 config %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return p.parse(file, path, true)
+}
+
+// parse reads statements from r
+func (p *parser) parse(r io.Reader, name string, isFile bool) ([]Statement, error) {
+	lines, err := logicalLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read i3 config %s: %w", name, err)
+	}
+	i := 0
+	return p.parseBlock(lines, &i, name, isFile, nil)
+}
+
+// parseBlock parses statements up to the brace closing parent, or to the end
+// of the input when parent is nil
+func (p *parser) parseBlock(lines []logicalLine, i *int, name string, isFile bool, parent *Statement) ([]Statement, error) {
+	var statements []Statement
+	for *i < len(lines) {
+		line := lines[*i]
+		*i++
+
+		text := strings.TrimSpace(line.text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if text == "}" {
+			if parent == nil {
+				return nil, fmt.Errorf("%s:%d: unexpected '}'", name, line.number)
+			}
+			return statements, nil
+		}
+
+		tokens, err := tokenize(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line.number, err)
+		}
+		statement := Statement{
+			File:    name,
+			Line:    line.number,
+			Keyword: tokens[0].value,
+			Raw:     text,
+			tokens:  tokens,
+		}
+
+		if tokens[len(tokens)-1].text == "{" {
+			if len(tokens) == 1 {
+				return nil, fmt.Errorf("%s:%d: block without a name", name, line.number)
+			}
+			statement.IsBlock = true
+			statement.tokens = tokens[:len(tokens)-1]
+			statement.Args = tokenValues(statement.tokens[1:])
+			block, err := p.parseBlock(lines, i, name, isFile, &statement)
+			if err != nil {
+				return nil, err
+			}
+			statement.Block = block
+			statements = append(statements, statement)
+			continue
+		}
+		statement.Args = tokenValues(tokens[1:])
+
+		if statement.Keyword == "include" && p.followIncludes {
+			if parent != nil {
+				return nil, fmt.Errorf("%s:%d: include is not allowed inside a block", name, line.number)
+			}
+			included, err := p.include(statement, name, isFile)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, included...)
+			continue
+		}
+
+		statements = append(statements, statement)
+	}
+
+	if parent != nil {
+		return nil, fmt.Errorf("%s:%d: block '%s' is not closed", name, parent.Line, parent.Raw)
+	}
+	return statements, nil
+}
+
+// include parses the files matched by an include directive
+func (p *parser) include(statement Statement, name string, isFile bool) ([]Statement, error) {
+	pattern := os.ExpandEnv(statement.RawFrom(0))
+	if strings.HasPrefix(pattern, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to get home directory: %w", name, statement.Line, err)
+		}
+		pattern = filepath.Join(homeDir, pattern[2:])
+	}
+	// Relative paths are resolved against the directory of the including file
+	if !filepath.IsAbs(pattern) && isFile {
+		pattern = filepath.Join(filepath.Dir(name), pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: invalid include pattern %s: %w", name, statement.Line, pattern, err)
+	}
+	sort.Strings(matches)
+
+	var statements []Statement
+	for _, match := range matches {
+		absolute, err := filepath.Abs(match)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to resolve %s: %w", name, statement.Line, match, err)
+		}
+		if p.seen[absolute] {
+			continue
+		}
+		included, err := p.parseFile(match)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, included...)
+	}
+	return statements, nil
+}
+
+// logicalLine is a config line with continuations joined
+type logicalLine struct {
+	number int
+	text   string
+}
+
+// logicalLines splits r into lines, joining lines that end in a backslash with the
+// next one like i3 does; leading whitespace of the continuation is dropped
+func logicalLines(r io.Reader) ([]logicalLine, error) {
+	var lines []logicalLine
+	var current *logicalLine
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if current != nil {
+			current.text += strings.TrimLeft(text, " \t")
+		} else {
+			lines = append(lines, logicalLine{number: number, text: text})
+			current = &lines[len(lines)-1]
+		}
+
+		if strings.HasSuffix(current.text, "\\") && !strings.HasPrefix(strings.TrimSpace(current.text), "#") {
+			current.text = strings.TrimSuffix(current.text, "\\")
+			continue
+		}
+		current = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// token is a word of a statement
+type token struct {
+	// text is the word as written, value has quotes removed
+	text  string
+	value string
+	// start is the byte offset of the word within the statement
+	start int
+}
+
+// tokenize splits a statement into words
+// Double-quoted strings and [criteria] lists are kept together as one word
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		switch s[i] {
+		case '"':
+			end, err := closingQuote(s, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end + 1
+			value := strings.ReplaceAll(s[start+1:end], `\"`, `"`)
+			tokens = append(tokens, token{text: s[start:i], value: value, start: start})
+			continue
+		case '[':
+			end, err := closingBracket(s, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end + 1
+		default:
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				i++
+			}
+		}
+		tokens = append(tokens, token{text: s[start:i], value: s[start:i], start: start})
+	}
+	return tokens, nil
+}
+
+// closingBracket returns the index of the bracket closing the criteria opened at s[open]
+func closingBracket(s string, open int) (int, error) {
+	for i := open + 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			end, err := closingQuote(s, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		case ']':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated criteria: %s", s[open:])
+}
+
+// closingQuote returns the index of the quote closing the string opened at s[open]
+func closingQuote(s string, open int) (int, error) {
+	for i := open + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted string: %s", s[open:])
+}
+
+// tokenValues returns the unquoted values of tokens
+func tokenValues(tokens []token) []string {
+	values := make([]string, len(tokens))
+	for i, t := range tokens {
+		values[i] = t.value
+	}
+	return values
+}
@@ -0,0 +1,275 @@
+package i3conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_Statements(t *testing.T) {
+	input := `# i3 config file (v4)
+
+set $mod Mod4
+  font pango:DejaVu Sans Mono 8
+bindsym $mod+Return exec i3-sensible-terminal
+`
+	cfg, err := Parse(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	if len(cfg.Statements) != 3 {
+		t.Fatalf("Expected 3 statements, got %d", len(cfg.Statements))
+	}
+
+	tests := []struct {
+		keyword string
+		args    []string
+		line    int
+		raw     string
+	}{
+		{"set", []string{"$mod", "Mod4"}, 3, "set $mod Mod4"},
+		{"font", []string{"pango:DejaVu", "Sans", "Mono", "8"}, 4, "font pango:DejaVu Sans Mono 8"},
+		{"bindsym", []string{"$mod+Return", "exec", "i3-sensible-terminal"}, 5, "bindsym $mod+Return exec i3-sensible-terminal"},
+	}
+	for i, tt := range tests {
+		s := cfg.Statements[i]
+		if s.Keyword != tt.keyword {
+			t.Errorf("Statement %d: expected keyword %s, got %s", i, tt.keyword, s.Keyword)
+		}
+		if !reflect.DeepEqual(s.Args, tt.args) {
+			t.Errorf("Statement %d: expected args %v, got %v", i, tt.args, s.Args)
+		}
+		if s.Line != tt.line {
+			t.Errorf("Statement %d: expected line %d, got %d", i, tt.line, s.Line)
+		}
+		if s.Raw != tt.raw {
+			t.Errorf("Statement %d: expected raw %q, got %q", i, tt.raw, s.Raw)
+		}
+		if s.File != "config" {
+			t.Errorf("Statement %d: expected file config, got %s", i, s.File)
+		}
+	}
+}
+
+func TestParse_Tokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "quoted string",
+			input:    `mode "resize mode" {` + "\n}",
+			expected: []string{"resize mode"},
+		},
+		{
+			name:     "escaped quote",
+			input:    `bindsym x exec "echo \"hi\""`,
+			expected: []string{"x", "exec", `echo "hi"`},
+		},
+		{
+			name:     "criteria with spaces",
+			input:    `for_window [class="Firefox" title="Library (x)"] floating enable`,
+			expected: []string{`[class="Firefox" title="Library (x)"]`, "floating", "enable"},
+		},
+		{
+			name:     "criteria with bracket in quotes",
+			input:    `for_window [title="a]b"] kill`,
+			expected: []string{`[title="a]b"]`, "kill"},
+		},
+		{
+			name:     "tabs",
+			input:    "bindsym\tx\tkill",
+			expected: []string{"x", "kill"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(strings.NewReader(tt.input), "config")
+			if err != nil {
+				t.Fatalf("Failed to parse config: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Statements[0].Args, tt.expected) {
+				t.Errorf("Expected args %q, got %q", tt.expected, cfg.Statements[0].Args)
+			}
+		})
+	}
+}
+
+func TestParse_LineContinuation(t *testing.T) {
+	input := `bindsym $mod+x \
+    exec --no-startup-id \
+    notify-send hello
+# a comment ending in a backslash \
+set $a b
+`
+	cfg, err := Parse(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	if len(cfg.Statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(cfg.Statements))
+	}
+	if cfg.Statements[0].Raw != "bindsym $mod+x exec --no-startup-id notify-send hello" {
+		t.Errorf("Expected continuation lines to be joined, got %q", cfg.Statements[0].Raw)
+	}
+	if cfg.Statements[0].Line != 1 {
+		t.Errorf("Expected statement to start on line 1, got %d", cfg.Statements[0].Line)
+	}
+	if cfg.Statements[1].Line != 5 {
+		t.Errorf("Expected comment not to continue, got set on line %d", cfg.Statements[1].Line)
+	}
+}
+
+func TestParse_Blocks(t *testing.T) {
+	input := `mode "resize" {
+	bindsym h resize shrink width 10 px
+	bindsym Escape mode "default"
+}
+bar {
+	status_command i3status
+	colors {
+		background #000000
+	}
+}
+mode "empty" {
+}
+`
+	cfg, err := Parse(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	if len(cfg.Statements) != 3 {
+		t.Fatalf("Expected 3 statements, got %d", len(cfg.Statements))
+	}
+
+	mode := cfg.Statements[0]
+	if !mode.IsBlock || len(mode.Block) != 2 {
+		t.Errorf("Expected mode block with 2 statements, got %+v", mode)
+	}
+	if !reflect.DeepEqual(mode.Args, []string{"resize"}) {
+		t.Errorf("Expected mode args [resize], got %v", mode.Args)
+	}
+
+	bar := cfg.Statements[1]
+	if len(bar.Block) != 2 || !bar.Block[1].IsBlock || len(bar.Block[1].Block) != 1 {
+		t.Errorf("Expected nested colors block in bar, got %+v", bar.Block)
+	}
+
+	expectedLines := []string{
+		"bar {",
+		"\tstatus_command i3status",
+		"\tcolors {",
+		"\t\tbackground #000000",
+		"\t}",
+		"}",
+	}
+	if !reflect.DeepEqual(bar.Lines(), expectedLines) {
+		t.Errorf("Expected lines %q, got %q", expectedLines, bar.Lines())
+	}
+
+	empty := cfg.Statements[2]
+	if !empty.IsBlock || len(empty.Block) != 0 {
+		t.Errorf("Expected empty block, got %+v", empty)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"unclosed block", "bar {\n\tposition top\n", "config:1: block 'bar {' is not closed"},
+		{"unexpected brace", "set $a b\n}\n", "config:2: unexpected '}'"},
+		{"unterminated quote", `bindsym x exec "echo`, "config:1: unterminated quoted string"},
+		{"unterminated criteria", `for_window [class="x" kill`, "config:1: unterminated criteria"},
+		{"block without name", "{\n}\n", "config:1: block without a name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input), "config")
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParse_IncludeNotFollowed(t *testing.T) {
+	cfg, err := Parse(strings.NewReader("include ~/.config/i3/*.conf\n"), "config")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if len(cfg.Statements) != 1 || cfg.Statements[0].Keyword != "include" {
+		t.Errorf("Expected include statement to be kept, got %+v", cfg.Statements)
+	}
+}
+
+func TestParseFile_Include(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "i3conf_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"config":             "set $mod Mod4\ninclude conf.d/*.conf\nbindsym $mod+q kill\n",
+		"conf.d/10-a.conf":   "exec a\n",
+		"conf.d/20-b.conf":   "exec b\n# cycles are ignored\ninclude ../config\ninclude 10-a.conf\n",
+		"conf.d/ignored.txt": "exec ignored\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg, err := ParseFile(filepath.Join(tempDir, "config"))
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	var raws []string
+	for _, s := range cfg.Statements {
+		raws = append(raws, s.Raw)
+	}
+	expected := []string{"set $mod Mod4", "exec a", "exec b", "bindsym $mod+q kill"}
+	if !reflect.DeepEqual(raws, expected) {
+		t.Errorf("Expected statements %q, got %q", expected, raws)
+	}
+	if len(cfg.Files) != 3 {
+		t.Errorf("Expected 3 files to be read, got %v", cfg.Files)
+	}
+	if cfg.Statements[1].File != filepath.Join(tempDir, "conf.d", "10-a.conf") {
+		t.Errorf("Expected included statement to record its file, got %s", cfg.Statements[1].File)
+	}
+
+	// Test: include inside a block is rejected
+	blockPath := filepath.Join(tempDir, "block")
+	if err := os.WriteFile(blockPath, []byte("bar {\n\tinclude config\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := ParseFile(blockPath); err == nil {
+		t.Error("Expected error for include inside a block")
+	}
+
+	// Test: Missing file
+	if _, err := ParseFile(filepath.Join(tempDir, "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
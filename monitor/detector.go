@@ -3,6 +3,7 @@ package monitor
 import (
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 )
 
@@ -13,12 +14,31 @@ type MonitorConfig struct {
 	MinMonitors      int      `yaml:"min_monitors"`
 }
 
+// Monitor roles that layouts and rules use to refer to detected monitors
+const (
+	RolePrimary = "primary_display"
+	RoleLeft    = "left_display"
+	RoleRight   = "right_display"
+)
+
+// Roles lists the monitor roles accepted by GetMonitorByRole
+var Roles = []string{RolePrimary, RoleLeft, RoleRight}
+
 // DetectedMonitors represents the monitors found on the system
 type DetectedMonitors struct {
-	Primary string
-	Left    string
-	Right   string
-	All     []string
+	Primary string   `json:"primary" yaml:"primary"`
+	Left    string   `json:"left" yaml:"left"`
+	Right   string   `json:"right" yaml:"right"`
+	All     []string `json:"all" yaml:"all"`
+	// DPI holds the pixel density of outputs whose physical size is known
+	DPI map[string]float64 `json:"dpi,omitempty" yaml:"dpi,omitempty"`
+	// Disabled lists outputs with a monitor connected that no CRTC drives, e.g.
+	// one xrandr has not been told to use; they get no role
+	Disabled []string `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// Stale lists outputs still driven by a CRTC after their monitor was unplugged
+	Stale []string `json:"stale,omitempty" yaml:"stale,omitempty"`
+	// Warnings describe outputs that were skipped because they could not be queried
+	Warnings []string `json:"-" yaml:"-"`
 }
 
 // Detector handles monitor detection operations
@@ -102,18 +122,54 @@ func (d *Detector) padWithDummyMonitors(monitors []string) []string {
 	return result
 }
 
-// GetMonitorByRole returns the monitor name for a given role (primary_display, left_display, right_display)
-func (dm *DetectedMonitors) GetMonitorByRole(role string) string {
+// Pad adds dummy monitors until there are at least minMonitors, and fills the
+// left and right roles that are not set with monitors that have no role yet
+func (dm *DetectedMonitors) Pad(dummyMonitors []string, minMonitors int) {
+	for _, dummy := range dummyMonitors {
+		if len(dm.All) >= minMonitors {
+			break
+		}
+		if !slices.Contains(dm.All, dummy) {
+			dm.All = append(dm.All, dummy)
+		}
+	}
+
+	for _, name := range dm.All {
+		if slices.Contains([]string{dm.Primary, dm.Left, dm.Right}, name) {
+			continue
+		}
+		if dm.Left == "" {
+			dm.Left = name
+		} else if dm.Right == "" {
+			dm.Right = name
+		}
+	}
+}
+
+// GetMonitorByRole returns the monitor name for a given role (primary_display, left_display, right_display),
+// empty when no monitor has the role
+func (dm *DetectedMonitors) GetMonitorByRole(role string) (string, error) {
+	if dm == nil {
+		return "", fmt.Errorf("no monitors detected to resolve role %s", role)
+	}
 	switch role {
-	case "primary_display":
-		return dm.Primary
-	case "left_display":
-		return dm.Left
-	case "right_display":
-		return dm.Right
+	case RolePrimary:
+		return dm.Primary, nil
+	case RoleLeft:
+		return dm.Left, nil
+	case RoleRight:
+		return dm.Right, nil
 	default:
-		return ""
+		return "", fmt.Errorf("unknown monitor role: %s (valid options: %s)", role, strings.Join(Roles, ", "))
+	}
+}
+
+// PrimaryDPI returns the pixel density of the primary monitor, 0 if unknown
+func (dm *DetectedMonitors) PrimaryDPI() float64 {
+	if dm == nil {
+		return 0
 	}
+	return dm.DPI[dm.Primary]
 }
 
 // String returns a string representation of the detected monitors
@@ -1,6 +1,8 @@
 package monitor
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -72,21 +74,37 @@ func TestDetectedMonitors_GetMonitorByRole(t *testing.T) {
 	tests := []struct {
 		role     string
 		expected string
+		errorMsg string
 	}{
-		{"primary_display", "eDP-1"},
-		{"left_display", "HDMI-1"},
-		{"right_display", "DP-1"},
-		{"invalid_role", ""},
+		{role: "primary_display", expected: "eDP-1"},
+		{role: "left_display", expected: "HDMI-1"},
+		{role: "right_display", expected: "DP-1"},
+		{role: "invalid_role", errorMsg: "unknown monitor role: invalid_role"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.role, func(t *testing.T) {
-			result := monitors.GetMonitorByRole(tt.role)
+			result, err := monitors.GetMonitorByRole(tt.role)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if result != tt.expected {
 				t.Errorf("expected %s, got %s", tt.expected, result)
 			}
 		})
 	}
+
+	// Test: No detected monitors
+	var none *DetectedMonitors
+	if _, err := none.GetMonitorByRole("primary_display"); err == nil || err.Error() != "no monitors detected to resolve role primary_display" {
+		t.Errorf("expected error for nil monitors, got %v", err)
+	}
 }
 
 func TestDetector_DetectMonitors_Assignment(t *testing.T) {
@@ -121,3 +139,41 @@ func TestDetector_DetectMonitors_Assignment(t *testing.T) {
 		t.Errorf("expected Right = %s, got %s", expected.Right, monitors.Right)
 	}
 }
+
+func TestDetectedMonitors_Pad(t *testing.T) {
+	tests := []struct {
+		name     string
+		monitors DetectedMonitors
+		expected DetectedMonitors
+	}{
+		{
+			name:     "single monitor",
+			monitors: DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}},
+			expected: DetectedMonitors{Primary: "eDP-1", Left: "dummy1", Right: "dummy2", All: []string{"eDP-1", "dummy1", "dummy2"}},
+		},
+		{
+			name:     "roles kept",
+			monitors: DetectedMonitors{Primary: "eDP-1", Right: "DP-2", All: []string{"eDP-1", "DP-2"}},
+			expected: DetectedMonitors{Primary: "eDP-1", Left: "dummy1", Right: "DP-2", All: []string{"eDP-1", "DP-2", "dummy1"}},
+		},
+		{
+			name:     "dummies already present",
+			monitors: DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1", "dummy1"}},
+			expected: DetectedMonitors{Primary: "eDP-1", Left: "dummy1", Right: "dummy2", All: []string{"eDP-1", "dummy1", "dummy2"}},
+		},
+		{
+			name:     "enough monitors",
+			monitors: DetectedMonitors{Primary: "DP-1", Left: "DP-2", Right: "DP-3", All: []string{"DP-1", "DP-2", "DP-3"}},
+			expected: DetectedMonitors{Primary: "DP-1", Left: "DP-2", Right: "DP-3", All: []string{"DP-1", "DP-2", "DP-3"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitors.Pad([]string{"dummy1", "dummy2"}, 3)
+			if !reflect.DeepEqual(tt.monitors, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, tt.monitors)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// edidHeader is the fixed 8-byte pattern every EDID base block starts with
+var edidHeader = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// EDIDInfo holds the identity of a monitor as reported in its EDID block
+type EDIDInfo struct {
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	ProductCode  uint16 `json:"product_code" yaml:"product_code"`
+	SerialNumber uint32 `json:"serial_number,omitempty" yaml:"serial_number,omitempty"`
+	Serial       string `json:"serial,omitempty" yaml:"serial,omitempty"`
+	Model        string `json:"model,omitempty" yaml:"model,omitempty"`
+	Week         int    `json:"week,omitempty" yaml:"week,omitempty"`
+	Year         int    `json:"year" yaml:"year"`
+}
+
+// ParseEDID extracts the monitor identity from a raw EDID base block
+func ParseEDID(data []byte) (*EDIDInfo, error) {
+	if len(data) < 128 {
+		return nil, fmt.Errorf("EDID too short: %d bytes", len(data))
+	}
+	if !bytes.Equal(data[:8], edidHeader) {
+		return nil, fmt.Errorf("invalid EDID header")
+	}
+
+	// Manufacturer ID is three 5-bit letters packed big-endian, 'A' = 1
+	id := binary.BigEndian.Uint16(data[8:10])
+	manufacturer := string([]byte{
+		byte('A' - 1 + (id>>10)&0x1f),
+		byte('A' - 1 + (id>>5)&0x1f),
+		byte('A' - 1 + id&0x1f),
+	})
+
+	info := &EDIDInfo{
+		Manufacturer: manufacturer,
+		ProductCode:  binary.LittleEndian.Uint16(data[10:12]),
+		SerialNumber: binary.LittleEndian.Uint32(data[12:16]),
+		Year:         int(data[17]) + 1990,
+	}
+	// Week 0xff marks the year as the model year rather than the manufacture date
+	if week := int(data[16]); week > 0 && week <= 54 {
+		info.Week = week
+	}
+
+	// Four 18-byte display descriptors may hold the model name and serial string
+	for offset := 54; offset+18 <= 126; offset += 18 {
+		descriptor := data[offset : offset+18]
+		if descriptor[0] != 0 || descriptor[1] != 0 {
+			continue // detailed timing descriptor
+		}
+		text := descriptorText(descriptor[5:18])
+		switch descriptor[3] {
+		case 0xfc:
+			info.Model = text
+		case 0xff:
+			info.Serial = text
+		}
+	}
+
+	return info, nil
+}
+
+// descriptorText decodes a text descriptor, which is terminated by a newline and space padded
+func descriptorText(data []byte) string {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// String returns a short human readable identity such as "DEL U2720Q (serial ABC123)"
+func (e *EDIDInfo) String() string {
+	name := e.Manufacturer
+	if e.Model != "" {
+		name += " " + e.Model
+	} else {
+		name += fmt.Sprintf(" 0x%04x", e.ProductCode)
+	}
+	if e.Serial != "" {
+		name += fmt.Sprintf(" (serial %s)", e.Serial)
+	}
+	return name
+}
@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"testing"
+)
+
+// buildEDID returns a minimal EDID base block with the given identity
+func buildEDID(model, serial string) []byte {
+	data := make([]byte, 128)
+	copy(data, edidHeader)
+
+	// "DEL" packed as three 5-bit letters
+	data[8], data[9] = 0x10, 0xac
+	// Product code 0xa0c4 and serial number 0x01020304, little-endian
+	data[10], data[11] = 0xc4, 0xa0
+	data[12], data[13], data[14], data[15] = 0x04, 0x03, 0x02, 0x01
+	// Week 10 of 2020
+	data[16], data[17] = 10, 30
+
+	// First descriptor is a detailed timing block
+	data[54], data[55] = 0x01, 0x1d
+
+	writeDescriptor := func(offset int, tag byte, text string) {
+		data[offset+3] = tag
+		field := []byte(text + "\n            ")[:13]
+		copy(data[offset+5:offset+18], field)
+	}
+	if model != "" {
+		writeDescriptor(72, 0xfc, model)
+	}
+	if serial != "" {
+		writeDescriptor(90, 0xff, serial)
+	}
+
+	return data
+}
+
+func TestParseEDID(t *testing.T) {
+	info, err := ParseEDID(buildEDID("DELL U2720Q", "ABC123"))
+	if err != nil {
+		t.Fatalf("Failed to parse EDID: %v", err)
+	}
+
+	if info.Manufacturer != "DEL" {
+		t.Errorf("Expected manufacturer DEL, got %s", info.Manufacturer)
+	}
+	if info.ProductCode != 0xa0c4 {
+		t.Errorf("Expected product code 0xa0c4, got 0x%04x", info.ProductCode)
+	}
+	if info.SerialNumber != 0x01020304 {
+		t.Errorf("Expected serial number 0x01020304, got 0x%08x", info.SerialNumber)
+	}
+	if info.Week != 10 || info.Year != 2020 {
+		t.Errorf("Expected week 10 of 2020, got week %d of %d", info.Week, info.Year)
+	}
+	if info.Model != "DELL U2720Q" {
+		t.Errorf("Expected model 'DELL U2720Q', got '%s'", info.Model)
+	}
+	if info.Serial != "ABC123" {
+		t.Errorf("Expected serial 'ABC123', got '%s'", info.Serial)
+	}
+
+	if s := info.String(); s != "DEL DELL U2720Q (serial ABC123)" {
+		t.Errorf("Unexpected string representation: %s", s)
+	}
+}
+
+func TestParseEDID_WithoutDescriptors(t *testing.T) {
+	info, err := ParseEDID(buildEDID("", ""))
+	if err != nil {
+		t.Fatalf("Failed to parse EDID: %v", err)
+	}
+
+	if info.Model != "" || info.Serial != "" {
+		t.Errorf("Expected no model or serial, got '%s' / '%s'", info.Model, info.Serial)
+	}
+	if s := info.String(); s != "DEL 0xa0c4" {
+		t.Errorf("Unexpected string representation: %s", s)
+	}
+}
+
+func TestParseEDID_Invalid(t *testing.T) {
+	if _, err := ParseEDID(make([]byte, 64)); err == nil {
+		t.Error("Expected error for truncated EDID")
+	}
+
+	if _, err := ParseEDID(make([]byte, 128)); err == nil {
+		t.Error("Expected error for missing EDID header")
+	}
+}
+
+func TestRefreshRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		dotClock   uint32
+		hTotal     uint16
+		vTotal     uint16
+		interlace  bool
+		doubleScan bool
+		expected   float64
+	}{
+		{"1080p60", 148500000, 2200, 1125, false, false, 60},
+		{"1080p59.94", 148352000, 2200, 1125, false, false, 59.94},
+		{"1080i", 74250000, 2200, 1125, true, false, 60},
+		{"double scan", 25175000, 800, 525, false, true, 29.97},
+		{"no timings", 0, 0, 0, false, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := refreshRate(tt.dotClock, tt.hTotal, tt.vTotal, tt.interlace, tt.doubleScan); result != tt.expected {
+				t.Errorf("refreshRate() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputInfo_DPI(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   OutputInfo
+		expected float64
+	}{
+		{
+			name:     "laptop panel",
+			output:   OutputInfo{CurrentMode: &ModeInfo{Width: 1920, Height: 1080}, WidthMM: 344, HeightMM: 194},
+			expected: 141.7,
+		},
+		{
+			name:     "4K laptop panel",
+			output:   OutputInfo{CurrentMode: &ModeInfo{Width: 3840, Height: 2160}, WidthMM: 344, HeightMM: 194},
+			expected: 283.4,
+		},
+		{
+			name:     "rotated",
+			output:   OutputInfo{CurrentMode: &ModeInfo{Width: 1080, Height: 1920}, WidthMM: 344, HeightMM: 194},
+			expected: 141.7,
+		},
+		{
+			name: "disabled output uses preferred mode",
+			output: OutputInfo{
+				Modes:   []ModeInfo{{Width: 1280, Height: 720}, {Width: 1920, Height: 1080, Preferred: true}},
+				WidthMM: 527, HeightMM: 296,
+			},
+			expected: 92.6,
+		},
+		{
+			name:     "unknown physical size",
+			output:   OutputInfo{CurrentMode: &ModeInfo{Width: 1920, Height: 1080}},
+			expected: 0,
+		},
+		{
+			name:     "no mode",
+			output:   OutputInfo{WidthMM: 344, HeightMM: 194},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.output.DPI(); result != tt.expected {
+				t.Errorf("DPI() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
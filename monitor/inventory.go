@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+)
+
+// Connection states reported for an output
+const (
+	ConnectionConnected    = "connected"
+	ConnectionDisconnected = "disconnected"
+	ConnectionUnknown      = "unknown"
+)
+
+// DisplayInfo is the full inventory of an X display as seen through RandR
+type DisplayInfo struct {
+	Display      string       `json:"display" yaml:"display"`
+	ScreenWidth  int          `json:"screen_width" yaml:"screen_width"`
+	ScreenHeight int          `json:"screen_height" yaml:"screen_height"`
+	Outputs      []OutputInfo `json:"outputs" yaml:"outputs"`
+}
+
+// OutputInfo describes a single RandR output
+type OutputInfo struct {
+	Name       string `json:"name" yaml:"name"`
+	Connection string `json:"connection" yaml:"connection"`
+	Primary    bool   `json:"primary" yaml:"primary"`
+	// Geometry is the area scanned out by the output's CRTC, nil if the output is disabled
+	Geometry *Geometry `json:"geometry,omitempty" yaml:"geometry,omitempty"`
+	// CurrentMode is the mode the output's CRTC is driving, nil if disabled
+	CurrentMode *ModeInfo  `json:"current_mode,omitempty" yaml:"current_mode,omitempty"`
+	Modes       []ModeInfo `json:"modes,omitempty" yaml:"modes,omitempty"`
+	WidthMM     int        `json:"width_mm" yaml:"width_mm"`
+	HeightMM    int        `json:"height_mm" yaml:"height_mm"`
+	EDID        *EDIDInfo  `json:"edid,omitempty" yaml:"edid,omitempty"`
+	// Error is set when querying the output or its CRTC failed, leaving the entry incomplete
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Geometry is the position, size and rotation of an output within the X screen
+type Geometry struct {
+	X        int    `json:"x" yaml:"x"`
+	Y        int    `json:"y" yaml:"y"`
+	Width    int    `json:"width" yaml:"width"`
+	Height   int    `json:"height" yaml:"height"`
+	Rotation string `json:"rotation" yaml:"rotation"`
+}
+
+// ModeInfo describes a display mode supported by an output
+type ModeInfo struct {
+	Name      string  `json:"name" yaml:"name"`
+	Width     int     `json:"width" yaml:"width"`
+	Height    int     `json:"height" yaml:"height"`
+	Refresh   float64 `json:"refresh" yaml:"refresh"`
+	Preferred bool    `json:"preferred,omitempty" yaml:"preferred,omitempty"`
+}
+
+// String returns the mode as WIDTHxHEIGHT@REFRESH
+func (m ModeInfo) String() string {
+	return fmt.Sprintf("%dx%d@%.2f", m.Width, m.Height, m.Refresh)
+}
+
+// Enabled reports whether the output is currently driven by a CRTC
+func (o OutputInfo) Enabled() bool {
+	return o.Geometry != nil
+}
+
+// DPI returns the pixel density of the output in its current mode, or of its
+// preferred mode when disabled; it is 0 when the physical size is unknown
+func (o OutputInfo) DPI() float64 {
+	mode := o.CurrentMode
+	for i := 0; mode == nil && i < len(o.Modes); i++ {
+		if o.Modes[i].Preferred {
+			mode = &o.Modes[i]
+		}
+	}
+	if mode == nil {
+		return 0
+	}
+	return computeDPI(mode.Width, mode.Height, o.WidthMM, o.HeightMM)
+}
+
+// computeDPI returns the pixel density along the diagonal, which does not depend
+// on rotation, rounded to one decimal; it is 0 when the physical size is unknown
+func computeDPI(width, height, widthMM, heightMM int) float64 {
+	if width <= 0 || height <= 0 || widthMM <= 0 || heightMM <= 0 {
+		return 0
+	}
+	pixels := math.Hypot(float64(width), float64(height))
+	inches := math.Hypot(float64(widthMM), float64(heightMM)) / 25.4
+	return math.Round(pixels/inches*10) / 10
+}
+
+// refreshRate computes the vertical refresh rate in Hz from mode timings,
+// rounded to two decimals
+func refreshRate(dotClock uint32, hTotal, vTotal uint16, interlace, doubleScan bool) float64 {
+	if hTotal == 0 || vTotal == 0 {
+		return 0
+	}
+	lines := float64(vTotal)
+	if doubleScan {
+		lines *= 2
+	}
+	if interlace {
+		lines /= 2
+	}
+	rate := float64(dotClock) / (float64(hTotal) * lines)
+	return math.Round(rate*100) / 100
+}
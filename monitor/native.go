@@ -4,9 +4,7 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/BurntSushi/xgb"
 	"github.com/BurntSushi/xgb/randr"
-	"github.com/BurntSushi/xgb/xproto"
 )
 
 // NativeDetector implements monitor detection using native X11 RandR bindings
@@ -14,6 +12,8 @@ type NativeDetector struct {
 	display       string
 	dummyMonitors []string
 	minMonitors   int
+	// randr replaces the connection to the display when set
+	randr RandR
 }
 
 // NewNativeDetector creates a new native detector
@@ -32,91 +32,113 @@ func NewNativeDetector(display string, dummyMonitors []string, minMonitors int)
 	}
 }
 
-// DetectMonitors detects connected monitors using native X11 RandR calls
-func (nd *NativeDetector) DetectMonitors() (*DetectedMonitors, error) {
-	// Connect to X server
-	conn, err := xgb.NewConnDisplay(nd.display)
+// SetRandR makes the detector query r instead of connecting to the X display,
+// e.g. a FakeRandR replaying a fixture. The caller remains responsible for closing it
+func (nd *NativeDetector) SetRandR(r RandR) {
+	nd.randr = r
+}
+
+// connect returns the RandR source to query and a function releasing it
+func (nd *NativeDetector) connect() (RandR, func(), error) {
+	if nd.randr != nil {
+		return nd.randr, func() {}, nil
+	}
+	r, err := DialRandR(nd.display)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to X display %s: %w", nd.display, err)
+		return nil, nil, err
 	}
-	defer conn.Close()
+	return r, r.Close, nil
+}
 
-	// Initialize RandR extension
-	err = randr.Init(conn)
+// DetectMonitors detects the active monitors using native X11 RandR calls.
+// Only outputs that are both connected and driven by a CRTC get roles; connected
+// but disabled and disconnected but still enabled outputs are reported apart, and
+// outputs that cannot be queried, or whose CRTC cannot be, are skipped with a warning
+func (nd *NativeDetector) DetectMonitors() (*DetectedMonitors, error) {
+	r, release, err := nd.connect()
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize RandR extension: %w", err)
+		return nil, err
 	}
-
-	// Get root window
-	setup := xproto.Setup(conn)
-	root := setup.DefaultScreen(conn).Root
+	defer release()
 
 	// Get screen resources
-	resources, err := randr.GetScreenResources(conn, root).Reply()
+	resources, err := r.Resources()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get screen resources: %w", err)
 	}
 
-	var connectedOutputs []string
-	var primaryOutput string
+	result := &DetectedMonitors{DPI: map[string]float64{}}
 
-	// Query each output to check if it's connected
+	primary, err := r.Primary()
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to get the primary output: %v", err))
+	}
+
+	var activeOutputs []string
 	for _, output := range resources.Outputs {
-		outputInfo, err := randr.GetOutputInfo(conn, output, resources.ConfigTimestamp).Reply()
+		outputInfo, err := r.OutputInfo(output)
 		if err != nil {
-			continue // Skip on error
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped output %d: %v", output, err))
+			continue
 		}
+		name := outputInfo.Name
+		connected := outputInfo.Connection == ConnectionConnected
 
-		// Check if output is connected
-		if outputInfo.Connection == randr.ConnectionConnected {
-			name := string(outputInfo.Name)
-			connectedOutputs = append(connectedOutputs, name)
-
-			// Check if this is the primary output
-			if outputInfo.Crtc != 0 {
-				crtcInfo, err := randr.GetCrtcInfo(conn, outputInfo.Crtc, resources.ConfigTimestamp).Reply()
-				if err == nil && len(crtcInfo.Outputs) > 0 {
-					// Check if this CRTC is the primary
-					primary, err := randr.GetOutputPrimary(conn, root).Reply()
-					if err == nil && primary.Output == output {
-						primaryOutput = name
-					}
-				}
+		// An output is enabled while a CRTC scans out a mode to it
+		var crtcInfo *RandRCrtc
+		enabled := outputInfo.Crtc != 0
+		if enabled {
+			crtcInfo, err = r.CrtcInfo(outputInfo.Crtc)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("skipped output %s: failed to get CRTC info: %v", name, err))
+				continue
+			}
+			enabled = crtcInfo.Mode != 0
+		}
+
+		switch {
+		case connected && enabled:
+			activeOutputs = append(activeOutputs, name)
+			if output == primary {
+				result.Primary = name
 			}
+			if dpi := computeDPI(int(crtcInfo.Width), int(crtcInfo.Height), int(outputInfo.WidthMM), int(outputInfo.HeightMM)); dpi > 0 {
+				result.DPI[name] = dpi
+			}
+		case connected:
+			result.Disabled = append(result.Disabled, name)
+		case enabled:
+			result.Stale = append(result.Stale, name)
 		}
 	}
 
-	// If no primary output was detected, use the first connected output
-	if primaryOutput == "" && len(connectedOutputs) > 0 {
-		primaryOutput = connectedOutputs[0]
+	// Without an active primary output, use the first active output
+	if result.Primary == "" && len(activeOutputs) > 0 {
+		result.Primary = activeOutputs[0]
 	}
 
 	// Sort outputs for consistent ordering
-	sort.Strings(connectedOutputs)
+	sort.Strings(activeOutputs)
+	sort.Strings(result.Disabled)
+	sort.Strings(result.Stale)
 
 	// Pad with dummy monitors if needed
-	paddedOutputs := nd.padWithDummyMonitors(connectedOutputs)
+	result.All = nd.padWithDummyMonitors(activeOutputs)
 
-	// Assign roles - same logic as before
-	var left, right string
-	if len(paddedOutputs) >= 2 {
-		left = paddedOutputs[1] // Second monitor as left
-	}
-	if len(paddedOutputs) >= 3 {
-		right = paddedOutputs[2] // Third monitor as right
+	// Ensure primary is set to first monitor if not detected
+	if result.Primary == "" && len(result.All) > 0 {
+		result.Primary = result.All[0]
 	}
 
-	// Ensure primary is set to first monitor if not detected
-	if primaryOutput == "" && len(paddedOutputs) > 0 {
-		primaryOutput = paddedOutputs[0]
+	// Assign roles - same logic as before
+	if len(result.All) >= 2 {
+		result.Left = result.All[1] // Second monitor as left
+	}
+	if len(result.All) >= 3 {
+		result.Right = result.All[2] // Third monitor as right
 	}
 
-	return &DetectedMonitors{
-		Primary: primaryOutput,
-		Left:    left,
-		Right:   right,
-		All:     paddedOutputs,
-	}, nil
+	return result, nil
 }
 
 // padWithDummyMonitors pads the monitor list with dummy monitors to meet minimum requirements
@@ -134,19 +156,123 @@ func (nd *NativeDetector) padWithDummyMonitors(monitors []string) []string {
 	return result
 }
 
-// GetDisplayInfo returns information about the X display connection
-func (nd *NativeDetector) GetDisplayInfo() (string, error) {
-	conn, err := xgb.NewConnDisplay(nd.display)
+// GetDisplayInfo queries the X server for the full inventory of outputs:
+// connection state, primary flag, CRTC geometry, modes, physical size and EDID identity.
+// An output or CRTC query that fails is recorded on the output's entry, and the
+// inventory goes on with the other outputs
+func (nd *NativeDetector) GetDisplayInfo() (*DisplayInfo, error) {
+	r, release, err := nd.connect()
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to X display %s: %w", nd.display, err)
+		return nil, err
+	}
+	defer release()
+
+	resources, err := r.Resources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screen resources: %w", err)
+	}
+
+	var primaryOutput uint32
+	if primary, err := r.Primary(); err == nil {
+		primaryOutput = primary
+	}
+
+	modes := screenModes(resources)
+
+	info := &DisplayInfo{Display: nd.display}
+	info.ScreenWidth, info.ScreenHeight = r.ScreenSize()
+
+	for _, output := range resources.Outputs {
+		outputInfo, err := r.OutputInfo(output)
+		if err != nil {
+			// Without output info there is no name, so the output goes by its ID
+			info.Outputs = append(info.Outputs, OutputInfo{
+				Name:       fmt.Sprint(output),
+				Connection: ConnectionUnknown,
+				Primary:    output == primaryOutput,
+				Error:      fmt.Sprintf("failed to get output info: %v", err),
+			})
+			continue
+		}
+
+		entry := OutputInfo{
+			Name:       outputInfo.Name,
+			Connection: outputInfo.Connection,
+			Primary:    output == primaryOutput,
+			WidthMM:    int(outputInfo.WidthMM),
+			HeightMM:   int(outputInfo.HeightMM),
+		}
+
+		for i, modeID := range outputInfo.Modes {
+			if mode, ok := modes[modeID]; ok {
+				mode.Preferred = i < outputInfo.NumPreferred
+				entry.Modes = append(entry.Modes, mode)
+			}
+		}
+
+		if outputInfo.Crtc != 0 {
+			crtcInfo, err := r.CrtcInfo(outputInfo.Crtc)
+			if err != nil {
+				entry.Error = fmt.Sprintf("failed to get CRTC info: %v", err)
+			} else if crtcInfo.Mode != 0 {
+				entry.Geometry = &Geometry{
+					X:        int(crtcInfo.X),
+					Y:        int(crtcInfo.Y),
+					Width:    int(crtcInfo.Width),
+					Height:   int(crtcInfo.Height),
+					Rotation: rotationName(crtcInfo.Rotation),
+				}
+				if mode, ok := modes[crtcInfo.Mode]; ok {
+					entry.CurrentMode = &mode
+				}
+			}
+		}
+
+		// Outputs without a sink or with corrupt EDID data simply have no identity
+		if data, err := r.EDID(output); err == nil && len(data) > 0 {
+			if edid, err := ParseEDID(data); err == nil {
+				entry.EDID = edid
+			}
+		}
+
+		info.Outputs = append(info.Outputs, entry)
 	}
-	defer conn.Close()
 
-	setup := xproto.Setup(conn)
-	screen := setup.DefaultScreen(conn)
+	return info, nil
+}
+
+// screenModes indexes the modes of the screen resources by ID
+func screenModes(resources *RandRResources) map[uint32]ModeInfo {
+	modes := make(map[uint32]ModeInfo, len(resources.Modes))
+	for _, mode := range resources.Modes {
+		modes[mode.ID] = ModeInfo{
+			Name:   mode.Name,
+			Width:  int(mode.Width),
+			Height: int(mode.Height),
+			Refresh: refreshRate(mode.DotClock, mode.HTotal, mode.VTotal,
+				mode.Flags&randr.ModeFlagInterlace != 0,
+				mode.Flags&randr.ModeFlagDoubleScan != 0),
+		}
+	}
+	return modes
+}
 
-	return fmt.Sprintf("Display: %s, Screen: %dx%d",
-		nd.display,
-		screen.WidthInPixels,
-		screen.HeightInPixels), nil
+// rotationName converts a RandR rotation bitmask to a name such as "normal" or "left"
+func rotationName(rotation uint16) string {
+	name := "normal"
+	switch {
+	case rotation&randr.RotationRotate90 != 0:
+		name = "left"
+	case rotation&randr.RotationRotate180 != 0:
+		name = "inverted"
+	case rotation&randr.RotationRotate270 != 0:
+		name = "right"
+	}
+	if rotation&randr.RotationReflectX != 0 {
+		name += "+reflect_x"
+	}
+	if rotation&randr.RotationReflectY != 0 {
+		name += "+reflect_y"
+	}
+	return name
 }
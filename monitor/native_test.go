@@ -1,6 +1,8 @@
 package monitor
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -114,11 +116,173 @@ func TestNewNativeDetector(t *testing.T) {
 	}
 }
 
-// Note: DetectMonitors() is not tested here because it requires a running X server.
-// In a real testing environment, you would use a mock X server or integration tests.
 func TestNativeDetector_InterfaceCompliance(t *testing.T) {
 	nd := NewNativeDetector(":0", []string{"dummy1", "dummy2"}, 3)
 
 	// This test ensures that NativeDetector implements MonitorDetector interface
 	var _ MonitorDetector = nd
 }
+
+// fixtureDetector returns a detector replaying a fixture from testdata
+func fixtureDetector(t *testing.T, name string) *NativeDetector {
+	t.Helper()
+	fixture, err := LoadFixture(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	nd := NewNativeDetector(fixture.Display, []string{"dummy1", "dummy2"}, 3)
+	nd.SetRandR(NewFakeRandR(fixture))
+	return nd
+}
+
+func TestNativeDetector_DetectMonitors(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected *DetectedMonitors
+	}{
+		{
+			// DP-2 is connected but not in use, so it gets no role
+			fixture: "docked.yaml",
+			expected: &DetectedMonitors{
+				Primary:  "DP-1",
+				Left:     "eDP-1",
+				Right:    "dummy1",
+				All:      []string{"DP-1", "eDP-1", "dummy1"},
+				DPI:      map[string]float64{"DP-1": 108.9, "eDP-1": 157.8},
+				Disabled: []string{"DP-2"},
+			},
+		},
+		{
+			// Without a primary output the first active one is used
+			fixture: "laptop.yaml",
+			expected: &DetectedMonitors{
+				Primary:  "eDP-1",
+				Left:     "dummy1",
+				Right:    "dummy2",
+				All:      []string{"eDP-1", "dummy1", "dummy2"},
+				DPI:      map[string]float64{"eDP-1": 157.8},
+				Warnings: []string{"skipped output 67: BadOutput {NiceName: Output, Sequence: 12, BadValue: 67}"},
+			},
+		},
+		{
+			// The primary output was unplugged, and DP-1 is skipped as its
+			// CRTC cannot be read
+			fixture: "unplugged.yaml",
+			expected: &DetectedMonitors{
+				Primary:  "eDP-1",
+				Left:     "dummy1",
+				Right:    "dummy2",
+				All:      []string{"eDP-1", "dummy1", "dummy2"},
+				DPI:      map[string]float64{"eDP-1": 157.8},
+				Stale:    []string{"HDMI-1"},
+				Warnings: []string{"skipped output DP-1: failed to get CRTC info: BadCrtc {NiceName: Crtc, Sequence: 9, BadValue: 65}"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			monitors, err := fixtureDetector(t, tt.fixture).DetectMonitors()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(monitors, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, monitors)
+			}
+		})
+	}
+}
+
+func TestNativeDetector_GetDisplayInfo(t *testing.T) {
+	info, err := fixtureDetector(t, "docked.yaml").GetDisplayInfo()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info.Display != ":0" || info.ScreenWidth != 4480 || info.ScreenHeight != 1440 {
+		t.Errorf("Expected display :0 of 4480x1440, got %s of %dx%d", info.Display, info.ScreenWidth, info.ScreenHeight)
+	}
+	var names []string
+	for _, output := range info.Outputs {
+		names = append(names, output.Name)
+	}
+	if expected := []string{"eDP-1", "DP-1", "HDMI-1", "DP-2"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected outputs %v, got %v", expected, names)
+	}
+
+	dp1 := info.Outputs[1]
+	if !dp1.Primary || info.Outputs[0].Primary {
+		t.Errorf("Expected only DP-1 to be primary")
+	}
+	expectedGeometry := &Geometry{X: 1920, Width: 2560, Height: 1440, Rotation: "normal"}
+	if !reflect.DeepEqual(dp1.Geometry, expectedGeometry) {
+		t.Errorf("Expected geometry %+v, got %+v", expectedGeometry, dp1.Geometry)
+	}
+	if dp1.CurrentMode == nil || dp1.CurrentMode.String() != "2560x1440@59.95" {
+		t.Errorf("Expected current mode 2560x1440@59.95, got %v", dp1.CurrentMode)
+	}
+	expectedModes := []ModeInfo{
+		{Name: "2560x1440", Width: 2560, Height: 1440, Refresh: 59.95, Preferred: true},
+		{Name: "1920x1080", Width: 1920, Height: 1080, Refresh: 60},
+		{Name: "1920x1080i", Width: 1920, Height: 1080, Refresh: 60},
+	}
+	if !reflect.DeepEqual(dp1.Modes, expectedModes) {
+		t.Errorf("Expected modes %+v, got %+v", expectedModes, dp1.Modes)
+	}
+	if dp1.EDID == nil || dp1.EDID.String() != "DEL DELL U2720Q (serial ABC123)" {
+		t.Errorf("Expected the EDID identity of DP-1, got %v", dp1.EDID)
+	}
+
+	// Connected but without a CRTC, so disabled
+	if dp2 := info.Outputs[3]; dp2.Connection != ConnectionConnected || dp2.Enabled() || dp2.EDID != nil {
+		t.Errorf("Expected DP-2 connected and disabled without EDID, got %+v", dp2)
+	}
+	if hdmi := info.Outputs[2]; hdmi.Connection != ConnectionDisconnected || hdmi.Enabled() {
+		t.Errorf("Expected HDMI-1 disconnected, got %+v", hdmi)
+	}
+}
+
+func TestNativeDetector_GetDisplayInfo_Fixtures(t *testing.T) {
+	// Failed queries are recorded on the output instead of failing the inventory
+	expectedErrors := map[string]map[string]string{
+		"docked.yaml":    {},
+		"laptop.yaml":    {"67": "failed to get output info: BadOutput {NiceName: Output, Sequence: 12, BadValue: 67}"},
+		"unplugged.yaml": {"DP-1": "failed to get CRTC info: BadCrtc {NiceName: Crtc, Sequence: 9, BadValue: 65}"},
+	}
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("Failed to find fixtures: %v", err)
+	}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		t.Run(name, func(t *testing.T) {
+			info, err := fixtureDetector(t, name).GetDisplayInfo()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			fixture, err := LoadFixture(path)
+			if err != nil {
+				t.Fatalf("Failed to load fixture: %v", err)
+			}
+			if len(info.Outputs) != len(fixture.Outputs) {
+				t.Errorf("Expected %d outputs, got %d", len(fixture.Outputs), len(info.Outputs))
+			}
+
+			errors := map[string]string{}
+			for _, output := range info.Outputs {
+				if output.Error != "" {
+					errors[output.Name] = output.Error
+				}
+			}
+			expected, ok := expectedErrors[name]
+			if !ok {
+				t.Fatalf("No expected errors for fixture %s", name)
+			}
+			if !reflect.DeepEqual(errors, expected) {
+				t.Errorf("Expected errors %v, got %v", expected, errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package monitor
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RandR is the set of RandR queries the native detector makes, so that a fixture
+// recorded on another machine can stand in for a live X server
+type RandR interface {
+	// ScreenSize returns the size of the X screen in pixels
+	ScreenSize() (width, height int)
+	// Resources returns the outputs, CRTCs and modes of the screen
+	Resources() (*RandRResources, error)
+	OutputInfo(output uint32) (*RandROutput, error)
+	CrtcInfo(crtc uint32) (*RandRCrtc, error)
+	// Primary returns the ID of the primary output, 0 if none is set
+	Primary() (uint32, error)
+	// EDID returns the raw EDID property of an output, nil if it has none
+	EDID(output uint32) ([]byte, error)
+	Close()
+}
+
+// RandRResources lists what the screen is made of
+type RandRResources struct {
+	Outputs []uint32    `json:"outputs" yaml:"outputs,flow"`
+	Crtcs   []uint32    `json:"crtcs" yaml:"crtcs,flow"`
+	Modes   []RandRMode `json:"modes" yaml:"modes"`
+}
+
+// RandRMode is a mode line as reported by RandR
+type RandRMode struct {
+	ID       uint32 `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Width    uint16 `json:"width" yaml:"width"`
+	Height   uint16 `json:"height" yaml:"height"`
+	DotClock uint32 `json:"dot_clock" yaml:"dot_clock"`
+	HTotal   uint16 `json:"htotal" yaml:"htotal"`
+	VTotal   uint16 `json:"vtotal" yaml:"vtotal"`
+	// Flags is the RandR mode flag bitmask, e.g. for interlaced modes
+	Flags uint32 `json:"flags,omitempty" yaml:"flags,omitempty"`
+}
+
+// RandROutput is the state of an output as reported by RandR
+type RandROutput struct {
+	Name       string `json:"name" yaml:"name"`
+	Connection string `json:"connection" yaml:"connection"`
+	// Crtc drives the output, 0 if the output is disabled
+	Crtc     uint32 `json:"crtc" yaml:"crtc"`
+	WidthMM  uint32 `json:"width_mm" yaml:"width_mm"`
+	HeightMM uint32 `json:"height_mm" yaml:"height_mm"`
+	// Modes are mode IDs, the first NumPreferred of them preferred
+	Modes        []uint32 `json:"modes,omitempty" yaml:"modes,flow,omitempty"`
+	NumPreferred int      `json:"num_preferred,omitempty" yaml:"num_preferred,omitempty"`
+}
+
+// RandRCrtc is the state of a CRTC as reported by RandR
+type RandRCrtc struct {
+	X      int16  `json:"x" yaml:"x"`
+	Y      int16  `json:"y" yaml:"y"`
+	Width  uint16 `json:"width" yaml:"width"`
+	Height uint16 `json:"height" yaml:"height"`
+	// Mode is the mode ID being scanned out, 0 if the CRTC is off
+	Mode uint32 `json:"mode" yaml:"mode"`
+	// Rotation is the RandR rotation and reflection bitmask
+	Rotation uint16   `json:"rotation" yaml:"rotation"`
+	Outputs  []uint32 `json:"outputs,omitempty" yaml:"outputs,flow,omitempty"`
+}
+
+// Fixture is the RandR state of an X display, recorded by Record and replayed by
+// FakeRandR
+type Fixture struct {
+	Display      string          `json:"display" yaml:"display"`
+	ScreenWidth  int             `json:"screen_width" yaml:"screen_width"`
+	ScreenHeight int             `json:"screen_height" yaml:"screen_height"`
+	Primary      uint32          `json:"primary" yaml:"primary"`
+	Modes        []RandRMode     `json:"modes" yaml:"modes"`
+	Outputs      []FixtureOutput `json:"outputs" yaml:"outputs"`
+	Crtcs        []FixtureCrtc   `json:"crtcs" yaml:"crtcs"`
+}
+
+// FixtureOutput is a recorded output; Error replays a failed query instead
+type FixtureOutput struct {
+	ID          uint32 `json:"id" yaml:"id"`
+	RandROutput `yaml:",inline"`
+	// EDID is the raw EDID property in hex
+	EDID  string `json:"edid,omitempty" yaml:"edid,omitempty"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// FixtureCrtc is a recorded CRTC; Error replays a failed query instead
+type FixtureCrtc struct {
+	ID        uint32 `json:"id" yaml:"id"`
+	RandRCrtc `yaml:",inline"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// LoadFixture reads a fixture written by the record command, in YAML or JSON
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	for _, output := range fixture.Outputs {
+		if _, err := hex.DecodeString(output.EDID); err != nil {
+			return nil, fmt.Errorf("fixture %s: output %s: invalid edid: %w", path, output.Name, err)
+		}
+	}
+	return &fixture, nil
+}
+
+// Record captures the RandR state of a display into a fixture. Failed output and
+// CRTC queries are recorded as errors so that they are replayed too
+func Record(r RandR, display string) (*Fixture, error) {
+	resources, err := r.Resources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screen resources: %w", err)
+	}
+
+	fixture := &Fixture{Display: display, Modes: resources.Modes}
+	fixture.ScreenWidth, fixture.ScreenHeight = r.ScreenSize()
+	// A server without a primary output is recorded as such
+	if primary, err := r.Primary(); err == nil {
+		fixture.Primary = primary
+	}
+
+	for _, id := range resources.Outputs {
+		entry := FixtureOutput{ID: id}
+		output, err := r.OutputInfo(id)
+		if err != nil {
+			entry.Error = err.Error()
+			fixture.Outputs = append(fixture.Outputs, entry)
+			continue
+		}
+		entry.RandROutput = *output
+		if edid, err := r.EDID(id); err == nil {
+			entry.EDID = hex.EncodeToString(edid)
+		}
+		fixture.Outputs = append(fixture.Outputs, entry)
+	}
+
+	for _, id := range resources.Crtcs {
+		entry := FixtureCrtc{ID: id}
+		if crtc, err := r.CrtcInfo(id); err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.RandRCrtc = *crtc
+		}
+		fixture.Crtcs = append(fixture.Crtcs, entry)
+	}
+
+	return fixture, nil
+}
+
+// FakeRandR replays a fixture in memory in place of an X server
+type FakeRandR struct {
+	fixture *Fixture
+	outputs map[uint32]FixtureOutput
+	crtcs   map[uint32]FixtureCrtc
+}
+
+var _ RandR = (*FakeRandR)(nil)
+
+// NewFakeRandR creates a fake replaying the given fixture
+func NewFakeRandR(fixture *Fixture) *FakeRandR {
+	fake := &FakeRandR{
+		fixture: fixture,
+		outputs: make(map[uint32]FixtureOutput, len(fixture.Outputs)),
+		crtcs:   make(map[uint32]FixtureCrtc, len(fixture.Crtcs)),
+	}
+	for _, output := range fixture.Outputs {
+		fake.outputs[output.ID] = output
+	}
+	for _, crtc := range fixture.Crtcs {
+		fake.crtcs[crtc.ID] = crtc
+	}
+	return fake
+}
+
+// ScreenSize returns the recorded screen size
+func (f *FakeRandR) ScreenSize() (int, int) {
+	return f.fixture.ScreenWidth, f.fixture.ScreenHeight
+}
+
+// Resources returns the recorded outputs, CRTCs and modes in fixture order
+func (f *FakeRandR) Resources() (*RandRResources, error) {
+	resources := &RandRResources{Modes: f.fixture.Modes}
+	for _, output := range f.fixture.Outputs {
+		resources.Outputs = append(resources.Outputs, output.ID)
+	}
+	for _, crtc := range f.fixture.Crtcs {
+		resources.Crtcs = append(resources.Crtcs, crtc.ID)
+	}
+	return resources, nil
+}
+
+// OutputInfo returns a recorded output
+func (f *FakeRandR) OutputInfo(id uint32) (*RandROutput, error) {
+	output, ok := f.outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("no output %d in fixture", id)
+	}
+	if output.Error != "" {
+		return nil, errors.New(output.Error)
+	}
+	return &output.RandROutput, nil
+}
+
+// CrtcInfo returns a recorded CRTC
+func (f *FakeRandR) CrtcInfo(id uint32) (*RandRCrtc, error) {
+	crtc, ok := f.crtcs[id]
+	if !ok {
+		return nil, fmt.Errorf("no CRTC %d in fixture", id)
+	}
+	if crtc.Error != "" {
+		return nil, errors.New(crtc.Error)
+	}
+	return &crtc.RandRCrtc, nil
+}
+
+// Primary returns the recorded primary output
+func (f *FakeRandR) Primary() (uint32, error) {
+	return f.fixture.Primary, nil
+}
+
+// EDID returns the recorded EDID property of an output
+func (f *FakeRandR) EDID(id uint32) ([]byte, error) {
+	output, ok := f.outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("no output %d in fixture", id)
+	}
+	return hex.DecodeString(output.EDID)
+}
+
+// Close does nothing, the fixture stays usable
+func (f *FakeRandR) Close() {}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRecord(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "monitor_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, name := range []string{"docked.yaml", "laptop.yaml", "unplugged.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			fixture, err := LoadFixture(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("Failed to load fixture: %v", err)
+			}
+
+			// Recording a replayed display gives back the same fixture, errors included
+			recorded, err := Record(NewFakeRandR(fixture), fixture.Display)
+			if err != nil {
+				t.Fatalf("Failed to record: %v", err)
+			}
+			if !reflect.DeepEqual(recorded, fixture) {
+				t.Errorf("Expected %+v, got %+v", fixture, recorded)
+			}
+
+			// and survives being written out as the record command does
+			data, err := yaml.Marshal(recorded)
+			if err != nil {
+				t.Fatalf("Failed to encode fixture: %v", err)
+			}
+			path := filepath.Join(tempDir, name)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Failed to write fixture: %v", err)
+			}
+			reloaded, err := LoadFixture(path)
+			if err != nil {
+				t.Fatalf("Failed to load recorded fixture: %v", err)
+			}
+			if !reflect.DeepEqual(reloaded, fixture) {
+				t.Errorf("Expected %+v, got %+v", fixture, reloaded)
+			}
+		})
+	}
+}
+
+func TestFakeRandR(t *testing.T) {
+	fixture, err := LoadFixture(filepath.Join("testdata", "laptop.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	fake := NewFakeRandR(fixture)
+
+	if _, err := fake.OutputInfo(67); err == nil || !strings.Contains(err.Error(), "BadOutput") {
+		t.Errorf("Expected the recorded error, got %v", err)
+	}
+	if _, err := fake.OutputInfo(99); err == nil || err.Error() != "no output 99 in fixture" {
+		t.Errorf("Expected a missing output error, got %v", err)
+	}
+	if _, err := fake.CrtcInfo(64); err == nil || err.Error() != "no CRTC 64 in fixture" {
+		t.Errorf("Expected a missing CRTC error, got %v", err)
+	}
+	if edid, err := fake.EDID(66); err != nil || len(edid) != 0 {
+		t.Errorf("Expected no EDID, got %x (%v)", edid, err)
+	}
+}
+
+func TestLoadFixture_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		errorMsg string
+	}{
+		{
+			name:     "invalid yaml",
+			content:  "outputs: [\n",
+			errorMsg: "failed to parse fixture",
+		},
+		{
+			name:     "invalid edid",
+			content:  "outputs:\n  - id: 66\n    name: eDP-1\n    edid: 00fz\n",
+			errorMsg: "output eDP-1: invalid edid",
+		},
+	}
+
+	tempDir, err := os.MkdirTemp("", "monitor_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "fixture.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write fixture: %v", err)
+			}
+			_, err := LoadFixture(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/randr"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// x11RandR answers RandR queries from a live X server
+type x11RandR struct {
+	conn   *xgb.Conn
+	screen *xproto.ScreenInfo
+	// timestamp is the configuration the output and CRTC queries refer to
+	timestamp xproto.Timestamp
+	// edidAtom is 0 when no driver has published EDID data
+	edidAtom xproto.Atom
+}
+
+var _ RandR = (*x11RandR)(nil)
+
+// DialRandR connects to an X display and initializes its RandR extension
+func DialRandR(display string) (RandR, error) {
+	conn, err := xgb.NewConnDisplay(display)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X display %s: %w", display, err)
+	}
+
+	if err := randr.Init(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize RandR extension: %w", err)
+	}
+
+	r := &x11RandR{conn: conn, screen: xproto.Setup(conn).DefaultScreen(conn)}
+	// The EDID property atom only exists once a driver has published EDID data
+	if atom, err := xproto.InternAtom(conn, true, uint16(len("EDID")), "EDID").Reply(); err == nil {
+		r.edidAtom = atom.Atom
+	}
+	return r, nil
+}
+
+// ScreenSize returns the size of the default screen
+func (r *x11RandR) ScreenSize() (int, int) {
+	return int(r.screen.WidthInPixels), int(r.screen.HeightInPixels)
+}
+
+// Resources queries the screen resources and remembers their configuration timestamp
+func (r *x11RandR) Resources() (*RandRResources, error) {
+	reply, err := randr.GetScreenResources(r.conn, r.screen.Root).Reply()
+	if err != nil {
+		return nil, err
+	}
+	r.timestamp = reply.ConfigTimestamp
+
+	resources := &RandRResources{}
+	for _, output := range reply.Outputs {
+		resources.Outputs = append(resources.Outputs, uint32(output))
+	}
+	for _, crtc := range reply.Crtcs {
+		resources.Crtcs = append(resources.Crtcs, uint32(crtc))
+	}
+
+	// Mode names are packed one after the other in Names
+	nameOffset := 0
+	for _, mode := range reply.Modes {
+		name := ""
+		if end := nameOffset + int(mode.NameLen); end <= len(reply.Names) {
+			name = string(reply.Names[nameOffset:end])
+		}
+		nameOffset += int(mode.NameLen)
+
+		resources.Modes = append(resources.Modes, RandRMode{
+			ID:       mode.Id,
+			Name:     name,
+			Width:    mode.Width,
+			Height:   mode.Height,
+			DotClock: mode.DotClock,
+			HTotal:   mode.Htotal,
+			VTotal:   mode.Vtotal,
+			Flags:    mode.ModeFlags,
+		})
+	}
+	return resources, nil
+}
+
+// OutputInfo queries the state of an output
+func (r *x11RandR) OutputInfo(output uint32) (*RandROutput, error) {
+	reply, err := randr.GetOutputInfo(r.conn, randr.Output(output), r.timestamp).Reply()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RandROutput{
+		Name:         string(reply.Name),
+		Connection:   connectionName(reply.Connection),
+		Crtc:         uint32(reply.Crtc),
+		WidthMM:      reply.MmWidth,
+		HeightMM:     reply.MmHeight,
+		NumPreferred: int(reply.NumPreferred),
+	}
+	for _, mode := range reply.Modes {
+		info.Modes = append(info.Modes, uint32(mode))
+	}
+	return info, nil
+}
+
+// CrtcInfo queries the state of a CRTC
+func (r *x11RandR) CrtcInfo(crtc uint32) (*RandRCrtc, error) {
+	reply, err := randr.GetCrtcInfo(r.conn, randr.Crtc(crtc), r.timestamp).Reply()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RandRCrtc{
+		X:        reply.X,
+		Y:        reply.Y,
+		Width:    reply.Width,
+		Height:   reply.Height,
+		Mode:     uint32(reply.Mode),
+		Rotation: reply.Rotation,
+	}
+	for _, output := range reply.Outputs {
+		info.Outputs = append(info.Outputs, uint32(output))
+	}
+	return info, nil
+}
+
+// Primary queries the primary output of the screen
+func (r *x11RandR) Primary() (uint32, error) {
+	reply, err := randr.GetOutputPrimary(r.conn, r.screen.Root).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(reply.Output), nil
+}
+
+// EDID queries the EDID property of an output
+func (r *x11RandR) EDID(output uint32) ([]byte, error) {
+	if r.edidAtom == 0 {
+		return nil, nil
+	}
+	reply, err := randr.GetOutputProperty(r.conn, randr.Output(output), r.edidAtom, xproto.GetPropertyTypeAny, 0, 256, false, false).Reply()
+	if err != nil {
+		return nil, err
+	}
+	return reply.Data, nil
+}
+
+// Close closes the connection to the X server
+func (r *x11RandR) Close() {
+	r.conn.Close()
+}
+
+// connectionName converts a RandR connection state to its name
+func connectionName(connection byte) string {
+	switch connection {
+	case randr.ConnectionConnected:
+		return ConnectionConnected
+	case randr.ConnectionDisconnected:
+		return ConnectionDisconnected
+	default:
+		return ConnectionUnknown
+	}
+}
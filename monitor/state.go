@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveMonitors writes detected monitors to path as JSON, creating its directory,
+// so later runs can render without access to the X server
+func SaveMonitors(path string, monitors *DetectedMonitors) error {
+	data, err := json.MarshalIndent(monitors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode monitors: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write monitor state %s: %w", path, err)
+	}
+	return nil
+}
+
+// fixtureKeys are top-level keys of RandR fixtures that monitors files never have
+var fixtureKeys = []string{"screen_width", "screen_height", "modes", "outputs", "crtcs"}
+
+// LoadMonitors reads monitors saved by SaveMonitors, written by hand in YAML or
+// JSON, or taken from the roles of "detect --format yaml" output
+// When all is left out it is made up of the monitors given for the roles
+func LoadMonitors(path string) (*DetectedMonitors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read monitors file %s: %w", path, err)
+	}
+
+	// Fixtures share keys such as primary, which would otherwise name an output ID
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse monitors file %s: %w", path, err)
+	}
+	if isFixture(&document) {
+		return nil, fmt.Errorf("monitors file %s is a RandR fixture; replay it with 'detect --fixture %s --format yaml' and pass that report instead", path, path)
+	}
+
+	var file struct {
+		DetectedMonitors `yaml:",inline"`
+		// Roles holds the monitors in detect reports
+		Roles *DetectedMonitors `yaml:"roles"`
+		// Display is the inventory of detect reports, which is not needed here
+		Display yaml.Node `yaml:"display"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse monitors file %s: %w", path, err)
+	}
+
+	monitors := &file.DetectedMonitors
+	if file.Roles != nil {
+		monitors = file.Roles
+	}
+	if monitors.Primary == "" {
+		return nil, fmt.Errorf("monitors file %s: primary is not set", path)
+	}
+	if len(monitors.All) == 0 {
+		for _, name := range []string{monitors.Primary, monitors.Left, monitors.Right} {
+			if name != "" {
+				monitors.All = append(monitors.All, name)
+			}
+		}
+	}
+	if err := validateMonitors(monitors); err != nil {
+		return nil, fmt.Errorf("monitors file %s: %w", path, err)
+	}
+	return monitors, nil
+}
+
+// validateMonitors checks that every monitor is named like an output and that
+// the roles are given to monitors in all
+func validateMonitors(monitors *DetectedMonitors) error {
+	roles := []struct{ role, name string }{
+		{"primary", monitors.Primary},
+		{"left", monitors.Left},
+		{"right", monitors.Right},
+	}
+	for _, r := range roles {
+		if r.name != "" && !isOutputName(r.name) {
+			return fmt.Errorf("%s: %q is not an output name", r.role, r.name)
+		}
+	}
+	for _, name := range monitors.All {
+		if !isOutputName(name) {
+			return fmt.Errorf("all: %q is not an output name", name)
+		}
+	}
+	for _, r := range roles {
+		if r.name != "" && !slices.Contains(monitors.All, r.name) {
+			return fmt.Errorf("%s: %s is not listed in all", r.role, r.name)
+		}
+	}
+	return nil
+}
+
+// isOutputName reports whether name looks like a RandR output name such as eDP-1,
+// rather than an output ID or a description
+func isOutputName(name string) bool {
+	if name == "" || strings.ContainsFunc(name, unicode.IsSpace) {
+		return false
+	}
+	return strings.ContainsFunc(name, func(r rune) bool { return !unicode.IsDigit(r) })
+}
+
+// isFixture reports whether a YAML document has the top-level keys of a RandR fixture
+func isFixture(document *yaml.Node) bool {
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	mapping := document.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if slices.Contains(fixtureKeys, mapping.Content[i].Value) {
+			return true
+		}
+	}
+	return false
+}
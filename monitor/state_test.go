@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveMonitors_LoadMonitors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "monitor_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	saved := &DetectedMonitors{
+		Primary: "eDP-1",
+		Left:    "DP-1",
+		Right:   "DP-2",
+		All:     []string{"eDP-1", "DP-1", "DP-2"},
+		DPI:     map[string]float64{"eDP-1": 141.2},
+	}
+	// The state directory is created as needed
+	path := filepath.Join(tempDir, "state", "monitors.json")
+	if err := SaveMonitors(path, saved); err != nil {
+		t.Fatalf("Failed to save monitors: %v", err)
+	}
+
+	loaded, err := LoadMonitors(path)
+	if err != nil {
+		t.Fatalf("Failed to load monitors: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("Expected %+v, got %+v", saved, loaded)
+	}
+}
+
+func TestLoadMonitors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected *DetectedMonitors
+		errorMsg string
+	}{
+		{
+			name:     "hand-written roles",
+			content:  "primary: eDP-1\nleft: HDMI-1\n",
+			expected: &DetectedMonitors{Primary: "eDP-1", Left: "HDMI-1", All: []string{"eDP-1", "HDMI-1"}},
+		},
+		{
+			name:     "json",
+			content:  `{"primary": "eDP-1", "all": ["eDP-1", "DP-1"]}`,
+			expected: &DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1", "DP-1"}},
+		},
+		{
+			name: "detect report",
+			content: `roles:
+  primary: DP-1
+  left: eDP-1
+  right: ""
+  all: [DP-1, eDP-1]
+display:
+  display: ":0"
+  outputs:
+    - name: DP-1
+`,
+			expected: &DetectedMonitors{Primary: "DP-1", Left: "eDP-1", All: []string{"DP-1", "eDP-1"}},
+		},
+		{
+			name:     "primary missing",
+			content:  "left: HDMI-1\n",
+			errorMsg: "primary is not set",
+		},
+		{
+			name:     "unknown field",
+			content:  "primary: eDP-1\nleft_display: HDMI-1\n",
+			errorMsg: "field left_display not found",
+		},
+		{
+			name:     "role not in all",
+			content:  "primary: eDP-1\nleft: HDMI-1\nall: [eDP-1, DP-1]\n",
+			errorMsg: "left: HDMI-1 is not listed in all",
+		},
+		{
+			name:     "output id",
+			content:  "primary: 67\n",
+			errorMsg: `primary: "67" is not an output name`,
+		},
+		{
+			name:     "output id in all",
+			content:  "primary: eDP-1\nall: [eDP-1, 68]\n",
+			errorMsg: `all: "68" is not an output name`,
+		},
+		{
+			name:     "randr fixture",
+			content:  "display: \":0\"\nscreen_width: 1920\nprimary: 66\noutputs:\n  - id: 66\n    name: eDP-1\n",
+			errorMsg: "is a RandR fixture; replay it with 'detect --fixture",
+		},
+		{
+			name:     "empty",
+			content:  "",
+			errorMsg: "primary is not set",
+		},
+		{
+			name:     "invalid yaml",
+			content:  "primary: [eDP-1\n",
+			errorMsg: "failed to parse monitors file",
+		},
+	}
+
+	tempDir, err := os.MkdirTemp("", "monitor_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "monitors.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write monitors file: %v", err)
+			}
+
+			monitors, err := LoadMonitors(path)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(monitors, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, monitors)
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package template
 import (
 	"embed"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/a7d-corp/i3-config-generator-go/config"
@@ -19,37 +23,126 @@ type TemplateData struct {
 	I3                  config.I3Config
 	Colors              config.ColorConfig
 	Layout              ResolvedLayoutConfig
-	ApplicationBindings map[string]string
+	ApplicationBindings config.OrderedMap
 	StartupPrograms     []string
 	WindowOverrides     []string
+	Keyboard            config.KeyboardConfig
+	Terminal            string
+	Launcher            config.LauncherConfig
+	Locker              config.LockerConfig
+	Bar                 config.BarConfig
+	MediaKeys           config.MediaKeysConfig
+	Backlight           config.BacklightConfig
+	Hotkeys             []config.HotkeyConfig
+	Extra               ResolvedExtraConfig
 	DetectedMonitors    *monitor.DetectedMonitors
+	// BorderWidth is the window border in pixels after scaling
+	BorderWidth int
+	// PrimaryDPI is the pixel density of the primary display, 0 when unknown;
+	// the density of every output is in DetectedMonitors.DPI
+	PrimaryDPI float64
+	// Scale is the scaling rule applied for the primary display's DPI
+	Scale config.ScalingRule
+	// Workspaces holds the key bindings of the per_output workspace scheme
+	Workspaces ResolvedWorkspaces
+	// GapKeys are the gap adjustment bindings, with disabled keys left empty
+	GapKeys config.GapKeysConfig
+}
+
+// templateFuncs are the helper functions available to templates
+var templateFuncs = template.FuncMap{
+	"quoteCommand": quoteCommand,
+	"indent":       indent,
+}
+
+// quoteCommand quotes an exec argument when i3 would otherwise split it into
+// several commands, escaping any double quotes it contains
+func quoteCommand(command string) string {
+	if !strings.ContainsAny(command, ",;") {
+		return command
+	}
+	return `"` + strings.ReplaceAll(command, `"`, `\"`) + `"`
+}
+
+// indent prefixes every line of s, so multi-line snippets can be placed inside blocks
+func indent(prefix, s string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// ResolvedExtraConfig holds the extra snippets with files read and templates expanded
+type ResolvedExtraConfig struct {
+	BeforeKeybindings []string
+	AfterWorkspaces   []string
+	EndOfFile         []string
+	Modes             map[string][]string
+}
+
+// ResolvedWorkspaces holds the workspace key bindings for the configured scheme
+type ResolvedWorkspaces struct {
+	// PerOutput is set when each output has its own workspace range
+	PerOutput bool
+	// Keys are the number keys bound to workspaces of the focused output
+	Keys []WorkspaceKey
+	// SwitchCommand and MoveCommand are run with the workspace number
+	SwitchCommand string
+	MoveCommand   string
+}
+
+// WorkspaceKey binds a number key to a workspace within the focused output's range
+type WorkspaceKey struct {
+	Key    string
+	Number int
 }
 
 // ResolvedLayoutConfig is a layout config with monitor references resolved
 type ResolvedLayoutConfig struct {
-	GapsInner          int
-	GapsOuter          int
-	MoveWorkspace      map[string]string // Resolved to actual monitor names
-	WorkspaceToDisplay map[string]string // Resolved to actual monitor names
+	GapsInner int
+	GapsOuter int
+	// Gaps are the further "gaps" arguments, such as "horizontal 5"
+	Gaps []string
+	// WorkspaceGaps are the per-workspace gaps lines
+	WorkspaceGaps      []string
+	SmartGaps          string
+	SmartBorders       string
+	HideEdgeBorders    string
+	MoveWorkspace      config.OrderedMap // Resolved to actual monitor names
+	WorkspaceToDisplay config.OrderedMap // Resolved to actual monitor names
+	Assignments        config.OrderedMap // Criteria to assign targets for this layout
 }
 
+// DefaultTemplateDir is the directory searched for template overrides when none is given
+const DefaultTemplateDir = "template"
+
 // Renderer handles template rendering operations
 type Renderer struct {
-	templateDir string
+	templateDir   string
+	allowEmbedded bool
+	warnings      io.Writer
 }
 
 // NewRenderer creates a new template renderer
+// If templateDir is empty, templates in DefaultTemplateDir take precedence over the
+// embedded ones; an explicit templateDir must contain the requested template
 func NewRenderer(templateDir string) *Renderer {
+	allowEmbedded := false
 	if templateDir == "" {
 		// Default to template directory relative to current working directory
-		templateDir = "template"
+		templateDir = DefaultTemplateDir
+		allowEmbedded = true
 	}
 
 	return &Renderer{
-		templateDir: templateDir,
+		templateDir:   templateDir,
+		allowEmbedded: allowEmbedded,
+		warnings:      os.Stderr,
 	}
 }
 
+// SetWarningOutput sets where non-fatal warnings are written (default: stderr)
+func (r *Renderer) SetWarningOutput(w io.Writer) {
+	r.warnings = w
+}
+
 // Render generates the i3 configuration by rendering the template with the given data
 func (r *Renderer) Render(cfg *config.Config, layoutName string, detectedMonitors *monitor.DetectedMonitors) (string, error) {
 	// Get the specified layout
@@ -58,57 +151,287 @@ func (r *Renderer) Render(cfg *config.Config, layoutName string, detectedMonitor
 		return "", fmt.Errorf("failed to get layout: %w", err)
 	}
 
-	// Resolve monitor references in the layout
-	resolvedLayout, err := r.resolveLayoutReferences(layout, detectedMonitors)
+	// Render with the settings the layout overrides
+	cfg, err = cfg.ForLayout(layoutName)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply layout overrides: %w", err)
+	}
+
+	// Resolve monitor references in the layout and the assignments that apply to it
+	resolvedLayout, err := r.resolveLayoutReferences(layout, cfg.AssignmentsFor(layoutName), detectedMonitors)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve layout references: %w", err)
 	}
+	resolvedLayout.MoveWorkspace = cfg.OrderEntries(resolvedLayout.MoveWorkspace)
+	resolvedLayout.WorkspaceToDisplay = cfg.OrderEntries(resolvedLayout.WorkspaceToDisplay)
+
+	windowOverrides, err := r.resolveWindowRules(cfg.WindowOverrides, detectedMonitors)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve window rules: %w", err)
+	}
+
+	var workspaces ResolvedWorkspaces
+	if cfg.Workspaces.PerOutputEnabled() {
+		outputs := realOutputs(detectedMonitors, cfg.MonitorDetection.DummyMonitors)
+		if len(outputs) == 0 {
+			return "", fmt.Errorf("the per_output workspace scheme needs detected monitors")
+		}
+		workspaces = resolvePerOutputWorkspaces(cfg.Workspaces)
+		resolvedLayout.WorkspaceToDisplay = perOutputAssignments(cfg.Workspaces, outputs)
+	}
+
+	// Fonts, borders and gaps follow the pixel density of the primary display
+	primaryDPI := detectedMonitors.PrimaryDPI()
+	scale := cfg.Scaling.For(primaryDPI)
+	i3 := cfg.I3
+	i3.Font = config.ScaleFont(i3.Font, scale.FontFactor())
+	i3.BarFont = config.ScaleFont(i3.BarFont, scale.FontFactor())
+	borderWidth := config.DefaultBorderWidth
+	if i3.BorderWidth != nil {
+		borderWidth = *i3.BorderWidth
+	}
+	borderWidth = config.ScaleSize(float64(borderWidth), scale.BorderFactor())
+	resolvedLayout.GapsInner = config.ScaleSize(float64(resolvedLayout.GapsInner), scale.GapFactor())
+	resolvedLayout.GapsOuter = config.ScaleSize(float64(resolvedLayout.GapsOuter), scale.GapFactor())
+	resolvedLayout.Gaps, resolvedLayout.WorkspaceGaps = resolveGaps(layout.ResolvedGaps(), layout.Gaps.Workspaces, scale.GapFactor())
+
+	// Typed assignments follow the application_bindings entries, in a new slice so that
+	// appending cannot write into the config's backing array
+	bindings := cfg.OrderEntries(cfg.ApplicationBindings)
+	applicationBindings := make(config.OrderedMap, 0, len(bindings)+len(resolvedLayout.Assignments))
+	applicationBindings = append(applicationBindings, bindings...)
+	applicationBindings = append(applicationBindings, resolvedLayout.Assignments...)
 
 	// Prepare template data
 	templateData := &TemplateData{
-		I3:                  cfg.I3,
+		I3:                  i3,
 		Colors:              cfg.Colors,
 		Layout:              *resolvedLayout,
-		ApplicationBindings: cfg.ApplicationBindings,
+		ApplicationBindings: applicationBindings,
 		StartupPrograms:     cfg.StartupPrograms,
-		WindowOverrides:     cfg.WindowOverrides,
+		Keyboard:            cfg.Keyboard,
+		Terminal:            cfg.Terminal,
+		Launcher:            cfg.Launcher,
+		Locker:              cfg.Locker,
+		Bar:                 cfg.Bar,
+		MediaKeys:           cfg.MediaKeys,
+		Backlight:           cfg.Backlight,
+		Hotkeys:             cfg.Hotkeys,
+		WindowOverrides:     windowOverrides,
 		DetectedMonitors:    detectedMonitors,
+		BorderWidth:         borderWidth,
+		PrimaryDPI:          primaryDPI,
+		Scale:               scale,
+		Workspaces:          workspaces,
+		GapKeys:             resolveGapKeys(cfg.GapKeys),
 	}
 
+	// Snippets are templates too, so they can refer to monitors and other settings
+	extra, err := r.resolveExtra(&cfg.Extra, templateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve extra config: %w", err)
+	}
+	templateData.Extra = *extra
+
 	// Load and render template
 	return r.renderTemplate("i3.tmpl", templateData)
 }
 
-// resolveLayoutReferences converts layout role references to actual monitor names
-func (r *Renderer) resolveLayoutReferences(layout *config.LayoutConfig, detectedMonitors *monitor.DetectedMonitors) (*ResolvedLayoutConfig, error) {
+// resolveLayoutReferences converts layout role references to actual monitor names,
+// along with the monitor roles that assignments send windows to
+func (r *Renderer) resolveLayoutReferences(layout *config.LayoutConfig, assignments []config.Assignment, detectedMonitors *monitor.DetectedMonitors) (*ResolvedLayoutConfig, error) {
+	gaps := layout.ResolvedGaps()
 	resolved := &ResolvedLayoutConfig{
-		GapsInner:          layout.GapsInner,
-		GapsOuter:          layout.GapsOuter,
-		MoveWorkspace:      make(map[string]string),
-		WorkspaceToDisplay: make(map[string]string),
+		GapsInner:          *gaps.Inner,
+		GapsOuter:          *gaps.Outer,
+		SmartGaps:          layout.Gaps.SmartGaps,
+		SmartBorders:       layout.Gaps.SmartBorders,
+		HideEdgeBorders:    layout.Gaps.HideEdgeBorders,
+		MoveWorkspace:      make(config.OrderedMap, 0, len(layout.MoveWorkspace)),
+		WorkspaceToDisplay: make(config.OrderedMap, 0, len(layout.WorkspaceToDisplay)),
+		Assignments:        make(config.OrderedMap, 0, len(assignments)),
 	}
 
 	// Resolve MoveWorkspace references
-	for keybind, role := range layout.MoveWorkspace {
-		monitorName := detectedMonitors.GetMonitorByRole(role)
-		if monitorName == "" {
-			return nil, fmt.Errorf("unknown monitor role: %s", role)
+	for _, entry := range layout.MoveWorkspace {
+		monitorName, err := resolveRole(entry.Value, detectedMonitors)
+		if err != nil {
+			return nil, fmt.Errorf("move_workspace %s: %w", entry.Key, err)
 		}
-		resolved.MoveWorkspace[keybind] = monitorName
+		resolved.MoveWorkspace = append(resolved.MoveWorkspace, config.MapEntry{Key: entry.Key, Value: monitorName})
 	}
 
 	// Resolve WorkspaceToDisplay references
-	for workspace, role := range layout.WorkspaceToDisplay {
-		monitorName := detectedMonitors.GetMonitorByRole(role)
-		if monitorName == "" {
-			return nil, fmt.Errorf("unknown monitor role: %s", role)
+	for _, entry := range layout.WorkspaceToDisplay {
+		monitorName, err := resolveRole(entry.Value, detectedMonitors)
+		if err != nil {
+			return nil, fmt.Errorf("workspace_to_display %s: %w", entry.Key, err)
+		}
+		resolved.WorkspaceToDisplay = append(resolved.WorkspaceToDisplay, config.MapEntry{Key: entry.Key, Value: monitorName})
+	}
+
+	// Resolve assignment targets; assignments without a target in this layout are skipped
+	for i, assignment := range assignments {
+		if assignment.AssignmentTarget.IsZero() {
+			continue
+		}
+		var output string
+		if assignment.Output != "" {
+			monitorName, err := resolveRole(assignment.Output, detectedMonitors)
+			if err != nil {
+				return nil, fmt.Errorf("assignments[%d]: %w", i, err)
+			}
+			output = monitorName
 		}
-		resolved.WorkspaceToDisplay[workspace] = monitorName
+		resolved.Assignments = append(resolved.Assignments, config.MapEntry{Key: assignment.Match.String(), Value: assignment.Target(output)})
 	}
 
 	return resolved, nil
 }
 
+// realOutputs returns the detected monitors in detection order, without the
+// dummy monitors used as padding
+func realOutputs(detectedMonitors *monitor.DetectedMonitors, dummies []string) []string {
+	if detectedMonitors == nil {
+		return nil
+	}
+	var outputs []string
+	for _, name := range detectedMonitors.All {
+		if !slices.Contains(dummies, name) {
+			outputs = append(outputs, name)
+		}
+	}
+	return outputs
+}
+
+// perOutputAssignments gives each output its own range of workspace numbers
+func perOutputAssignments(workspaces config.WorkspacesConfig, outputs []string) config.OrderedMap {
+	assignments := make(config.OrderedMap, 0, len(outputs)*workspaces.PerOutput)
+	for i, output := range outputs {
+		first, last := workspaces.Range(i)
+		for number := first; number <= last; number++ {
+			assignments = append(assignments, config.MapEntry{Key: strconv.Itoa(number), Value: output})
+		}
+	}
+	return assignments
+}
+
+// resolvePerOutputWorkspaces binds the number keys to the workspaces of the
+// focused output's range; ranges larger than ten are only partly reachable by key
+func resolvePerOutputWorkspaces(workspaces config.WorkspacesConfig) ResolvedWorkspaces {
+	command := fmt.Sprintf("%s --per-output %d", workspaces.Command, workspaces.PerOutput)
+	resolved := ResolvedWorkspaces{
+		PerOutput:     true,
+		SwitchCommand: command,
+		MoveCommand:   command + " --move",
+	}
+	for number := 1; number <= min(workspaces.PerOutput, 10); number++ {
+		resolved.Keys = append(resolved.Keys, WorkspaceKey{Key: strconv.Itoa(number % 10), Number: number})
+	}
+	return resolved
+}
+
+// resolveGaps returns the gap sizes besides inner and outer, which have their own
+// lines, and the per-workspace gaps, scaled by factor
+func resolveGaps(sizes config.GapSizes, workspaces []config.WorkspaceGaps, factor float64) ([]string, []string) {
+	sizes.Inner, sizes.Outer = nil, nil
+	var workspaceGaps []string
+	for _, workspace := range workspaces {
+		workspaceGaps = append(workspaceGaps, workspace.Statements(factor)...)
+	}
+	return sizes.Statements(factor), workspaceGaps
+}
+
+// resolveGapKeys clears the gap bindings that are disabled with "none"
+func resolveGapKeys(keys config.GapKeysConfig) config.GapKeysConfig {
+	for _, key := range []*string{&keys.Decrease, &keys.Increase, &keys.DecreaseCurrent, &keys.IncreaseCurrent} {
+		if *key == config.NoKey {
+			*key = ""
+		}
+	}
+	return keys
+}
+
+// resolveRole returns the monitor detected for a role such as primary_display
+func resolveRole(role string, detectedMonitors *monitor.DetectedMonitors) (string, error) {
+	monitorName, err := detectedMonitors.GetMonitorByRole(role)
+	if err != nil {
+		return "", err
+	}
+	if monitorName == "" {
+		return "", fmt.Errorf("no monitor detected for role %s (detected: %s)", role, strings.Join(detectedMonitors.All, ", "))
+	}
+	return monitorName, nil
+}
+
+// resolveWindowRules renders window rules in i3 syntax, without the for_window keyword,
+// resolving the monitor roles they move windows to
+func (r *Renderer) resolveWindowRules(rules []config.WindowRule, detectedMonitors *monitor.DetectedMonitors) ([]string, error) {
+	resolved := make([]string, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Raw != "" {
+			resolved = append(resolved, rule.Raw)
+			continue
+		}
+
+		var output string
+		if rule.MoveToOutput != "" {
+			monitorName, err := resolveRole(rule.MoveToOutput, detectedMonitors)
+			if err != nil {
+				return nil, fmt.Errorf("window_overrides[%d]: %w", i, err)
+			}
+			output = monitorName
+		}
+		resolved = append(resolved, rule.Match.String()+" "+strings.Join(rule.Actions(output), ", "))
+	}
+	return resolved, nil
+}
+
+// resolveExtra reads snippet files and expands each snippet as a template with data
+func (r *Renderer) resolveExtra(extra *config.ExtraConfig, data *TemplateData) (*ResolvedExtraConfig, error) {
+	resolved := &ResolvedExtraConfig{Modes: make(map[string][]string, len(extra.Modes))}
+
+	var err error
+	if resolved.BeforeKeybindings, err = r.resolveSnippets("before_keybindings", extra.BeforeKeybindings, data); err != nil {
+		return nil, err
+	}
+	if resolved.AfterWorkspaces, err = r.resolveSnippets("after_workspaces", extra.AfterWorkspaces, data); err != nil {
+		return nil, err
+	}
+	if resolved.EndOfFile, err = r.resolveSnippets("end_of_file", extra.EndOfFile, data); err != nil {
+		return nil, err
+	}
+	for mode, snippets := range extra.Modes {
+		if resolved.Modes[mode], err = r.resolveSnippets("modes."+mode, snippets, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// resolveSnippets returns the expanded text of snippets; name identifies them in errors
+func (r *Renderer) resolveSnippets(name string, snippets []config.Snippet, data *TemplateData) ([]string, error) {
+	resolved := make([]string, 0, len(snippets))
+	for i, snippet := range snippets {
+		content, err := snippet.Content()
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		resolved = append(resolved, strings.TrimRight(buf.String(), "\n"))
+	}
+	return resolved, nil
+}
+
 // renderTemplate loads and renders the specified template file
 func (r *Renderer) renderTemplate(templateFile string, data *TemplateData) (string, error) {
 	var tmpl *template.Template
@@ -119,7 +442,7 @@ func (r *Renderer) renderTemplate(templateFile string, data *TemplateData) (stri
 		templatePath := filepath.Join(r.templateDir, templateFile)
 		if _, statErr := os.Stat(templatePath); statErr == nil {
 			// External template file exists, use it
-			tmpl, err = template.ParseFiles(templatePath)
+			tmpl, err = template.New(templateFile).Funcs(templateFuncs).ParseFiles(templatePath)
 			if err != nil {
 				return "", fmt.Errorf("failed to parse template %s: %w", templatePath, err)
 			}
@@ -128,11 +451,15 @@ func (r *Renderer) renderTemplate(templateFile string, data *TemplateData) (stri
 
 	// If no external template found, use embedded template
 	if tmpl == nil {
+		if !r.allowEmbedded {
+			return "", fmt.Errorf("template file not found: %s", filepath.Join(r.templateDir, templateFile))
+		}
+
 		templateContent, err := embeddedTemplates.ReadFile(templateFile)
 		if err != nil {
 			return "", fmt.Errorf("template file not found: %s (neither in filesystem nor embedded)", templateFile)
 		}
-		tmpl, err = template.New(templateFile).Parse(string(templateContent))
+		tmpl, err = template.New(templateFile).Funcs(templateFuncs).Parse(string(templateContent))
 		if err != nil {
 			return "", fmt.Errorf("failed to parse embedded template %s: %w", templateFile, err)
 		}
@@ -172,10 +499,28 @@ func (r *Renderer) RenderToFile(cfg *config.Config, layoutName string, detectedM
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	// Rendered secrets must not be readable by other users
+	perm := os.FileMode(0644)
+	if cfg.HasSecrets() {
+		perm = 0600
+	}
+
 	// Write the rendered configuration to file
-	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(outputPath, []byte(content), perm); err != nil {
 		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
+	// WriteFile keeps the mode of an existing file, so check what we ended up with
+	if cfg.HasSecrets() {
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat output file: %w", err)
+		}
+		if info.Mode().Perm()&0004 != 0 {
+			fmt.Fprintf(r.warnings, "warning: %s contains secrets but is world-readable (mode %04o); run: chmod 600 %s\n",
+				outputPath, info.Mode().Perm(), outputPath)
+		}
+	}
+
 	return nil
 }
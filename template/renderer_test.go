@@ -3,10 +3,12 @@ package template
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/a7d-corp/i3-config-generator-go/config"
+	"github.com/a7d-corp/i3-config-generator-go/i3conf"
 	"github.com/a7d-corp/i3-config-generator-go/monitor"
 )
 
@@ -23,20 +25,20 @@ func TestRenderer_resolveLayoutReferences(t *testing.T) {
 	layout := &config.LayoutConfig{
 		GapsInner: 20,
 		GapsOuter: 0,
-		MoveWorkspace: map[string]string{
-			"Ctrl+Shift+1": "left_display",
-			"Ctrl+Shift+2": "right_display",
-			"Ctrl+Shift+3": "primary_display",
+		MoveWorkspace: config.OrderedMap{
+			{Key: "Ctrl+Shift+1", Value: "left_display"},
+			{Key: "Ctrl+Shift+2", Value: "right_display"},
+			{Key: "Ctrl+Shift+3", Value: "primary_display"},
 		},
-		WorkspaceToDisplay: map[string]string{
-			"1": "left_display",
-			"2": "left_display",
-			"3": "right_display",
-			"4": "primary_display",
+		WorkspaceToDisplay: config.OrderedMap{
+			{Key: "1", Value: "left_display"},
+			{Key: "2", Value: "left_display"},
+			{Key: "3", Value: "right_display"},
+			{Key: "4", Value: "primary_display"},
 		},
 	}
 
-	resolved, err := renderer.resolveLayoutReferences(layout, detectedMonitors)
+	resolved, err := renderer.resolveLayoutReferences(layout, nil, detectedMonitors)
 	if err != nil {
 		t.Fatalf("Failed to resolve layout references: %v", err)
 	}
@@ -54,7 +56,7 @@ func TestRenderer_resolveLayoutReferences(t *testing.T) {
 	}
 
 	for keybind, expectedMonitor := range expectedMoveWorkspace {
-		if actualMonitor := resolved.MoveWorkspace[keybind]; actualMonitor != expectedMonitor {
+		if actualMonitor, _ := resolved.MoveWorkspace.Get(keybind); actualMonitor != expectedMonitor {
 			t.Errorf("MoveWorkspace[%s]: expected %s, got %s", keybind, expectedMonitor, actualMonitor)
 		}
 	}
@@ -68,10 +70,19 @@ func TestRenderer_resolveLayoutReferences(t *testing.T) {
 	}
 
 	for workspace, expectedMonitor := range expectedWorkspaceToDisplay {
-		if actualMonitor := resolved.WorkspaceToDisplay[workspace]; actualMonitor != expectedMonitor {
+		if actualMonitor, _ := resolved.WorkspaceToDisplay.Get(workspace); actualMonitor != expectedMonitor {
 			t.Errorf("WorkspaceToDisplay[%s]: expected %s, got %s", workspace, expectedMonitor, actualMonitor)
 		}
 	}
+
+	// Resolved entries keep the order of the layout definition
+	expectedOrder := []string{"1", "2", "3", "4"}
+	for i, key := range resolved.WorkspaceToDisplay.Keys() {
+		if key != expectedOrder[i] {
+			t.Errorf("WorkspaceToDisplay order: expected %v, got %v", expectedOrder, resolved.WorkspaceToDisplay.Keys())
+			break
+		}
+	}
 }
 
 func TestRenderer_resolveLayoutReferences_InvalidRole(t *testing.T) {
@@ -84,12 +95,12 @@ func TestRenderer_resolveLayoutReferences_InvalidRole(t *testing.T) {
 	}
 
 	layout := &config.LayoutConfig{
-		MoveWorkspace: map[string]string{
-			"Ctrl+Shift+1": "invalid_role",
+		MoveWorkspace: config.OrderedMap{
+			{Key: "Ctrl+Shift+1", Value: "invalid_role"},
 		},
 	}
 
-	_, err := renderer.resolveLayoutReferences(layout, detectedMonitors)
+	_, err := renderer.resolveLayoutReferences(layout, nil, detectedMonitors)
 	if err == nil {
 		t.Error("Expected error for invalid monitor role")
 	}
@@ -97,6 +108,72 @@ func TestRenderer_resolveLayoutReferences_InvalidRole(t *testing.T) {
 	if !strings.Contains(err.Error(), "unknown monitor role") {
 		t.Errorf("Expected error message about unknown monitor role, got: %v", err)
 	}
+
+	// Test: Roles without detected monitors, as with static monitor configuration
+	layout.MoveWorkspace[0].Value = "left_display"
+	_, err = renderer.resolveLayoutReferences(layout, nil, nil)
+	if err == nil || err.Error() != "move_workspace Ctrl+Shift+1: no monitors detected to resolve role left_display" {
+		t.Errorf("Expected error for missing monitors, got: %v", err)
+	}
+}
+
+func TestRenderer_resolveLayoutReferences_Assignments(t *testing.T) {
+	renderer := NewRenderer("")
+
+	cfg := &config.Config{
+		Assignments: []config.Assignment{
+			{
+				Match:            config.Criteria{Class: "^Slack$"},
+				AssignmentTarget: config.AssignmentTarget{Output: "right_display"},
+				Layouts:          map[string]config.AssignmentTarget{"no_mon": {Output: "primary_display"}},
+			},
+			{
+				Match:   config.Criteria{Class: "^Firefox$"},
+				Layouts: map[string]config.AssignmentTarget{"two_mon": {Workspace: "1: web"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		layout   string
+		monitors *monitor.DetectedMonitors
+		expected config.OrderedMap
+	}{
+		{
+			layout:   "two_mon",
+			monitors: &monitor.DetectedMonitors{Primary: "eDP-1", Left: "HDMI-1", Right: "DP-1", All: []string{"eDP-1", "HDMI-1", "DP-1"}},
+			expected: config.OrderedMap{
+				{Key: `[class="^Slack$"]`, Value: "output DP-1"},
+				{Key: `[class="^Firefox$"]`, Value: `workspace "1: web"`},
+			},
+		},
+		{
+			layout:   "no_mon",
+			monitors: &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}},
+			expected: config.OrderedMap{
+				{Key: `[class="^Slack$"]`, Value: "output eDP-1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			resolved, err := renderer.resolveLayoutReferences(&config.LayoutConfig{}, cfg.AssignmentsFor(tt.layout), tt.monitors)
+			if err != nil {
+				t.Fatalf("Failed to resolve layout references: %v", err)
+			}
+			if !reflect.DeepEqual(resolved.Assignments, tt.expected) {
+				t.Errorf("Expected assignments %v, got %v", tt.expected, resolved.Assignments)
+			}
+		})
+	}
+
+	// Test: Role without a detected monitor
+	onlyLaptop := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	_, err := renderer.resolveLayoutReferences(&config.LayoutConfig{}, cfg.AssignmentsFor("one_mon"), onlyLaptop)
+	if err == nil || !strings.Contains(err.Error(), "assignments[0]: no monitor detected for role right_display") {
+		t.Errorf("Expected error for undetected role, got %v", err)
+	}
 }
 
 func TestRenderer_renderTemplate(t *testing.T) {
@@ -119,8 +196,8 @@ gaps inner {{.Layout.GapsInner}}
 gaps outer {{.Layout.GapsOuter}}
 
 # Application bindings
-{{range $key, $value := .ApplicationBindings}}
-assign {{$key}} {{$value}}
+{{range .ApplicationBindings}}
+assign {{.Key}} {{.Value}}
 {{end}}
 
 # Startup programs
@@ -151,9 +228,9 @@ exec --no-startup-id xrandr --output {{.DetectedMonitors.Primary}} --primary
 			GapsInner: 20,
 			GapsOuter: 0,
 		},
-		ApplicationBindings: map[string]string{
-			"[class=\"^Firefox$\"]":  "1",
-			"[class=\"^Chromium$\"]": "3",
+		ApplicationBindings: config.OrderedMap{
+			{Key: "[class=\"^Firefox$\"]", Value: "1"},
+			{Key: "[class=\"^Chromium$\"]", Value: "3"},
 		},
 		StartupPrograms: []string{
 			"/usr/bin/numlockx on",
@@ -203,3 +280,487 @@ func TestRenderer_renderTemplate_FileNotFound(t *testing.T) {
 		t.Errorf("Expected error about template file not found, got: %v", err)
 	}
 }
+
+func TestRenderer_Render_EmbeddedTemplate(t *testing.T) {
+	renderer := NewRenderer("")
+
+	cfg := &config.Config{
+		I3: config.I3Config{ModKey: "Mod4", BarFont: "pango:DejaVu Sans 9"},
+		Layouts: map[string]config.LayoutConfig{
+			"no_mon": {GapsInner: 10},
+		},
+		Keyboard: config.KeyboardConfig{Layout: "gb", Variant: "extd"},
+		Hotkeys: []config.HotkeyConfig{
+			{Key: "XF86Mail", Command: "/usr/bin/blueberry", Description: "bluetooth"},
+			{Key: "$mod+Shift+s", Command: "~/.local/bin/floating-resize.sh", NoStartupID: true},
+		},
+		Extra: config.ExtraConfig{
+			EndOfFile: []config.Snippet{{Raw: "default_border pixel 1"}, {Raw: "mode \"launch\" {\n\tbindsym f exec firefox\n}"}},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{
+		Primary: "eDP-1",
+		All:     []string{"eDP-1"},
+	}
+
+	result, err := renderer.Render(cfg, "no_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render embedded template: %v", err)
+	}
+
+	expectedElements := []string{
+		"exec_always --no-startup-id setxkbmap gb -variant extd",
+		"bindsym $mod+d exec --no-startup-id dmenu_run",
+		"\tfont pango:DejaVu Sans 9\n",
+		"\tstatus_command i3status\n",
+		"font " + config.DefaultFont,
+		"bindsym $mod+Return exec --no-startup-id i3-sensible-terminal",
+		"set $locker i3lock",
+		"bindsym Control+mod1+l exec $locker",
+		"bindsym XF86AudioLowerVolume exec pactl set-sink-volume @DEFAULT_SINK@ -5%",
+		"bindsym XF86MonBrightnessUp exec brightnessctl set +10%",
+		"# bluetooth\nbindsym XF86Mail exec /usr/bin/blueberry",
+		"bindsym $mod+Shift+s exec --no-startup-id ~/.local/bin/floating-resize.sh",
+		"gaps inner 10",
+		"# -- extra config -- #\n\ndefault_border pixel 1\nmode \"launch\" {\n\tbindsym f exec firefox\n}",
+	}
+
+	for _, expected := range expectedElements {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected output to contain '%s', but it was missing", expected)
+		}
+	}
+
+	// Nothing user-specific may leak from the embedded template
+	for _, personal := range []string{"alacritty", "pamixer", "brillo", "rofi", "light-locker", "/home/"} {
+		if strings.Contains(result, personal) {
+			t.Errorf("Expected output not to contain '%s'", personal)
+		}
+	}
+
+	// A disabled bar is left out entirely
+	cfg.Bar.Disabled = true
+	result, err = renderer.Render(cfg, "no_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render embedded template: %v", err)
+	}
+	if strings.Contains(result, "bar {") || strings.Contains(result, "status_command") {
+		t.Errorf("Expected no bar in output, got:\n%s", result)
+	}
+}
+
+func TestRenderer_Render_Extra(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "renderer_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	snippetFile := filepath.Join(tempDir, "workspaces.conf")
+	if err := os.WriteFile(snippetFile, []byte("workspace 1 gaps inner 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write snippet file: %v", err)
+	}
+
+	cfg := &config.Config{
+		Layouts: map[string]config.LayoutConfig{
+			"one_mon": {WorkspaceToDisplay: config.OrderedMap{{Key: "1", Value: "primary_display"}}},
+		},
+		Extra: config.ExtraConfig{
+			BeforeKeybindings: []config.Snippet{{Raw: "default_border pixel 1"}},
+			AfterWorkspaces:   []config.Snippet{{File: snippetFile}},
+			EndOfFile:         []config.Snippet{{Raw: "exec --no-startup-id xset s off -display {{.DetectedMonitors.Primary}}"}},
+			Modes: map[string][]config.Snippet{
+				config.ExtraModeResize: {{Raw: "bindsym h resize shrink width 1 px\nbindsym l resize grow width 1 px"}},
+				config.ExtraModePower:  {{Raw: "bindsym x exec {{.Terminal}}"}},
+			},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	result, err := NewRenderer("").Render(cfg, "one_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	expectedElements := []string{
+		"client.urgent           $base02 $base08 $base07 $base08\n\n# -- extra config (before keybindings) -- #\n\ndefault_border pixel 1\n\n# -- set custom keybindings -- #",
+		"workspace 1 output eDP-1\n\n# -- extra config (after workspaces) -- #\n\nworkspace 1 gaps inner 0\n\n# -- per-application config -- #",
+		"\tbindsym Escape mode \"default\"\n\tbindsym h resize shrink width 1 px\n\tbindsym l resize grow width 1 px\n}",
+		"\tbindsym x exec i3-sensible-terminal\n}",
+		"# -- extra config -- #\n\nexec --no-startup-id xset s off -display eDP-1",
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected output to contain %q, but it was missing", expected)
+		}
+	}
+
+	// Test: Snippet that is not a valid template
+	cfg.Extra.EndOfFile = []config.Snippet{{Raw: "{{.Unknown"}}
+	if _, err := NewRenderer("").Render(cfg, "one_mon", detectedMonitors); err == nil {
+		t.Error("Expected error for invalid snippet template")
+	}
+
+	// Test: Missing snippet file
+	cfg.Extra.EndOfFile = []config.Snippet{{File: filepath.Join(tempDir, "missing.conf")}}
+	if _, err := NewRenderer("").Render(cfg, "one_mon", detectedMonitors); err == nil {
+		t.Error("Expected error for missing snippet file")
+	}
+}
+
+func TestRenderer_Render_WindowRules(t *testing.T) {
+	floating := true
+	cfg := &config.Config{
+		Layouts: map[string]config.LayoutConfig{"two_mon": {}},
+		ApplicationBindings: config.OrderedMap{
+			{Key: `[class="^Firefox$"]`, Value: "1"},
+		},
+		Assignments: []config.Assignment{
+			{Match: config.Criteria{Class: "^Slack$"}, AssignmentTarget: config.AssignmentTarget{Workspace: "3: chat"}},
+		},
+		WindowOverrides: []config.WindowRule{
+			{Raw: `[class="^mpv$"] floating enable`},
+			{Match: config.Criteria{Class: "^Pavucontrol$", Title: `say "hi"`}, Floating: &floating, Resize: &config.ResizeAction{Width: 800, Height: 600}},
+			{Match: config.Criteria{Class: "^Spotify$"}, MoveToOutput: "right_display"},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{
+		Primary: "eDP-1",
+		Left:    "HDMI-1",
+		Right:   "DP-1",
+		All:     []string{"eDP-1", "HDMI-1", "DP-1"},
+	}
+	result, err := NewRenderer("").Render(cfg, "two_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	expectedElements := []string{
+		`assign [class="^Firefox$"] 1`,
+		`assign [class="^Slack$"] workspace "3: chat"`,
+		`for_window [class="^mpv$"] floating enable`,
+		`for_window [class="^Pavucontrol$" title="say \"hi\""] floating enable, resize set 800 px 600 px`,
+		`for_window [class="^Spotify$"] move container to output DP-1`,
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected output to contain %q, but it was missing", expected)
+		}
+	}
+
+	// Test: Assignments are not written into spare capacity of the config's bindings
+	bindings := make(config.OrderedMap, 1, 4)
+	bindings[0] = config.MapEntry{Key: `[class="^Firefox$"]`, Value: "1"}
+	cfg.ApplicationBindings = bindings
+	if _, err := NewRenderer("").Render(cfg, "two_mon", detectedMonitors); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if spare := bindings[:2][1]; spare != (config.MapEntry{}) {
+		t.Errorf("Expected application bindings to be left alone, got %v", spare)
+	}
+
+	// Test: Role without a detected monitor
+	detectedMonitors.Right = ""
+	if _, err := NewRenderer("").Render(cfg, "two_mon", detectedMonitors); err == nil {
+		t.Error("Expected error for unresolved monitor role")
+	}
+}
+
+func TestRenderer_Render_LayoutOverrides(t *testing.T) {
+	bigFont := "pango:DejaVu Sans Mono 12"
+	noPrograms := []string{}
+	cfg := &config.Config{
+		I3:              config.I3Config{ModKey: "Mod4"},
+		StartupPrograms: []string{"~/bin/external-displays.sh"},
+		Layouts: map[string]config.LayoutConfig{
+			"two_mon": {},
+			"no_mon": {Overrides: config.LayoutOverrides{
+				I3:              &config.I3Config{Font: bigFont},
+				StartupPrograms: &noPrograms,
+			}},
+		},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	renderer := NewRenderer("")
+
+	result, err := renderer.Render(cfg, "no_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if !strings.Contains(result, "font "+bigFont) || strings.Contains(result, "external-displays.sh") {
+		t.Errorf("Expected no_mon overrides to be applied, got:\n%s", result)
+	}
+
+	result, err = renderer.Render(cfg, "two_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if !strings.Contains(result, "font "+config.DefaultFont) || !strings.Contains(result, "exec --no-startup-id ~/bin/external-displays.sh") {
+		t.Errorf("Expected global settings for two_mon, got:\n%s", result)
+	}
+}
+
+func TestRenderer_Render_Scaling(t *testing.T) {
+	cfg := &config.Config{
+		I3: config.I3Config{ModKey: "Mod4", Font: "pango:DejaVu Sans Mono 8"},
+		Layouts: map[string]config.LayoutConfig{
+			"no_mon": {GapsInner: 10, GapsOuter: 5},
+		},
+		Scaling: config.ScalingConfig{Rules: []config.ScalingRule{
+			{MinDPI: 120, Fonts: 1.5},
+			{MinDPI: 192, Fonts: 2, Borders: 2, Gaps: 1.5},
+		}},
+	}
+	cfg.ApplyDefaults()
+	renderer := NewRenderer("")
+
+	tests := []struct {
+		name     string
+		dpi      float64
+		expected []string
+	}{
+		{"unknown dpi", 0, []string{"font pango:DejaVu Sans Mono 8", "border pixel 2", "gaps inner 10", "gaps outer 5"}},
+		{"below all rules", 96, []string{"font pango:DejaVu Sans Mono 8", "border pixel 2", "gaps inner 10"}},
+		{"fonts only", 141.2, []string{"font pango:DejaVu Sans Mono 12", "border pixel 2", "gaps inner 10"}},
+		{"high dpi", 282.4, []string{"font pango:DejaVu Sans Mono 16", "border pixel 4", "gaps inner 15", "gaps outer 8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detectedMonitors := &monitor.DetectedMonitors{
+				Primary: "eDP-1",
+				All:     []string{"eDP-1"},
+				DPI:     map[string]float64{"eDP-1": tt.dpi},
+			}
+			result, err := renderer.Render(cfg, "no_mon", detectedMonitors)
+			if err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderer_Render_PerOutputWorkspaces(t *testing.T) {
+	cfg := &config.Config{
+		I3:               config.I3Config{ModKey: "Mod4"},
+		MonitorDetection: config.MonitorConfig{DummyMonitors: []string{"DUMMY-1"}},
+		Layouts: map[string]config.LayoutConfig{
+			"two_mon": {WorkspaceToDisplay: config.OrderedMap{{Key: "1", Value: "left_display"}}},
+		},
+		Workspaces: config.WorkspacesConfig{Scheme: config.WorkspaceSchemePerOutput},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{
+		Primary: "eDP-1",
+		Left:    "DP-1",
+		Right:   "DUMMY-1",
+		All:     []string{"eDP-1", "DP-1", "DUMMY-1"},
+	}
+	result, err := NewRenderer("").Render(cfg, "two_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	expected := []string{
+		"bindsym $mod+1 exec --no-startup-id i3-config-generator workspace --per-output 10 1\n",
+		"bindsym $mod+0 exec --no-startup-id i3-config-generator workspace --per-output 10 10\n",
+		"bindsym $mod+Shift+3 exec --no-startup-id i3-config-generator workspace --per-output 10 --move 3\n",
+		"workspace 1 output eDP-1\n",
+		"workspace 10 output eDP-1\n",
+		"workspace 11 output DP-1\n",
+		"workspace 20 output DP-1\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(result, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, result)
+		}
+	}
+
+	// The layout's own workspace assignments, fixed bindings and dummy monitors are not used
+	for _, line := range []string{"workspace 1 output DP-1", "bindsym $mod+1 workspace 1", "workspace 21 output"} {
+		if strings.Contains(result, line) {
+			t.Errorf("Expected output not to contain %q", line)
+		}
+	}
+}
+
+func TestRenderer_Render_Gaps(t *testing.T) {
+	inner, horizontal := 8, 16
+	cfg := &config.Config{
+		I3: config.I3Config{ModKey: "Mod4"},
+		Layouts: map[string]config.LayoutConfig{
+			"no_mon": {
+				GapsOuter: 2,
+				Gaps: config.GapsConfig{
+					GapSizes:        config.GapSizes{Inner: &inner, Horizontal: &horizontal},
+					Workspaces:      []config.WorkspaceGaps{{Workspace: "1", GapSizes: config.GapSizes{Inner: &horizontal}}},
+					SmartGaps:       "on",
+					SmartBorders:    "no_gaps",
+					HideEdgeBorders: "smart",
+				},
+			},
+		},
+		GapKeys: config.GapKeysConfig{Step: 2, Increase: "$mod+plus", DecreaseCurrent: config.NoKey},
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	result, err := NewRenderer("").Render(cfg, "no_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	expected := "gaps inner 8\ngaps outer 2\ngaps horizontal 16\nworkspace 1 gaps inner 16\n" +
+		"smart_gaps on\nsmart_borders no_gaps\nhide_edge_borders smart"
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected gaps section %q, got:\n%s", expected, result)
+	}
+
+	for _, line := range []string{
+		"bindsym $Mod+shift+g gaps inner all minus 2; gaps outer all minus 2\n",
+		"bindsym $mod+plus gaps inner all plus 2; gaps outer all plus 2\n",
+		"bindsym $Mod+shift+ctrl+h gaps inner current plus 2; gaps outer current plus 2\n",
+	} {
+		if !strings.Contains(result, line) {
+			t.Errorf("Expected output to contain %q", line)
+		}
+	}
+	if strings.Contains(result, "gaps inner current minus") {
+		t.Error("Expected the disabled decrease_current binding to be left out")
+	}
+}
+
+func TestQuoteCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"/usr/bin/pamixer -d 5", "/usr/bin/pamixer -d 5"},
+		{"/usr/bin/playerctl -p 'spotify,firefox' next", "\"/usr/bin/playerctl -p 'spotify,firefox' next\""},
+		{"notify-send \"a; b\"", "\"notify-send \\\"a; b\\\"\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if result := quoteCommand(tt.command); result != tt.expected {
+				t.Errorf("quoteCommand(%q) = %q, want %q", tt.command, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderer_RenderToFile_Secrets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "template_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	t.Setenv("I3CG_TEST_TOKEN", "s3cr3t")
+
+	configContent := `
+i3:
+  mod_key: "Mod4"
+default_layout: no_mon
+layouts:
+  no_mon:
+    gaps_inner: 0
+hotkeys:
+  - key: "$mod+XF86Launch7"
+    command: "curl -u webhook:${env:I3CG_TEST_TOKEN} https://example.com/hook"
+`
+	configPath := filepath.Join(tempDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := config.NewLoader(tempDir).LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	detectedMonitors := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	renderer := NewRenderer("")
+	var warnings strings.Builder
+	renderer.SetWarningOutput(&warnings)
+
+	// Test: New files are created readable by the owner only
+	outputPath := filepath.Join(tempDir, "i3", "config")
+	if err := renderer.RenderToFile(cfg, "no_mon", detectedMonitors, outputPath); err != nil {
+		t.Fatalf("Failed to render to file: %v", err)
+	}
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to stat output file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %04o", info.Mode().Perm())
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected no warnings, got %q", warnings.String())
+	}
+
+	// Test: Existing world-readable files trigger a warning
+	if err := os.Chmod(outputPath, 0644); err != nil {
+		t.Fatalf("Failed to chmod output file: %v", err)
+	}
+	if err := renderer.RenderToFile(cfg, "no_mon", detectedMonitors, outputPath); err != nil {
+		t.Fatalf("Failed to render to file: %v", err)
+	}
+	if !strings.Contains(warnings.String(), "world-readable") {
+		t.Errorf("Expected world-readable warning, got %q", warnings.String())
+	}
+	if strings.Contains(warnings.String(), "s3cr3t") {
+		t.Errorf("Warning must not contain the secret: %q", warnings.String())
+	}
+}
+
+func TestRenderer_Render_ImportedWindowRules(t *testing.T) {
+	rules := []string{
+		`for_window [class="mpv"] floating enable, border pixel 2, layout tabbed`,
+		`for_window [class="KeePassXC"] move scratchpad, scratchpad show`,
+		`for_window [class="Gimp"] layout tabbed, floating enable`,
+		`for_window [class="Steam"] border none, floating enable`,
+		`for_window [class="Zoom"] floating enable, floating disable`,
+		`for_window [window_role="pop-up"] floating enable, sticky enable`,
+	}
+	parsed, err := i3conf.Parse(strings.NewReader(strings.Join(rules, "\n")+"\n"), "")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	imported, err := config.ImportI3Config(parsed)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	cfg := &config.Config{
+		Layouts:         map[string]config.LayoutConfig{"one_mon": {}},
+		WindowOverrides: imported.WindowOverrides,
+	}
+	cfg.ApplyDefaults()
+
+	detectedMonitors := &monitor.DetectedMonitors{Primary: "eDP-1", All: []string{"eDP-1"}}
+	result, err := NewRenderer("").Render(cfg, "one_mon", detectedMonitors)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	// Imported rules render with their commands in the original order
+	for _, expected := range rules {
+		if !strings.Contains(result, expected+"\n") {
+			t.Errorf("Expected output to contain %q, but it was missing", expected)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/a7d-corp/i3-config-generator-go/cli"
+	"github.com/a7d-corp/i3-config-generator-go/config"
+)
+
+// i3Workspace is the part of i3's get_workspaces reply the workspace command needs
+type i3Workspace struct {
+	Num     int    `json:"num"`
+	Name    string `json:"name"`
+	Focused bool   `json:"focused"`
+	Output  string `json:"output"`
+}
+
+// runWorkspace switches to, or moves the focused container to, a workspace
+// within the range of the focused output
+func runWorkspace(args *cli.Args) error {
+	workspaces, err := getWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	current := focusedWorkspaceNumber(workspaces)
+	scheme := config.WorkspacesConfig{PerOutput: args.PerOutput}
+	target := strconv.Itoa(scheme.OnOutputOf(current, args.Workspace))
+
+	command := "workspace number " + target
+	if args.Move {
+		command = "move container to workspace number " + target
+	}
+	cmd := exec.Command("i3-msg", command)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run i3-msg %s: %w", command, err)
+	}
+	return nil
+}
+
+// getWorkspaces asks the running i3 instance for its workspaces
+func getWorkspaces() ([]i3Workspace, error) {
+	output, err := exec.Command("i3-msg", "-t", "get_workspaces").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query i3 workspaces: %w", err)
+	}
+	var workspaces []i3Workspace
+	if err := json.Unmarshal(output, &workspaces); err != nil {
+		return nil, fmt.Errorf("failed to parse i3 workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// focusedWorkspaceNumber returns the number of the focused workspace; when it is a
+// named workspace without a number, another numbered workspace on the same
+// output is used instead, and 0 when there is none
+func focusedWorkspaceNumber(workspaces []i3Workspace) int {
+	for _, focused := range workspaces {
+		if !focused.Focused {
+			continue
+		}
+		if focused.Num > 0 {
+			return focused.Num
+		}
+		for _, workspace := range workspaces {
+			if workspace.Output == focused.Output && workspace.Num > 0 {
+				return workspace.Num
+			}
+		}
+	}
+	return 0
+}